@@ -30,12 +30,21 @@ func main() {
 		logger.Fatal().Err(err).Msg(errors.ErrorFailedToConnectToTheDatabase)
 	}
 
-	container := di.NewContainer(db)
+	container, err := di.NewContainer(db, cfg)
+	if err != nil {
+		logger.Fatal().Err(err).Msg(errors.ErrorFailedToCreateTheContainer)
+	}
 
 	cancelTx := app.NewCancelTransactionProcess(container.CancelTransactionInteractor, cfg.Process)
 	go cancelTx.Run(ctx)
 
-	router := routers.NewRouter(container)
+	snapshots := app.NewBalanceSnapshotProcess(container.BalanceSnapshotInteractor, cfg.Snapshot)
+	go snapshots.Run(ctx)
+
+	reconciliation := app.NewReconciliationProcess(container.ReconciliationInteractor, cfg.Reconciliation)
+	go reconciliation.Run(ctx)
+
+	router := routers.NewRouter(container, cfg.Admin)
 	service := app.NewService(cfg)
 	service.Run(ctx, router)
 }
@@ -135,7 +135,7 @@ func printBalance() {
 	}
 
 	var balanceResponse struct {
-		Balance float64 `json:"balance"`
+		Balance string `json:"balance"`
 	}
 	err = json.NewDecoder(resp.Body).Decode(&balanceResponse)
 	if err != nil {
@@ -143,5 +143,5 @@ func printBalance() {
 		return
 	}
 
-	fmt.Printf("User balance: %.2f\n", balanceResponse.Balance)
+	fmt.Printf("User balance: %s\n", balanceResponse.Balance)
 }
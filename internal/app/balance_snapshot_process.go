@@ -0,0 +1,40 @@
+package app
+
+import (
+	"context"
+	"github.com/mufasadev/enlabs-test/internal/config"
+	"strconv"
+	"time"
+)
+
+type BalanceSnapshotHandler interface {
+	Execute(ctx context.Context) error
+}
+
+type BalanceSnapshotProcess struct {
+	handler BalanceSnapshotHandler
+	config  config.Snapshot
+}
+
+func NewBalanceSnapshotProcess(h BalanceSnapshotHandler, cfg config.Snapshot) *BalanceSnapshotProcess {
+	return &BalanceSnapshotProcess{handler: h, config: cfg}
+}
+
+// Run takes balance snapshots every interval until the context is done.
+func (p *BalanceSnapshotProcess) Run(ctx context.Context) error {
+	interval, err := strconv.Atoi(p.config.Interval)
+	if err != nil {
+		return err
+	}
+	ticker := time.NewTicker(time.Duration(interval) * time.Minute)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+			p.handler.Execute(ctx)
+		}
+	}
+}
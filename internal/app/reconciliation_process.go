@@ -0,0 +1,40 @@
+package app
+
+import (
+	"context"
+	"github.com/mufasadev/enlabs-test/internal/config"
+	"strconv"
+	"time"
+)
+
+type ReconciliationHandler interface {
+	Execute(ctx context.Context) error
+}
+
+type ReconciliationProcess struct {
+	handler ReconciliationHandler
+	config  config.Reconciliation
+}
+
+func NewReconciliationProcess(h ReconciliationHandler, cfg config.Reconciliation) *ReconciliationProcess {
+	return &ReconciliationProcess{handler: h, config: cfg}
+}
+
+// Run reconciles the user balances every interval until the context is done.
+func (p *ReconciliationProcess) Run(ctx context.Context) error {
+	interval, err := strconv.Atoi(p.config.Interval)
+	if err != nil {
+		return err
+	}
+	ticker := time.NewTicker(time.Duration(interval) * time.Minute)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+			p.handler.Execute(ctx)
+		}
+	}
+}
@@ -15,10 +15,38 @@ type Config struct {
 	Server
 	PostgreSQL
 	Process
+	Snapshot
+	Reconciliation
+	Admin
+	Money
 }
 
+// Process is the configuration for the cancel transaction process
 type Process struct {
-	Interval string `env:"PROCESS_INTERVAL" envDefault:"10"`
+	Interval        string `env:"PROCESS_INTERVAL" envDefault:"10"`
+	CancelStrategy  string `env:"CANCEL_STRATEGY" envDefault:"global_odd"`
+	CancelBatchSize string `env:"CANCEL_BATCH_SIZE" envDefault:"20"`
+	CancelWindow    string `env:"CANCEL_WINDOW" envDefault:"60"`
+}
+
+// Snapshot is the configuration for the balance snapshot process
+type Snapshot struct {
+	Interval string `env:"SNAPSHOT_INTERVAL" envDefault:"60"`
+}
+
+// Reconciliation is the configuration for the balance reconciliation process
+type Reconciliation struct {
+	Interval string `env:"RECONCILIATION_INTERVAL" envDefault:"60"`
+}
+
+// Money is the configuration for monetary amounts. The default currency must be in the currency registry.
+type Money struct {
+	Currency string `env:"CURRENCY" envDefault:"EUR"`
+}
+
+// Admin is the configuration for the admin API
+type Admin struct {
+	Token string `env:"ADMIN_TOKEN" envDefault:""`
 }
 
 // Server is the configuration for the server
@@ -1,10 +1,15 @@
 package di
 
 import (
+	"context"
+	"fmt"
 	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/mufasadev/enlabs-test/internal/config"
+	"github.com/mufasadev/enlabs-test/internal/domain/models"
 	"github.com/mufasadev/enlabs-test/internal/infrastructure/api/handlers"
 	"github.com/mufasadev/enlabs-test/internal/infrastructure/database/repositories"
 	"github.com/mufasadev/enlabs-test/internal/usecases/interactor"
+	"time"
 )
 
 type Container struct {
@@ -12,32 +17,110 @@ type Container struct {
 	SourceTypeInteractor        *interactor.SourceTypeInteractor
 	UserInteractor              *interactor.UserInteractor
 	CancelTransactionInteractor *interactor.CancelTransactionInteractor
+	BalanceSnapshotInteractor   *interactor.BalanceSnapshotInteractor
+	ReconciliationInteractor    *interactor.ReconciliationInteractor
 	BalanceHandler              *handlers.BalanceHandler
+	UserHandler                 *handlers.UserHandler
+	SourceHandler               *handlers.SourceHandler
+	CancellationRunHandler      *handlers.CancellationRunHandler
+	CancelJobHandler            *handlers.CancelJobHandler
+	ReconciliationHandler       *handlers.ReconciliationHandler
+	StatementHandler            *handlers.StatementHandler
+	TransferHandler             *handlers.TransferHandler
+	CurrencyHandler             *handlers.CurrencyHandler
+	FxRateHandler               *handlers.FxRateHandler
+	RoundHandler                *handlers.RoundHandler
 }
 
 // NewContainer creates a new Container instance.
-func NewContainer(db *pgxpool.Pool) *Container {
+func NewContainer(db *pgxpool.Pool, cfg *config.Config) (*Container, error) {
+	currencyRepository := repositories.NewCurrencyRepositoryImpl(db)
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	currency, err := currencyRepository.GetByCode(ctx, cfg.Money.Currency)
+	if err != nil {
+		return nil, err
+	}
+	if currency == nil {
+		return nil, fmt.Errorf("unknown currency %q", cfg.Money.Currency)
+	}
+	defaultCurrency, err := models.NewCurrency(currency.Code, currency.Scale)
+	if err != nil {
+		return nil, err
+	}
+	models.SetDefaultCurrency(defaultCurrency)
+
 	transactionRepository := repositories.NewTransactionRepositoryImpl(db)
 	userRepository := repositories.NewUserRepositoryImpl(db)
+	walletRepository := repositories.NewWalletRepositoryImpl(db)
 	sourceTypeRepository := repositories.NewSourceTypeRepositoryImpl(db)
+	cancellationRunRepository := repositories.NewCancellationRunRepositoryImpl(db)
+	balanceSnapshotRepository := repositories.NewBalanceSnapshotRepositoryImpl(db)
+	reconciliationRepository := repositories.NewReconciliationRepositoryImpl(db)
+	ledgerRepository := repositories.NewLedgerRepositoryImpl(db)
+	transferRepository := repositories.NewTransferRepositoryImpl(db)
+	fxRateRepository := repositories.NewFxRateRepositoryImpl(db)
+	roundRepository := repositories.NewRoundRepositoryImpl(db)
 
-	transactionInteractor := interactor.NewTransactionInteractor(transactionRepository, userRepository, sourceTypeRepository)
+	transactionInteractor := interactor.NewTransactionInteractor(transactionRepository, userRepository, sourceTypeRepository, currencyRepository, fxRateRepository)
 	transactionHandler := handlers.NewTransactionHandler(transactionInteractor)
 
+	transferInteractor := interactor.NewTransferInteractor(transferRepository, userRepository, currencyRepository)
+	transferHandler := handlers.NewTransferHandler(transferInteractor)
+
 	sourceTypeInteractor := interactor.NewSourceTypeInteractor(sourceTypeRepository)
+	sourceHandler := handlers.NewSourceHandler(sourceTypeInteractor)
+
+	userInteractor := interactor.NewUserInteractor(userRepository, walletRepository, currencyRepository)
+	userHandler := handlers.NewUserHandler(userInteractor)
+
+	cancellationStrategy, err := repositories.NewCancellationStrategy(cfg.Process)
+	if err != nil {
+		return nil, err
+	}
+	cancelTransactionInteractor := interactor.NewCancelTransactionInteractor(transactionRepository, cancellationRunRepository, cancellationStrategy)
+
+	cancelJobHandler := handlers.NewCancelJobHandler(cancelTransactionInteractor)
+
+	cancellationRunInteractor := interactor.NewCancellationRunInteractor(cancellationRunRepository)
+	cancellationRunHandler := handlers.NewCancellationRunHandler(cancellationRunInteractor)
+
+	balanceInteractor := interactor.NewUserInteractor(userRepository, walletRepository, currencyRepository)
+	balanceSnapshotInteractor := interactor.NewBalanceSnapshotInteractor(balanceSnapshotRepository, walletRepository, userRepository)
+	balanceHandler := handlers.NewBalanceHandler(balanceInteractor, balanceSnapshotInteractor)
 
-	userInteractor := interactor.NewUserInteractor(userRepository)
+	reconciliationInteractor := interactor.NewReconciliationInteractor(reconciliationRepository)
+	reconciliationHandler := handlers.NewReconciliationHandler(reconciliationInteractor)
 
-	cancelTransactionInteractor := interactor.NewCancelTransactionInteractor(transactionRepository)
+	statementInteractor := interactor.NewStatementInteractor(ledgerRepository, currencyRepository)
+	statementHandler := handlers.NewStatementHandler(statementInteractor)
 
-	balanceInteractor := interactor.NewUserInteractor(userRepository)
-	balanceHandler := handlers.NewBalanceHandler(balanceInteractor)
+	currencyInteractor := interactor.NewCurrencyInteractor(currencyRepository)
+	currencyHandler := handlers.NewCurrencyHandler(currencyInteractor)
+
+	fxRateInteractor := interactor.NewFxRateInteractor(fxRateRepository, currencyRepository)
+	fxRateHandler := handlers.NewFxRateHandler(fxRateInteractor)
+
+	roundInteractor := interactor.NewRoundInteractor(roundRepository)
+	roundHandler := handlers.NewRoundHandler(roundInteractor)
 
 	return &Container{
 		TransactionHandler:          transactionHandler,
 		SourceTypeInteractor:        sourceTypeInteractor,
 		UserInteractor:              userInteractor,
 		CancelTransactionInteractor: cancelTransactionInteractor,
+		BalanceSnapshotInteractor:   balanceSnapshotInteractor,
+		ReconciliationInteractor:    reconciliationInteractor,
 		BalanceHandler:              balanceHandler,
-	}
+		UserHandler:                 userHandler,
+		SourceHandler:               sourceHandler,
+		CancellationRunHandler:      cancellationRunHandler,
+		CancelJobHandler:            cancelJobHandler,
+		ReconciliationHandler:       reconciliationHandler,
+		StatementHandler:            statementHandler,
+		TransferHandler:             transferHandler,
+		CurrencyHandler:             currencyHandler,
+		FxRateHandler:               fxRateHandler,
+		RoundHandler:                roundHandler,
+	}, nil
 }
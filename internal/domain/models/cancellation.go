@@ -0,0 +1,28 @@
+package models
+
+import (
+	"github.com/shopspring/decimal"
+	"time"
+)
+
+// CancellationRun is one execution of the cancel process.
+type CancellationRun struct {
+	ID             string     `db:"id"`
+	Strategy       string     `db:"strategy"`
+	StartedAt      time.Time  `db:"started_at"`
+	FinishedAt     *time.Time `db:"finished_at"`
+	CancelledCount int        `db:"cancelled_count"`
+	Error          *string    `db:"error"`
+}
+
+// Cancellation records a transaction reversed by a cancellation run and its effect on the user balance.
+type Cancellation struct {
+	ID            string          `db:"id"`
+	RunID         string          `db:"run_id"`
+	TransactionID string          `db:"transaction_id"`
+	UserID        string          `db:"user_id"`
+	Currency      Currency        `db:"currency"`
+	BalanceBefore decimal.Decimal `db:"balance_before"`
+	BalanceAfter  decimal.Decimal `db:"balance_after"`
+	CreatedAt     time.Time       `db:"created_at"`
+}
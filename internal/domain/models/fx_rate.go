@@ -0,0 +1,26 @@
+package models
+
+import (
+	"github.com/shopspring/decimal"
+	"time"
+)
+
+// MaxRateScale is the largest number of decimal places the rate columns store.
+const MaxRateScale = 12
+
+// FxRate is the price of one unit of the base currency in the quote currency, valid from a point in time until
+// the next rate of the pair.
+type FxRate struct {
+	ID            int64
+	BaseCurrency  string
+	QuoteCurrency string
+	Rate          decimal.Decimal
+	ValidFrom     time.Time
+	CreatedAt     time.Time
+}
+
+// Convert converts an amount of the base currency to the currency, which must be the quote currency. The result is
+// rounded half away from zero to the scale of the currency.
+func (r FxRate) Convert(amount decimal.Decimal, currency Currency) Money {
+	return NewMoney(amount.Mul(r.Rate).Round(currency.Scale), currency)
+}
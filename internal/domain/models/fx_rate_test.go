@@ -0,0 +1,24 @@
+package models
+
+import (
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestFxRateConvert(t *testing.T) {
+	rate := FxRate{BaseCurrency: "USD", QuoteCurrency: "EUR", Rate: decimal.RequireFromString("0.923456789012")}
+	eur := Currency{Code: "EUR", Scale: 2}
+
+	converted := rate.Convert(decimal.RequireFromString("10.00"), eur)
+	assert.Equal(t, "9.23", converted.String())
+	assert.Equal(t, eur, converted.Currency)
+
+	// half a cent is rounded away from zero
+	rate.Rate = decimal.RequireFromString("0.5")
+	assert.Equal(t, "0.01", rate.Convert(decimal.RequireFromString("0.01"), eur).String())
+
+	btc := Currency{Code: "BTC", Scale: 8}
+	rate = FxRate{BaseCurrency: "EUR", QuoteCurrency: "BTC", Rate: decimal.RequireFromString("0.000016")}
+	assert.Equal(t, "0.00016000", rate.Convert(decimal.RequireFromString("10"), btc).String())
+}
@@ -0,0 +1,57 @@
+package models
+
+import (
+	"github.com/shopspring/decimal"
+	"time"
+)
+
+// Ledger entry types.
+const (
+	EntryTypeOpening      = "opening"
+	EntryTypeTransaction  = "transaction"
+	EntryTypeCancellation = "cancellation"
+	EntryTypeAdjustment   = "adjustment"
+)
+
+// Ledger account types. A user account holds the player's funds, a source account is the
+// house side of a game provider and the equity account balances opening entries and adjustments.
+const (
+	AccountTypeUser   = "user"
+	AccountTypeSource = "source"
+	AccountTypeEquity = "equity"
+)
+
+// EquityAccountID is the id of the single equity account.
+const EquityAccountID = "00000000-0000-0000-0000-000000000000"
+
+// Entry directions. Credits increase an account balance, debits decrease it.
+const (
+	DirectionDebit  = "debit"
+	DirectionCredit = "credit"
+)
+
+// LedgerEntry is one leg of a balanced posting. Every posting writes a debit and a credit of the same amount
+// in the same currency.
+type LedgerEntry struct {
+	ID            string          `db:"id"`
+	PostingID     string          `db:"posting_id"`
+	TransactionID *string         `db:"transaction_id"`
+	EntryType     string          `db:"entry_type"`
+	AccountType   string          `db:"account_type"`
+	AccountID     string          `db:"account_id"`
+	Direction     string          `db:"direction"`
+	Amount        decimal.Decimal `db:"amount"`
+	Currency      string          `db:"currency"`
+	CreatedAt     time.Time       `db:"created_at"`
+}
+
+// StatementLine is a change of a user balance as shown on an account statement. The amount is signed and
+// transaction fields are nil for entries not posted for a transaction.
+type StatementLine struct {
+	EntryType     string          `db:"entry_type"`
+	TransactionID *string         `db:"transaction_id"`
+	State         *string         `db:"state"`
+	Source        *string         `db:"source"`
+	Amount        decimal.Decimal `db:"amount"`
+	CreatedAt     time.Time       `db:"created_at"`
+}
@@ -0,0 +1,97 @@
+package models
+
+import (
+	"fmt"
+	apperrors "github.com/mufasadev/enlabs-test/internal/errors"
+	"github.com/shopspring/decimal"
+	"strings"
+)
+
+// MaxScale is the largest number of decimal places the amount columns store.
+const MaxScale = 8
+
+// maxAmount is the exclusive upper bound of an amount, the amount columns hold 20 integer digits.
+var maxAmount = decimal.New(1, 20)
+
+// Currency is a currency code and the number of decimal places of its minor unit.
+type Currency struct {
+	Code  string
+	Scale int32
+}
+
+// NewCurrency validates a three-letter currency code and the scale of its minor unit.
+func NewCurrency(code string, scale int32) (Currency, error) {
+	if len(code) != 3 || strings.ToUpper(code) != code {
+		return Currency{}, fmt.Errorf("invalid currency code %q", code)
+	}
+	if scale < 0 || scale > MaxScale {
+		return Currency{}, fmt.Errorf("currency scale must be between 0 and %d", MaxScale)
+	}
+	return Currency{Code: code, Scale: scale}, nil
+}
+
+// defaultCurrency is the currency used when a request names none. It is set once at startup.
+var defaultCurrency = Currency{Code: "EUR", Scale: 2}
+
+// DefaultCurrency returns the currency used when a request names none.
+func DefaultCurrency() Currency {
+	return defaultCurrency
+}
+
+// SetDefaultCurrency sets the currency used when a request names none.
+func SetDefaultCurrency(currency Currency) {
+	defaultCurrency = currency
+}
+
+// Format formats the amount with the scale of the currency.
+func (c Currency) Format(amount decimal.Decimal) string {
+	return amount.StringFixed(c.Scale)
+}
+
+// Money is an amount in a currency. Money without a currency is in the default currency.
+type Money struct {
+	decimal.Decimal
+	Currency Currency
+}
+
+// NewMoney returns the amount in the currency.
+func NewMoney(amount decimal.Decimal, currency Currency) Money {
+	return Money{Decimal: amount, Currency: currency}
+}
+
+// ParseMoney parses a positive amount of the currency. Negative and zero amounts and amounts with more
+// decimal places than the scale of the currency are rejected instead of being rounded.
+func ParseMoney(value string, currency Currency) (Money, error) {
+	amount, err := decimal.NewFromString(value)
+	if err != nil {
+		return Money{}, apperrors.NewBadRequestError("Invalid amount")
+	}
+	if !amount.IsPositive() {
+		return Money{}, apperrors.NewBadRequestError("Amount must be positive")
+	}
+	if !amount.Equal(amount.Round(currency.Scale)) {
+		return Money{}, apperrors.NewBadRequestError(fmt.Sprintf("Amount must have at most %d decimal places", currency.Scale))
+	}
+	if amount.GreaterThanOrEqual(maxAmount) {
+		return Money{}, apperrors.NewBadRequestError("Amount is too large")
+	}
+	return NewMoney(amount, currency), nil
+}
+
+// CurrencyOrDefault returns the currency of the money, or the default currency when it has none.
+func (m Money) CurrencyOrDefault() Currency {
+	if m.Currency.Code == "" {
+		return DefaultCurrency()
+	}
+	return m.Currency
+}
+
+// String formats the amount with the scale of its currency.
+func (m Money) String() string {
+	return m.CurrencyOrDefault().Format(m.Decimal)
+}
+
+// MarshalJSON encodes the amount as a string with the scale of its currency.
+func (m Money) MarshalJSON() ([]byte, error) {
+	return []byte(`"` + m.String() + `"`), nil
+}
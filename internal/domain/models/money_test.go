@@ -0,0 +1,67 @@
+package models
+
+import (
+	"encoding/json"
+	"errors"
+	apperrors "github.com/mufasadev/enlabs-test/internal/errors"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestParseMoney(t *testing.T) {
+	eur := Currency{Code: "EUR", Scale: 2}
+	jpy := Currency{Code: "JPY", Scale: 0}
+
+	cases := []struct {
+		value    string
+		currency Currency
+		valid    bool
+	}{
+		{"10.50", eur, true},
+		{"10.5", eur, true},
+		{"10.500", eur, true},
+		{"99999999999999999999.99", eur, true},
+		{"100", jpy, true},
+		{"10.505", eur, false},
+		{"100.5", jpy, false},
+		{"0", eur, false},
+		{"0.00", eur, false},
+		{"-10.50", eur, false},
+		{"100000000000000000000", eur, false},
+		{"ten", eur, false},
+		{"", eur, false},
+	}
+
+	for _, c := range cases {
+		money, err := ParseMoney(c.value, c.currency)
+		if c.valid {
+			assert.NoError(t, err, c.value)
+			assert.Equal(t, c.currency, money.Currency)
+		} else {
+			var badRequest *apperrors.BadRequestError
+			assert.True(t, errors.As(err, &badRequest), c.value)
+		}
+	}
+}
+
+func TestMoneyFormat(t *testing.T) {
+	money := NewMoney(decimal.RequireFromString("1234.5"), Currency{Code: "BHD", Scale: 3})
+	assert.Equal(t, "1234.500", money.String())
+
+	encoded, err := json.Marshal(money)
+	assert.NoError(t, err)
+	assert.Equal(t, `"1234.500"`, string(encoded))
+
+	// money without a currency is formatted in the default one
+	assert.Equal(t, "1234.50", Money{Decimal: decimal.RequireFromString("1234.5")}.String())
+}
+
+func TestNewCurrency(t *testing.T) {
+	_, err := NewCurrency("EUR", 2)
+	assert.NoError(t, err)
+	_, err = NewCurrency("eur", 2)
+	assert.Error(t, err)
+	_, err = NewCurrency("EUR", MaxScale+1)
+	assert.Error(t, err)
+}
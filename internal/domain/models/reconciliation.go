@@ -0,0 +1,29 @@
+package models
+
+import (
+	"github.com/shopspring/decimal"
+	"time"
+)
+
+// ReconciliationRun is one execution of the balance reconciliation.
+type ReconciliationRun struct {
+	ID             string     `db:"id"`
+	StartedAt      time.Time  `db:"started_at"`
+	FinishedAt     *time.Time `db:"finished_at"`
+	WalletsChecked int        `db:"wallets_checked"`
+	DriftedCount   int        `db:"drifted_count"`
+	Error          *string    `db:"error"`
+}
+
+// BalanceDrift is a wallet whose stored balance does not match the balance expected from the transactions
+// or from the ledger.
+type BalanceDrift struct {
+	ID              string          `db:"id"`
+	RunID           string          `db:"run_id"`
+	UserID          string          `db:"user_id"`
+	Currency        Currency        `db:"currency"`
+	ExpectedBalance decimal.Decimal `db:"expected_balance"`
+	ActualBalance   decimal.Decimal `db:"actual_balance"`
+	LedgerBalance   decimal.Decimal `db:"ledger_balance"`
+	CreatedAt       time.Time       `db:"created_at"`
+}
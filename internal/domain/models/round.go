@@ -0,0 +1,62 @@
+package models
+
+import (
+	"fmt"
+	apperrors "github.com/mufasadev/enlabs-test/internal/errors"
+	"github.com/shopspring/decimal"
+	"time"
+)
+
+// RoundStatus is the lifecycle state of a game round.
+type RoundStatus string
+
+const (
+	// RoundStatusOpen is a round that accepts bets and wins.
+	RoundStatusOpen RoundStatus = "open"
+	// RoundStatusClosed is a settled round. Its transactions stay applied.
+	RoundStatusClosed RoundStatus = "closed"
+	// RoundStatusRolledBack is a round whose applied transactions were all reversed.
+	RoundStatusRolledBack RoundStatus = "rolled_back"
+)
+
+// roundStatusTransitions lists the statuses every round status may move to. Closed and rolled back are final.
+var roundStatusTransitions = map[RoundStatus][]RoundStatus{
+	RoundStatusOpen: {RoundStatusClosed, RoundStatusRolledBack},
+}
+
+// CanTransitionTo reports whether a round in status s may move to next.
+func (s RoundStatus) CanTransitionTo(next RoundStatus) bool {
+	for _, allowed := range roundStatusTransitions[s] {
+		if allowed == next {
+			return true
+		}
+	}
+	return false
+}
+
+// Round groups a bet and its matching wins under the round id of the sender. All transactions of a round belong
+// to one user, source and wallet currency.
+type Round struct {
+	ID         string
+	RoundID    string
+	UserID     string
+	SourceType SourceType
+	Currency   Currency
+	Status     RoundStatus
+	// BetAmount and WinAmount are the sums of the applied and cancelled lost and win transactions of the round,
+	// so a rolled back round keeps its totals.
+	BetAmount  decimal.Decimal
+	WinAmount  decimal.Decimal
+	CreatedAt  time.Time
+	UpdatedAt  time.Time
+	FinishedAt *time.Time
+}
+
+// TransitionTo moves the round to the next status or fails with a conflict if the transition is not allowed.
+func (r *Round) TransitionTo(next RoundStatus) error {
+	if !r.Status.CanTransitionTo(next) {
+		return apperrors.NewConflictError(fmt.Sprintf("Round %s is %s", r.RoundID, r.Status))
+	}
+	r.Status = next
+	return nil
+}
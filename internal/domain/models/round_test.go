@@ -0,0 +1,36 @@
+package models
+
+import (
+	"errors"
+	apperrors "github.com/mufasadev/enlabs-test/internal/errors"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestRoundStatusTransitions(t *testing.T) {
+	cases := []struct {
+		from    RoundStatus
+		to      RoundStatus
+		allowed bool
+	}{
+		{RoundStatusOpen, RoundStatusClosed, true},
+		{RoundStatusOpen, RoundStatusRolledBack, true},
+		{RoundStatusClosed, RoundStatusRolledBack, false},
+		{RoundStatusClosed, RoundStatusOpen, false},
+		{RoundStatusRolledBack, RoundStatusClosed, false},
+		{RoundStatusRolledBack, RoundStatusOpen, false},
+	}
+
+	for _, c := range cases {
+		round := &Round{RoundID: "r-1", Status: c.from}
+		err := round.TransitionTo(c.to)
+		if c.allowed {
+			assert.NoError(t, err, "%s -> %s", c.from, c.to)
+			assert.Equal(t, c.to, round.Status)
+		} else {
+			var conflict *apperrors.ConflictError
+			assert.True(t, errors.As(err, &conflict), "%s -> %s", c.from, c.to)
+			assert.Equal(t, c.from, round.Status)
+		}
+	}
+}
@@ -1,6 +1,48 @@
 package models
 
+import (
+	"github.com/shopspring/decimal"
+	"regexp"
+	"time"
+)
+
+// SourceNamePattern is the format of source names sent in the Source-Type header.
+var SourceNamePattern = regexp.MustCompile(`^[a-z0-9_-]{1,20}$`)
+
 type SourceType struct {
-	ID   string `json:"id"`
-	Name string `json:"name"`
+	ID          string                 `json:"id"`
+	Name        string                 `json:"name"`
+	DisplayName string                 `json:"display_name"`
+	Enabled     bool                   `json:"enabled"`
+	Settings    map[string]interface{} `json:"settings"`
+	Limits      SourceLimits           `json:"limits"`
+	States      []string               `json:"states"`
+	CreatedAt   time.Time              `json:"created_at"`
+	UpdatedAt   time.Time              `json:"updated_at"`
+}
+
+// DefaultSourceStates are the transaction states a new source may send.
+var DefaultSourceStates = []string{StateWin, StateLost}
+
+// AllowsState reports whether the source may send transactions of the state.
+func (s *SourceType) AllowsState(state string) bool {
+	for _, allowed := range s.States {
+		if allowed == state {
+			return true
+		}
+	}
+	return false
+}
+
+// SourceLimits caps the amounts a source can send. A nil limit is unlimited.
+// Daily volumes are counted per UTC day from applied transactions.
+type SourceLimits struct {
+	MaxAmount    *decimal.Decimal `json:"max_amount"`
+	MaxDailyWin  *decimal.Decimal `json:"max_daily_win"`
+	MaxDailyLoss *decimal.Decimal `json:"max_daily_loss"`
+}
+
+// IsSet reports whether any limit is set.
+func (l SourceLimits) IsSet() bool {
+	return l.MaxAmount != nil || l.MaxDailyWin != nil || l.MaxDailyLoss != nil
 }
@@ -1,6 +1,41 @@
 package models
 
-const winState = "win"
-const lostState = "lost" // lose
+// Transaction kinds sent in the state field.
+const (
+	StateWin             = "win"
+	StateLost            = "lost" // lose
+	StateDeposit         = "deposit"
+	StateWithdrawal      = "withdrawal"
+	StateBonus           = "bonus"
+	StateRefund          = "refund"
+	StateFee             = "fee"
+	StateAdjustment      = "adjustment"
+	StateAdjustmentDebit = "adjustment_debit" // corrects an over-credit
+)
 
-var ValidStates = map[string]bool{winState: true, lostState: false}
+// TransactionKind describes how a transaction state changes the user balance. The sources allowed to send a kind
+// are stored with the source, see SourceType.States.
+type TransactionKind struct {
+	State string
+	// Sign is 1 for kinds crediting the user balance and -1 for kinds debiting it.
+	Sign int
+}
+
+// TransactionKinds is the registry of transaction kinds by state.
+var TransactionKinds = map[string]TransactionKind{
+	StateWin:             {State: StateWin, Sign: 1},
+	StateLost:            {State: StateLost, Sign: -1},
+	StateDeposit:         {State: StateDeposit, Sign: 1},
+	StateWithdrawal:      {State: StateWithdrawal, Sign: -1},
+	StateBonus:           {State: StateBonus, Sign: 1},
+	StateRefund:          {State: StateRefund, Sign: 1},
+	StateFee:             {State: StateFee, Sign: -1},
+	StateAdjustment:      {State: StateAdjustment, Sign: 1},
+	StateAdjustmentDebit: {State: StateAdjustmentDebit, Sign: -1},
+}
+
+// GetTransactionKind returns the kind of a transaction state.
+func GetTransactionKind(state string) (TransactionKind, bool) {
+	kind, ok := TransactionKinds[state]
+	return kind, ok
+}
@@ -5,14 +5,31 @@ import (
 	"time"
 )
 
+// Transaction is a balance change of a user. Amount is in the currency of the wallet it changes. A transaction sent
+// in another currency keeps the amount as sent in OriginalAmount and the rate it was converted with in FxRate.
+// Metadata holds the correlation data of the sender and is never interpreted. RoundID is the round id of the sender
+// for a bet or win of a game round. LimitAmount is the amount in the default currency the limits of the source are
+// checked with, it is only set for a transaction in another currency.
 type Transaction struct {
-	ID            string          `db:"id"`
-	TransactionID string          `db:"transaction_id"`
-	State         string          `db:"state"`
-	Amount        decimal.Decimal `db:"amount"`
-	SourceType    SourceType      `db:"source_id"`
-	Processed     bool            `db:"processed"`
-	User          User            `db:"user_id"`
-	CreatedAt     time.Time       `db:"created_at"`
-	UpdatedAt     time.Time       `db:"updated_at"`
+	ID             string                 `db:"id"`
+	TransactionID  string                 `db:"transaction_id"`
+	State          string                 `db:"state"`
+	Amount         Money                  `db:"amount"`
+	OriginalAmount *Money                 `db:"original_amount"`
+	FxRate         *decimal.Decimal       `db:"fx_rate"`
+	LimitAmount    *Money                 `db:"-"`
+	Metadata       map[string]interface{} `db:"metadata"`
+	RoundID        string                 `db:"round_id"`
+	SourceType     SourceType             `db:"source_id"`
+	Status         TransactionStatus      `db:"status"`
+	User           User                   `db:"user_id"`
+	BalanceAfter   *decimal.Decimal       `db:"balance_after"`
+	CreatedAt      time.Time              `db:"created_at"`
+	UpdatedAt      time.Time              `db:"updated_at"`
+	CancelledAt    *time.Time             `db:"-"`
+}
+
+// Sign returns 1 when the transaction credits the user balance and -1 when it debits it.
+func (t *Transaction) Sign() int {
+	return TransactionKinds[t.State].Sign
 }
@@ -0,0 +1,62 @@
+package models
+
+import apperrors "github.com/mufasadev/enlabs-test/internal/errors"
+
+// TransactionStatus is the lifecycle state of a transaction.
+type TransactionStatus string
+
+const (
+	// StatusPending is a transaction that was accepted but not applied to the balance yet.
+	StatusPending TransactionStatus = "pending"
+	// StatusApplied is a transaction that changed the user balance.
+	StatusApplied TransactionStatus = "applied"
+	// StatusRejectedInsufficientFunds is a transaction that would have made the balance negative.
+	StatusRejectedInsufficientFunds TransactionStatus = "rejected_insufficient_funds"
+	// StatusCancelled is an applied transaction that was reversed by the cancel process.
+	StatusCancelled TransactionStatus = "cancelled"
+)
+
+// statusTransitions lists the statuses every status may move to. Rejected and cancelled are final.
+var statusTransitions = map[TransactionStatus][]TransactionStatus{
+	StatusPending: {StatusApplied, StatusRejectedInsufficientFunds},
+	StatusApplied: {StatusCancelled},
+}
+
+// IsValid reports whether s is a known status.
+func (s TransactionStatus) IsValid() bool {
+	switch s {
+	case StatusPending, StatusApplied, StatusRejectedInsufficientFunds, StatusCancelled:
+		return true
+	}
+	return false
+}
+
+// CanTransitionTo reports whether a transaction in status s may move to next.
+func (s TransactionStatus) CanTransitionTo(next TransactionStatus) bool {
+	for _, allowed := range statusTransitions[s] {
+		if allowed == next {
+			return true
+		}
+	}
+	return false
+}
+
+// StatusesTransitionableTo returns every status that may move to next.
+func StatusesTransitionableTo(next TransactionStatus) []TransactionStatus {
+	statuses := make([]TransactionStatus, 0)
+	for from := range statusTransitions {
+		if from.CanTransitionTo(next) {
+			statuses = append(statuses, from)
+		}
+	}
+	return statuses
+}
+
+// TransitionTo moves the transaction to the next status or fails if the transition is not allowed.
+func (t *Transaction) TransitionTo(next TransactionStatus) error {
+	if !t.Status.CanTransitionTo(next) {
+		return apperrors.NewInvalidStatusTransitionError(string(t.Status), string(next))
+	}
+	t.Status = next
+	return nil
+}
@@ -0,0 +1,40 @@
+package models
+
+import (
+	"errors"
+	apperrors "github.com/mufasadev/enlabs-test/internal/errors"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestTransactionStatusTransitions(t *testing.T) {
+	cases := []struct {
+		from    TransactionStatus
+		to      TransactionStatus
+		allowed bool
+	}{
+		{StatusPending, StatusApplied, true},
+		{StatusPending, StatusRejectedInsufficientFunds, true},
+		{StatusApplied, StatusCancelled, true},
+		{StatusPending, StatusCancelled, false},
+		{StatusRejectedInsufficientFunds, StatusApplied, false},
+		{StatusRejectedInsufficientFunds, StatusCancelled, false},
+		{StatusCancelled, StatusApplied, false},
+		{StatusApplied, StatusPending, false},
+	}
+
+	for _, c := range cases {
+		transaction := &Transaction{Status: c.from}
+		err := transaction.TransitionTo(c.to)
+		if c.allowed {
+			assert.NoError(t, err, "%s -> %s", c.from, c.to)
+			assert.Equal(t, c.to, transaction.Status)
+		} else {
+			var transitionErr *apperrors.InvalidStatusTransitionError
+			assert.True(t, errors.As(err, &transitionErr), "%s -> %s", c.from, c.to)
+			assert.Equal(t, c.from, transaction.Status)
+		}
+	}
+
+	assert.Equal(t, []TransactionStatus{StatusApplied}, StatusesTransitionableTo(StatusCancelled))
+}
@@ -0,0 +1,28 @@
+package models
+
+import (
+	"github.com/shopspring/decimal"
+	"time"
+)
+
+// TransferSourceID is the reserved source both transactions of a transfer are booked to.
+const TransferSourceID = "5f0c2d4e-7a1b-4c3d-9e8f-0a1b2c3d4e5f"
+
+// Transfer moves funds from one user to another. It is stored with a lost transaction of the sender and
+// a win transaction of the recipient, both linked to the transfer.
+type Transfer struct {
+	ID               string            `db:"id"`
+	TransferID       string            `db:"transfer_id"`
+	FromUserID       string            `db:"from_user_id"`
+	ToUserID         string            `db:"to_user_id"`
+	Amount           Money             `db:"amount"`
+	Status           TransactionStatus `db:"status"`
+	FromBalanceAfter decimal.Decimal   `db:"-"`
+	ToBalanceAfter   decimal.Decimal   `db:"-"`
+	CreatedAt        time.Time         `db:"created_at"`
+}
+
+// TransferTransactionID returns the transaction id of one side of a transfer.
+func TransferTransactionID(transferID string, state string) string {
+	return "transfer:" + transferID + ":" + state
+}
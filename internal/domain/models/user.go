@@ -1,9 +1,15 @@
 package models
 
-import "github.com/shopspring/decimal"
+import (
+	"github.com/shopspring/decimal"
+	"time"
+)
 
+// User is a player. Balance and CreditLimit are those of the wallet in the default currency.
 type User struct {
-	ID      string          `json:"id"`
-	Balance decimal.Decimal `json:"balance"`
-	Account string          `json:"account_number"`
+	ID          string          `json:"id"`
+	Balance     decimal.Decimal `json:"balance"`
+	CreditLimit decimal.Decimal `json:"credit_limit"`
+	Account     string          `json:"account_number"`
+	CreatedAt   time.Time       `json:"created_at"`
 }
@@ -0,0 +1,16 @@
+package models
+
+import (
+	"github.com/shopspring/decimal"
+	"time"
+)
+
+// Wallet is the balance of a user in one currency. The balance may go below zero down to -CreditLimit.
+type Wallet struct {
+	UserID      string          `db:"user_id"`
+	Currency    Currency        `db:"currency"`
+	Balance     decimal.Decimal `db:"balance"`
+	CreditLimit decimal.Decimal `db:"credit_limit"`
+	CreatedAt   time.Time       `db:"created_at"`
+	UpdatedAt   time.Time       `db:"updated_at"`
+}
@@ -0,0 +1,12 @@
+package repositories
+
+import (
+	"context"
+	"github.com/shopspring/decimal"
+	"time"
+)
+
+type BalanceSnapshotRepository interface {
+	TakeSnapshots(ctx context.Context, at time.Time) (int, error)
+	GetUserBalanceAt(ctx context.Context, userID string, currency string, at time.Time) (decimal.Decimal, error)
+}
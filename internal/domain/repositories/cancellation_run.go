@@ -0,0 +1,14 @@
+package repositories
+
+import (
+	"context"
+	"github.com/mufasadev/enlabs-test/internal/domain/models"
+)
+
+type CancellationRunRepository interface {
+	Create(ctx context.Context, strategy string) (*models.CancellationRun, error)
+	Finish(ctx context.Context, id string, cancelledCount int, runErr error) error
+	GetByID(ctx context.Context, id string) (*models.CancellationRun, error)
+	List(ctx context.Context, after *Cursor, limit int) ([]models.CancellationRun, error)
+	ListItems(ctx context.Context, runID string) ([]models.Cancellation, error)
+}
@@ -0,0 +1,11 @@
+package repositories
+
+import (
+	"context"
+	"github.com/mufasadev/enlabs-test/internal/domain/models"
+)
+
+type CurrencyRepository interface {
+	GetByCode(ctx context.Context, code string) (*models.Currency, error)
+	List(ctx context.Context) ([]models.Currency, error)
+}
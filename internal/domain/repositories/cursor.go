@@ -0,0 +1,9 @@
+package repositories
+
+import "time"
+
+// Cursor points at the last row of a page, ordered by (timestamp, id) descending.
+type Cursor struct {
+	CreatedAt time.Time
+	ID        string
+}
@@ -0,0 +1,20 @@
+package repositories
+
+import (
+	"context"
+	"github.com/mufasadev/enlabs-test/internal/domain/models"
+	"time"
+)
+
+// RateProvider returns the rate converting the base currency to the quote currency at an instant, or nil when
+// the pair has no rate at that instant.
+type RateProvider interface {
+	GetRate(ctx context.Context, base string, quote string, at time.Time) (*models.FxRate, error)
+}
+
+// FxRateRepository stores the rates of the fx_rates table and provides them to conversions.
+type FxRateRepository interface {
+	RateProvider
+	Create(ctx context.Context, rate *models.FxRate) error
+	ListCurrent(ctx context.Context) ([]models.FxRate, error)
+}
@@ -0,0 +1,15 @@
+package repositories
+
+import (
+	"context"
+	"github.com/mufasadev/enlabs-test/internal/domain/models"
+	"github.com/shopspring/decimal"
+	"time"
+)
+
+type LedgerRepository interface {
+	GetAccountBalance(ctx context.Context, accountType string, accountID string, currency string) (decimal.Decimal, error)
+	GetByTransactionID(ctx context.Context, transactionID string) ([]models.LedgerEntry, error)
+	CountUnbalancedPostings(ctx context.Context) (int, error)
+	StreamUserStatement(ctx context.Context, userID string, currency string, from time.Time, to time.Time, opening func(balance decimal.Decimal) error, line func(l *models.StatementLine) error) error
+}
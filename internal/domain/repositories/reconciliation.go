@@ -0,0 +1,15 @@
+package repositories
+
+import (
+	"context"
+	"github.com/mufasadev/enlabs-test/internal/domain/models"
+)
+
+type ReconciliationRepository interface {
+	Create(ctx context.Context) (*models.ReconciliationRun, error)
+	Reconcile(ctx context.Context, runID string) (walletsChecked int, drifts []models.BalanceDrift, err error)
+	Finish(ctx context.Context, id string, walletsChecked int, driftedCount int, runErr error) error
+	GetByID(ctx context.Context, id string) (*models.ReconciliationRun, error)
+	List(ctx context.Context, after *Cursor, limit int) ([]models.ReconciliationRun, error)
+	ListDrifts(ctx context.Context, runID string) ([]models.BalanceDrift, error)
+}
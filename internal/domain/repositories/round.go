@@ -0,0 +1,12 @@
+package repositories
+
+import (
+	"context"
+	"github.com/mufasadev/enlabs-test/internal/domain/models"
+)
+
+type RoundRepository interface {
+	GetByRoundID(ctx context.Context, userID string, roundID string) (*models.Round, error)
+	Close(ctx context.Context, userID string, roundID string) (*models.Round, error)
+	Rollback(ctx context.Context, userID string, roundID string) (*models.Round, []CancelOddTransactionsAndUpdateBalanceRow, error)
+}
@@ -7,4 +7,9 @@ import (
 
 type SourceTypeRepository interface {
 	GetByName(ctx context.Context, name string) (*models.SourceType, error)
+	GetByID(ctx context.Context, id string) (*models.SourceType, error)
+	List(ctx context.Context) ([]models.SourceType, error)
+	Create(ctx context.Context, source *models.SourceType) error
+	Update(ctx context.Context, source *models.SourceType) error
+	Delete(ctx context.Context, id string) error
 }
@@ -4,19 +4,52 @@ import (
 	"context"
 	"github.com/mufasadev/enlabs-test/internal/domain/models"
 	"github.com/shopspring/decimal"
+	"time"
 )
 
 const (
-	SerializationError   = "40001"
-	UniqueViolationError = "23505"
+	SerializationError       = "40001"
+	UniqueViolationError     = "23505"
+	ForeignKeyViolationError = "23503"
+	CheckViolationError      = "23514"
 )
 
 type TransactionRepository interface {
 	GetByTransactionID(ctx context.Context, transactionID string) (*models.Transaction, error)
 	InsertTransactionAndUpdateUserBalanceWithoutCreatingTransaction(ctx context.Context, transaction *models.Transaction) (TransactionRow, error)
-	GetUserBalance(ctx context.Context, userId string) (*decimal.Decimal, error)
+	GetUserBalance(ctx context.Context, userId string, currency string) (*decimal.Decimal, error)
 	InsertTransactionAndUpdateUserBalanceWithCreatingTransaction(ctx context.Context, transaction *models.Transaction) (TransactionRow, error)
+	InsertTransactionsAndUpdateUserBalance(ctx context.Context, transactions []*models.Transaction) ([]TransactionRow, error)
 	CancelOddTransactionsAndUpdateBalance(ctx context.Context) ([]CancelOddTransactionsAndUpdateBalanceRow, error)
+	CancelTransactionsAndUpdateBalance(ctx context.Context, strategy CancellationStrategy, runID string) ([]CancelOddTransactionsAndUpdateBalanceRow, error)
+	PreviewCancelTransactions(ctx context.Context, strategy CancellationStrategy) ([]CancellationPreviewRow, error)
+	List(ctx context.Context, filter TransactionFilter) ([]models.Transaction, error)
+}
+
+// CancellationStrategy selects the transactions the cancel process tries to reverse.
+type CancellationStrategy interface {
+	// Name identifies the strategy in logs.
+	Name() string
+	// CandidatesQuery returns a query selecting the id, user_id, state, sign, amount, currency and created_at
+	// of the applied transactions to cancel, and the arguments of the query. Transfers are never cancelled, and the
+	// cancel process only cancels win and lost transactions.
+	CandidatesQuery() (string, []interface{})
+}
+
+// TransactionFilter narrows down a transaction history query. Empty fields are not applied.
+type TransactionFilter struct {
+	UserID   string
+	State    string
+	Currency string
+	Source   string
+	Status   string
+	From     *time.Time
+	To       *time.Time
+	// Metadata matches transactions whose top-level metadata keys hold the values, compared as text
+	Metadata map[string]string
+	RoundID  string
+	After    *Cursor
+	Limit    int
 }
 
 type CancelOddTransactionsAndUpdateBalanceRow struct {
@@ -25,11 +58,28 @@ type CancelOddTransactionsAndUpdateBalanceRow struct {
 	TransactionId string
 	State         string
 	Amount        decimal.Decimal
+	Currency      models.Currency
+	BalanceBefore decimal.Decimal
+	BalanceAfter  decimal.Decimal
+}
+
+// CancellationPreviewRow is a candidate of the cancel process. TransactionId is the external transaction id and
+// UserBalance is the balance the wallet of the currency would have after the whole run.
+type CancellationPreviewRow struct {
+	UserId        string
+	UserBalance   decimal.Decimal
+	TransactionId string
+	State         string
+	Amount        decimal.Decimal
+	Currency      models.Currency
+	Cancellable   bool
+	BalanceBefore decimal.Decimal
+	BalanceAfter  decimal.Decimal
 }
 
 type TransactionRow struct {
 	UserId        string
-	UserBalance   float64
+	UserBalance   models.Money
 	TransactionId string
-	Processed     bool
+	Status        models.TransactionStatus
 }
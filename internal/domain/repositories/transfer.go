@@ -0,0 +1,11 @@
+package repositories
+
+import (
+	"context"
+	"github.com/mufasadev/enlabs-test/internal/domain/models"
+)
+
+type TransferRepository interface {
+	Create(ctx context.Context, transfer *models.Transfer) error
+	GetByTransferID(ctx context.Context, transferID string) (*models.Transfer, error)
+}
@@ -7,5 +7,8 @@ import (
 
 type UserRepository interface {
 	GetByID(ctx context.Context, id string) (*models.User, error)
+	GetByAccountNumber(ctx context.Context, accountNumber string) (*models.User, error)
+	List(ctx context.Context, after *Cursor, limit int) ([]models.User, error)
+	Create(ctx context.Context) (*models.User, error)
 	Update(ctx context.Context, user *models.User) error
 }
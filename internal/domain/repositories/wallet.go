@@ -0,0 +1,11 @@
+package repositories
+
+import (
+	"context"
+	"github.com/mufasadev/enlabs-test/internal/domain/models"
+)
+
+type WalletRepository interface {
+	ListByUserID(ctx context.Context, userID string) ([]models.Wallet, error)
+	SetCreditLimit(ctx context.Context, wallet *models.Wallet) error
+}
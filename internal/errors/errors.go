@@ -7,16 +7,51 @@ import (
 
 const (
 	ErrFailedCancelOddTransactions    = "Failed to cancel odd transactions"
+	ErrFailedTakeBalanceSnapshots     = "Failed to take balance snapshots"
+	ErrFailedPreviewCancellation      = "Failed to preview cancellation"
+	ErrFailedCreateCancellationRun    = "Failed to create cancellation run"
+	ErrFailedFinishCancellationRun    = "Failed to finish cancellation run"
+	ErrFailedListCancellationRuns     = "Failed to list cancellation runs"
+	ErrFailedGetCancellationRun       = "Failed to get cancellation run"
+	ErrFailedReconcileBalances        = "Failed to reconcile balances"
+	ErrFailedCreateReconciliationRun  = "Failed to create reconciliation run"
+	ErrFailedFinishReconciliationRun  = "Failed to finish reconciliation run"
+	ErrFailedListReconciliationRuns   = "Failed to list reconciliation runs"
+	ErrFailedGetReconciliationRun     = "Failed to get reconciliation run"
 	ErrorFailedToConnectToTheDatabase = "Failed to connect to the database"
+	ErrorFailedToCreateTheContainer   = "Failed to create the container"
 	ErrorFailedToRunTheServer         = "Failed to run the server"
 	ErrorFailedToShutdownTheServer    = "Failed to shutdown the server"
 	ErrFailedDecodeRequestBody        = "Failed to decode request body"
 	ErrInvalidRequestBody             = "Invalid request body"
 	ErrFailedProcessTransaction       = "Failed to process transaction"
+	ErrFailedProcessBatch             = "Failed to process transaction batch"
+	ErrFailedCreateTransfer           = "Failed to create transfer"
+	ErrFailedListTransactions         = "Failed to list transactions"
+	ErrFailedGetTransaction           = "Failed to get transaction"
+	ErrInvalidQueryParameters         = "Invalid query parameters"
+	ErrFailedCreateUser               = "Failed to create user"
+	ErrFailedGetUser                  = "Failed to get user"
+	ErrFailedListUsers                = "Failed to list users"
+	ErrFailedSetCreditLimit           = "Failed to set credit limit"
+	ErrFailedGetBalance               = "Failed to get balance"
+	ErrFailedListCurrencies           = "Failed to list currencies"
+	ErrFailedListFxRates              = "Failed to list exchange rates"
+	ErrFailedGetRound                 = "Failed to get round"
+	ErrFailedCloseRound               = "Failed to close round"
+	ErrFailedRollbackRound            = "Failed to roll back round"
+	ErrFailedCreateFxRate             = "Failed to create exchange rate"
+	ErrFailedWriteStatement           = "Failed to write statement"
 	ErrSourceTypeRequired             = "Source-Type is required"
 	ErrInvalidSourceType              = "Invalid Source-Type"
+	ErrFailedCreateSource             = "Failed to create source"
+	ErrFailedUpdateSource             = "Failed to update source"
+	ErrFailedDeleteSource             = "Failed to delete source"
+	ErrFailedGetSource                = "Failed to get source"
+	ErrFailedListSources              = "Failed to list sources"
 	ErrUserIDRequired                 = "User ID is required"
 	ErrInvalidUserID                  = "Invalid User ID"
+	ErrInvalidAdminToken              = "Invalid admin token"
 )
 
 type BadRequestError struct {
@@ -31,6 +66,30 @@ func (e *BadRequestError) Error() string {
 	return fmt.Sprintf("Bad request: %s", e.Message)
 }
 
+type NotFoundError struct {
+	Message string
+}
+
+func NewNotFoundError(message string) *NotFoundError {
+	return &NotFoundError{Message: message}
+}
+
+func (e *NotFoundError) Error() string {
+	return fmt.Sprintf("Not found: %s", e.Message)
+}
+
+type UnauthorizedError struct {
+	Message string
+}
+
+func NewUnauthorizedError(message string) *UnauthorizedError {
+	return &UnauthorizedError{Message: message}
+}
+
+func (e *UnauthorizedError) Error() string {
+	return fmt.Sprintf("Unauthorized: %s", e.Message)
+}
+
 type InsufficientFundsError struct{}
 
 func NewInsufficientFundsError() *InsufficientFundsError {
@@ -51,6 +110,65 @@ func (e *TransactionDuplicateError) Error() string {
 	return "transaction already exists"
 }
 
+type ConflictError struct {
+	Message string
+}
+
+func NewConflictError(message string) *ConflictError {
+	return &ConflictError{Message: message}
+}
+
+func (e *ConflictError) Error() string {
+	return fmt.Sprintf("Conflict: %s", e.Message)
+}
+
+type SourceDisabledError struct {
+	Source string
+}
+
+func NewSourceDisabledError(source string) *SourceDisabledError {
+	return &SourceDisabledError{Source: source}
+}
+
+func (e *SourceDisabledError) Error() string {
+	return fmt.Sprintf("source %s is disabled", e.Source)
+}
+
+type LimitExceededError struct {
+	Limit string
+}
+
+func NewLimitExceededError(limit string) *LimitExceededError {
+	return &LimitExceededError{Limit: limit}
+}
+
+func (e *LimitExceededError) Error() string {
+	return fmt.Sprintf("source limit %s exceeded", e.Limit)
+}
+
+type TransactionConflictError struct{}
+
+func NewTransactionConflictError() *TransactionConflictError {
+	return &TransactionConflictError{}
+}
+
+func (e *TransactionConflictError) Error() string {
+	return "transaction already exists with a different payload"
+}
+
+type InvalidStatusTransitionError struct {
+	From string
+	To   string
+}
+
+func NewInvalidStatusTransitionError(from, to string) *InvalidStatusTransitionError {
+	return &InvalidStatusTransitionError{From: from, To: to}
+}
+
+func (e *InvalidStatusTransitionError) Error() string {
+	return fmt.Sprintf("invalid transaction status transition from %s to %s", e.From, e.To)
+}
+
 func Is(err, target error) bool {
 	return errors.Is(err, target)
 }
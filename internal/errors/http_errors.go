@@ -5,37 +5,99 @@ import (
 	"net/http"
 )
 
+// Error codes returned in the errorCode field of HTTP errors.
+const (
+	CodeBadRequest          = "bad_request"
+	CodeUnauthorized        = "unauthorized"
+	CodeNotFound            = "not_found"
+	CodeConflict            = "conflict"
+	CodeInsufficientFunds   = "insufficient_funds"
+	CodeDuplicate           = "duplicate_transaction"
+	CodeTransactionConflict = "transaction_conflict"
+	CodeSourceDisabled      = "source_disabled"
+	CodeLimitExceeded       = "limit_exceeded"
+	CodeInternal            = "internal_error"
+)
+
 type HTTPError struct {
-	Code    int    `json:"code"`
-	Message string `json:"message"`
+	Code      int    `json:"code"`
+	ErrorCode string `json:"errorCode"`
+	Message   string `json:"message"`
 }
 
-// HandleHTTPError handles http errors
-func HandleHTTPError(w http.ResponseWriter, err error) {
+// NewHTTPError maps an error to its HTTP representation.
+func NewHTTPError(err error) *HTTPError {
 	var httpErr *HTTPError
 	switch e := err.(type) {
 	case *BadRequestError:
 		httpErr = &HTTPError{
-			Code:    http.StatusBadRequest,
-			Message: e.Error(),
+			Code:      http.StatusBadRequest,
+			ErrorCode: CodeBadRequest,
+			Message:   e.Error(),
+		}
+	case *NotFoundError:
+		httpErr = &HTTPError{
+			Code:      http.StatusNotFound,
+			ErrorCode: CodeNotFound,
+			Message:   e.Error(),
+		}
+	case *UnauthorizedError:
+		httpErr = &HTTPError{
+			Code:      http.StatusUnauthorized,
+			ErrorCode: CodeUnauthorized,
+			Message:   e.Error(),
 		}
 	case *InsufficientFundsError:
 		httpErr = &HTTPError{
-			Code:    http.StatusBadRequest,
-			Message: e.Error(),
+			Code:      http.StatusBadRequest,
+			ErrorCode: CodeInsufficientFunds,
+			Message:   e.Error(),
 		}
 	case *TransactionDuplicateError:
 		httpErr = &HTTPError{
-			Code:    http.StatusUnprocessableEntity,
-			Message: e.Error(),
+			Code:      http.StatusUnprocessableEntity,
+			ErrorCode: CodeDuplicate,
+			Message:   e.Error(),
+		}
+	case *ConflictError:
+		httpErr = &HTTPError{
+			Code:      http.StatusConflict,
+			ErrorCode: CodeConflict,
+			Message:   e.Error(),
+		}
+	case *SourceDisabledError:
+		httpErr = &HTTPError{
+			Code:      http.StatusForbidden,
+			ErrorCode: CodeSourceDisabled,
+			Message:   e.Error(),
+		}
+	case *LimitExceededError:
+		httpErr = &HTTPError{
+			Code:      http.StatusTooManyRequests,
+			ErrorCode: CodeLimitExceeded,
+			Message:   e.Error(),
+		}
+	case *TransactionConflictError:
+		httpErr = &HTTPError{
+			Code:      http.StatusConflict,
+			ErrorCode: CodeTransactionConflict,
+			Message:   e.Error(),
 		}
 	default:
 		httpErr = &HTTPError{
-			Code:    http.StatusInternalServerError,
-			Message: "Internal server error",
+			Code:      http.StatusInternalServerError,
+			ErrorCode: CodeInternal,
+			Message:   "Internal server error",
 		}
 	}
 
+	return httpErr
+}
+
+// HandleHTTPError handles http errors
+func HandleHTTPError(w http.ResponseWriter, err error) {
+	httpErr := NewHTTPError(err)
+
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(httpErr.Code)
 	json.NewEncoder(w).Encode(httpErr)
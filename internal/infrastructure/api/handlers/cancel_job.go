@@ -0,0 +1,50 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/mufasadev/enlabs-test/internal/errors"
+	"github.com/mufasadev/enlabs-test/internal/usecases/interactor"
+	"github.com/mufasadev/enlabs-test/pkg/log"
+	"github.com/rs/zerolog"
+	"net/http"
+	"time"
+)
+
+type CancelJobHandler struct {
+	interactor *interactor.CancelTransactionInteractor
+	logger     *zerolog.Logger
+}
+
+func NewCancelJobHandler(interactor *interactor.CancelTransactionInteractor) *CancelJobHandler {
+	logger := log.GetLogger()
+	return &CancelJobHandler{interactor: interactor, logger: &logger}
+}
+
+func (h *CancelJobHandler) Preview(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	preview, err := h.interactor.Preview(ctx)
+	if err != nil {
+		h.logger.Error().Err(err).Msg(errors.ErrFailedPreviewCancellation)
+		errors.HandleHTTPError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(preview)
+}
+
+func (h *CancelJobHandler) Run(w http.ResponseWriter, r *http.Request) {
+	// the interactor times the run once it holds the lock, so waiting for a scheduled run does not use it up
+	run, err := h.interactor.Run(context.Background())
+	if err != nil {
+		h.logger.Error().Err(err).Msg(errors.ErrFailedCancelOddTransactions)
+		errors.HandleHTTPError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(run)
+}
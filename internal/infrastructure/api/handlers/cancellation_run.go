@@ -0,0 +1,79 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/go-chi/chi/v5"
+	"github.com/mufasadev/enlabs-test/internal/errors"
+	http2 "github.com/mufasadev/enlabs-test/internal/infrastructure/api/http"
+	"github.com/mufasadev/enlabs-test/internal/usecases/dtos"
+	"github.com/mufasadev/enlabs-test/internal/usecases/interactor"
+	"github.com/mufasadev/enlabs-test/pkg/log"
+	"github.com/rs/zerolog"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+type CancellationRunHandler struct {
+	interactor *interactor.CancellationRunInteractor
+	logger     *zerolog.Logger
+}
+
+func NewCancellationRunHandler(interactor *interactor.CancellationRunInteractor) *CancellationRunHandler {
+	logger := log.GetLogger()
+	return &CancellationRunHandler{interactor: interactor, logger: &logger}
+}
+
+func (h *CancellationRunHandler) ListRuns(w http.ResponseWriter, r *http.Request) {
+	query, err := parsePageQuery(r)
+	if err != nil {
+		h.logger.Error().Err(err).Msg(errors.ErrInvalidQueryParameters)
+		errors.HandleHTTPError(w, err)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	page, err := h.interactor.ListRuns(ctx, query)
+	if err != nil {
+		h.logger.Error().Err(err).Msg(errors.ErrFailedListCancellationRuns)
+		errors.HandleHTTPError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(page)
+}
+
+func (h *CancellationRunHandler) GetRun(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	run, err := h.interactor.GetRun(ctx, chi.URLParam(r, http2.RunIDParam))
+	if err != nil {
+		h.logger.Error().Err(err).Msg(errors.ErrFailedGetCancellationRun)
+		errors.HandleHTTPError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(run)
+}
+
+// parsePageQuery reads the cursor and limit from the query string.
+func parsePageQuery(r *http.Request) (*dtos.PageQuery, error) {
+	values := r.URL.Query()
+	query := &dtos.PageQuery{Cursor: values.Get("cursor")}
+
+	if v := values.Get("limit"); v != "" {
+		limit, err := strconv.Atoi(v)
+		if err != nil || limit <= 0 {
+			return nil, errors.NewBadRequestError("Invalid limit")
+		}
+		query.Limit = limit
+	}
+
+	return query, nil
+}
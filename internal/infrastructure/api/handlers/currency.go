@@ -0,0 +1,38 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/mufasadev/enlabs-test/internal/errors"
+	"github.com/mufasadev/enlabs-test/internal/usecases/interactor"
+	"github.com/mufasadev/enlabs-test/pkg/log"
+	"github.com/rs/zerolog"
+	"net/http"
+	"time"
+)
+
+type CurrencyHandler struct {
+	interactor *interactor.CurrencyInteractor
+	logger     *zerolog.Logger
+}
+
+func NewCurrencyHandler(interactor *interactor.CurrencyInteractor) *CurrencyHandler {
+	logger := log.GetLogger()
+	return &CurrencyHandler{interactor: interactor, logger: &logger}
+}
+
+// ListCurrencies returns the currencies wallets can be held in.
+func (h *CurrencyHandler) ListCurrencies(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	currencies, err := h.interactor.ListCurrencies(ctx)
+	if err != nil {
+		h.logger.Error().Err(err).Msg(errors.ErrFailedListCurrencies)
+		errors.HandleHTTPError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(currencies)
+}
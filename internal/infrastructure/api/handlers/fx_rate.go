@@ -0,0 +1,62 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/mufasadev/enlabs-test/internal/errors"
+	"github.com/mufasadev/enlabs-test/internal/usecases/dtos"
+	"github.com/mufasadev/enlabs-test/internal/usecases/interactor"
+	"github.com/mufasadev/enlabs-test/pkg/log"
+	"github.com/rs/zerolog"
+	"net/http"
+	"time"
+)
+
+type FxRateHandler struct {
+	interactor *interactor.FxRateInteractor
+	logger     *zerolog.Logger
+}
+
+func NewFxRateHandler(interactor *interactor.FxRateInteractor) *FxRateHandler {
+	logger := log.GetLogger()
+	return &FxRateHandler{interactor: interactor, logger: &logger}
+}
+
+// ListRates returns the current rate of every currency pair.
+func (h *FxRateHandler) ListRates(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	rates, err := h.interactor.ListRates(ctx)
+	if err != nil {
+		h.logger.Error().Err(err).Msg(errors.ErrFailedListFxRates)
+		errors.HandleHTTPError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(rates)
+}
+
+// CreateRate records a new rate of a currency pair.
+func (h *FxRateHandler) CreateRate(w http.ResponseWriter, r *http.Request) {
+	var dto dtos.FxRateDTO
+	if err := json.NewDecoder(r.Body).Decode(&dto); err != nil {
+		h.logger.Error().Err(err).Msg(errors.ErrFailedDecodeRequestBody)
+		errors.HandleHTTPError(w, errors.NewBadRequestError(errors.ErrInvalidRequestBody))
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	rate, err := h.interactor.CreateRate(ctx, &dto)
+	if err != nil {
+		h.logger.Error().Err(err).Msg(errors.ErrFailedCreateFxRate)
+		errors.HandleHTTPError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(rate)
+}
@@ -0,0 +1,76 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/go-chi/chi/v5"
+	"github.com/mufasadev/enlabs-test/internal/errors"
+	http2 "github.com/mufasadev/enlabs-test/internal/infrastructure/api/http"
+	"github.com/mufasadev/enlabs-test/internal/usecases/interactor"
+	"github.com/mufasadev/enlabs-test/pkg/log"
+	"github.com/rs/zerolog"
+	"net/http"
+	"time"
+)
+
+type ReconciliationHandler struct {
+	interactor *interactor.ReconciliationInteractor
+	logger     *zerolog.Logger
+}
+
+func NewReconciliationHandler(interactor *interactor.ReconciliationInteractor) *ReconciliationHandler {
+	logger := log.GetLogger()
+	return &ReconciliationHandler{interactor: interactor, logger: &logger}
+}
+
+func (h *ReconciliationHandler) Run(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	run, err := h.interactor.Run(ctx)
+	if err != nil {
+		h.logger.Error().Err(err).Msg(errors.ErrFailedReconcileBalances)
+		errors.HandleHTTPError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(run)
+}
+
+func (h *ReconciliationHandler) ListRuns(w http.ResponseWriter, r *http.Request) {
+	query, err := parsePageQuery(r)
+	if err != nil {
+		h.logger.Error().Err(err).Msg(errors.ErrInvalidQueryParameters)
+		errors.HandleHTTPError(w, err)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	page, err := h.interactor.ListRuns(ctx, query)
+	if err != nil {
+		h.logger.Error().Err(err).Msg(errors.ErrFailedListReconciliationRuns)
+		errors.HandleHTTPError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(page)
+}
+
+func (h *ReconciliationHandler) GetRun(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	run, err := h.interactor.GetRun(ctx, chi.URLParam(r, http2.RunIDParam))
+	if err != nil {
+		h.logger.Error().Err(err).Msg(errors.ErrFailedGetReconciliationRun)
+		errors.HandleHTTPError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(run)
+}
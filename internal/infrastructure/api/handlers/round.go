@@ -0,0 +1,72 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/go-chi/chi/v5"
+	"github.com/mufasadev/enlabs-test/internal/errors"
+	http2 "github.com/mufasadev/enlabs-test/internal/infrastructure/api/http"
+	"github.com/mufasadev/enlabs-test/internal/usecases/interactor"
+	"github.com/mufasadev/enlabs-test/pkg/log"
+	"github.com/rs/zerolog"
+	"net/http"
+	"time"
+)
+
+type RoundHandler struct {
+	interactor *interactor.RoundInteractor
+	logger     *zerolog.Logger
+}
+
+func NewRoundHandler(interactor *interactor.RoundInteractor) *RoundHandler {
+	logger := log.GetLogger()
+	return &RoundHandler{interactor: interactor, logger: &logger}
+}
+
+// GetRound returns the status of a round.
+func (h *RoundHandler) GetRound(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	round, err := h.interactor.GetRound(ctx, chi.URLParam(r, http2.UserIDParam), chi.URLParam(r, http2.RoundIDParam))
+	if err != nil {
+		h.logger.Error().Err(err).Msg(errors.ErrFailedGetRound)
+		errors.HandleHTTPError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(round)
+}
+
+// CloseRound settles a round of the source sent in the Source-Type header.
+func (h *RoundHandler) CloseRound(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	round, err := h.interactor.CloseRound(ctx, chi.URLParam(r, http2.UserIDParam), r.Header.Get("Source-Type"), chi.URLParam(r, http2.RoundIDParam))
+	if err != nil {
+		h.logger.Error().Err(err).Msg(errors.ErrFailedCloseRound)
+		errors.HandleHTTPError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(round)
+}
+
+// RollbackRound reverses a round of the source sent in the Source-Type header.
+func (h *RoundHandler) RollbackRound(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	round, err := h.interactor.RollbackRound(ctx, chi.URLParam(r, http2.UserIDParam), r.Header.Get("Source-Type"), chi.URLParam(r, http2.RoundIDParam))
+	if err != nil {
+		h.logger.Error().Err(err).Msg(errors.ErrFailedRollbackRound)
+		errors.HandleHTTPError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(round)
+}
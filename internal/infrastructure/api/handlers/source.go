@@ -0,0 +1,112 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/go-chi/chi/v5"
+	"github.com/mufasadev/enlabs-test/internal/errors"
+	http2 "github.com/mufasadev/enlabs-test/internal/infrastructure/api/http"
+	"github.com/mufasadev/enlabs-test/internal/usecases/dtos"
+	"github.com/mufasadev/enlabs-test/internal/usecases/interactor"
+	"github.com/mufasadev/enlabs-test/pkg/log"
+	"github.com/rs/zerolog"
+	"net/http"
+	"time"
+)
+
+type SourceHandler struct {
+	interactor *interactor.SourceTypeInteractor
+	logger     *zerolog.Logger
+}
+
+func NewSourceHandler(interactor *interactor.SourceTypeInteractor) *SourceHandler {
+	logger := log.GetLogger()
+	return &SourceHandler{interactor: interactor, logger: &logger}
+}
+
+func (h *SourceHandler) ListSources(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	sources, err := h.interactor.ListSources(ctx)
+	if err != nil {
+		h.logger.Error().Err(err).Msg(errors.ErrFailedListSources)
+		errors.HandleHTTPError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(sources)
+}
+
+func (h *SourceHandler) GetSource(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	source, err := h.interactor.GetSource(ctx, chi.URLParam(r, http2.SourceIDParam))
+	if err != nil {
+		h.logger.Error().Err(err).Msg(errors.ErrFailedGetSource)
+		errors.HandleHTTPError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(source)
+}
+
+func (h *SourceHandler) CreateSource(w http.ResponseWriter, r *http.Request) {
+	var dto dtos.SourceDTO
+	if err := json.NewDecoder(r.Body).Decode(&dto); err != nil {
+		h.logger.Error().Err(err).Msg(errors.ErrFailedDecodeRequestBody)
+		errors.HandleHTTPError(w, errors.NewBadRequestError(errors.ErrInvalidRequestBody))
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	source, err := h.interactor.CreateSource(ctx, &dto)
+	if err != nil {
+		h.logger.Error().Err(err).Msg(errors.ErrFailedCreateSource)
+		errors.HandleHTTPError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(source)
+}
+
+func (h *SourceHandler) UpdateSource(w http.ResponseWriter, r *http.Request) {
+	var dto dtos.SourceDTO
+	if err := json.NewDecoder(r.Body).Decode(&dto); err != nil {
+		h.logger.Error().Err(err).Msg(errors.ErrFailedDecodeRequestBody)
+		errors.HandleHTTPError(w, errors.NewBadRequestError(errors.ErrInvalidRequestBody))
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	source, err := h.interactor.UpdateSource(ctx, chi.URLParam(r, http2.SourceIDParam), &dto)
+	if err != nil {
+		h.logger.Error().Err(err).Msg(errors.ErrFailedUpdateSource)
+		errors.HandleHTTPError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(source)
+}
+
+func (h *SourceHandler) DeleteSource(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	if err := h.interactor.DeleteSource(ctx, chi.URLParam(r, http2.SourceIDParam)); err != nil {
+		h.logger.Error().Err(err).Msg(errors.ErrFailedDeleteSource)
+		errors.HandleHTTPError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
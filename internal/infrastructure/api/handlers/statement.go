@@ -0,0 +1,198 @@
+package handlers
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"github.com/go-chi/chi/v5"
+	"github.com/mufasadev/enlabs-test/internal/errors"
+	http2 "github.com/mufasadev/enlabs-test/internal/infrastructure/api/http"
+	"github.com/mufasadev/enlabs-test/internal/usecases/dtos"
+	"github.com/mufasadev/enlabs-test/internal/usecases/interactor"
+	"github.com/mufasadev/enlabs-test/pkg/log"
+	"github.com/rs/zerolog"
+	"net/http"
+	"time"
+)
+
+type StatementHandler struct {
+	interactor *interactor.StatementInteractor
+	logger     *zerolog.Logger
+}
+
+func NewStatementHandler(interactor *interactor.StatementInteractor) *StatementHandler {
+	logger := log.GetLogger()
+	return &StatementHandler{interactor: interactor, logger: &logger}
+}
+
+// GetStatement streams the account statement of the user as JSON or CSV.
+func (h *StatementHandler) GetStatement(w http.ResponseWriter, r *http.Request) {
+	userId := chi.URLParam(r, http2.UserIDParam)
+
+	query, err := parseStatementQuery(r)
+	if err != nil {
+		h.logger.Error().Err(err).Msg(errors.ErrInvalidQueryParameters)
+		errors.HandleHTTPError(w, err)
+		return
+	}
+
+	var writer statementWriter
+	if query.Format == dtos.StatementFormatCSV {
+		writer = &csvStatementWriter{w: w}
+	} else {
+		writer = &jsonStatementWriter{w: w}
+	}
+
+	// statements can be long, they are written while they are read
+	ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
+	defer cancel()
+
+	if err = h.interactor.WriteStatement(ctx, userId, query, writer); err != nil {
+		h.logger.Error().Err(err).Msg(errors.ErrFailedWriteStatement)
+		// the status is already sent once the statement has started
+		if !writer.Started() {
+			errors.HandleHTTPError(w, err)
+		}
+	}
+}
+
+// parseStatementQuery reads the statement period, currency and format from the query string.
+func parseStatementQuery(r *http.Request) (*dtos.StatementQuery, error) {
+	values := r.URL.Query()
+	query := &dtos.StatementQuery{Format: dtos.StatementFormatJSON, Currency: values.Get("currency")}
+
+	if v := values.Get("format"); v != "" {
+		if v != dtos.StatementFormatJSON && v != dtos.StatementFormatCSV {
+			return nil, errors.NewBadRequestError("Invalid format")
+		}
+		query.Format = v
+	}
+
+	if v := values.Get("from"); v != "" {
+		from, err := time.Parse(time.RFC3339, v)
+		if err != nil {
+			return nil, errors.NewBadRequestError("Invalid from")
+		}
+		query.From = &from
+	}
+
+	if v := values.Get("to"); v != "" {
+		to, err := time.Parse(time.RFC3339, v)
+		if err != nil {
+			return nil, errors.NewBadRequestError("Invalid to")
+		}
+		query.To = &to
+	}
+
+	return query, nil
+}
+
+type statementWriter interface {
+	interactor.StatementWriter
+	Started() bool
+}
+
+// jsonStatementWriter writes the statement as one JSON object whose lines are encoded one by one.
+type jsonStatementWriter struct {
+	w       http.ResponseWriter
+	started bool
+	lines   int
+}
+
+func (s *jsonStatementWriter) Started() bool {
+	return s.started
+}
+
+func (s *jsonStatementWriter) Begin(summary *dtos.StatementSummary) error {
+	head, err := json.Marshal(summary)
+	if err != nil {
+		return err
+	}
+
+	s.w.Header().Set("Content-Type", "application/json")
+	s.w.WriteHeader(http.StatusOK)
+	s.started = true
+
+	// open the summary object again to append the lines to it
+	_, err = fmt.Fprintf(s.w, `%s,"lines":[`, head[:len(head)-1])
+	return err
+}
+
+func (s *jsonStatementWriter) WriteLine(line *dtos.StatementLine) error {
+	b, err := json.Marshal(line)
+	if err != nil {
+		return err
+	}
+
+	if s.lines > 0 {
+		if _, err = s.w.Write([]byte(",")); err != nil {
+			return err
+		}
+	}
+	s.lines++
+
+	_, err = s.w.Write(b)
+	return err
+}
+
+func (s *jsonStatementWriter) End(summary *dtos.StatementSummary) error {
+	closing, err := json.Marshal(summary.ClosingBalance)
+	if err != nil {
+		return err
+	}
+
+	_, err = fmt.Fprintf(s.w, `],"closingBalance":%s}`+"\n", closing)
+	return err
+}
+
+// csvStatementWriter writes the statement as CSV with the opening and closing balances as the first and last rows.
+type csvStatementWriter struct {
+	w       http.ResponseWriter
+	csv     *csv.Writer
+	started bool
+}
+
+func (s *csvStatementWriter) Started() bool {
+	return s.started
+}
+
+func (s *csvStatementWriter) Begin(summary *dtos.StatementSummary) error {
+	s.w.Header().Set("Content-Type", "text/csv; charset=utf-8")
+	s.w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="statement-%s-%s.csv"`, summary.UserID, summary.Currency))
+	s.w.WriteHeader(http.StatusOK)
+	s.started = true
+
+	s.csv = csv.NewWriter(s.w)
+	if err := s.csv.Write([]string{"date", "type", "transactionId", "state", "source", "amount", "balance"}); err != nil {
+		return err
+	}
+
+	var date string
+	if summary.From != nil {
+		date = summary.From.Format(time.RFC3339Nano)
+	}
+	return s.csv.Write([]string{date, "opening_balance", "", "", "", "", summary.OpeningBalance})
+}
+
+func (s *csvStatementWriter) WriteLine(line *dtos.StatementLine) error {
+	return s.csv.Write([]string{
+		line.Date.Format(time.RFC3339Nano),
+		line.Type,
+		line.TransactionID,
+		line.State,
+		line.Source,
+		line.Amount,
+		line.Balance,
+	})
+}
+
+func (s *csvStatementWriter) End(summary *dtos.StatementSummary) error {
+	err := s.csv.Write([]string{summary.To.Format(time.RFC3339Nano), "closing_balance", "", "", "", "", summary.ClosingBalance})
+	if err != nil {
+		return err
+	}
+
+	s.csv.Flush()
+	return s.csv.Error()
+}
@@ -1,6 +1,7 @@
 package handlers
 
 import (
+	"context"
 	"encoding/json"
 	"github.com/go-chi/chi/v5"
 	"github.com/mufasadev/enlabs-test/internal/errors"
@@ -10,6 +11,9 @@ import (
 	"github.com/mufasadev/enlabs-test/pkg/log"
 	"github.com/rs/zerolog"
 	"net/http"
+	"strconv"
+	"strings"
+	"time"
 )
 
 type TransactionHandler struct {
@@ -30,17 +34,19 @@ func (h *TransactionHandler) ProcessTransaction(w http.ResponseWriter, r *http.R
 		errors.HandleHTTPError(w, errors.NewBadRequestError(errors.ErrInvalidRequestBody))
 		return
 	}
-	var amount interface{}
-	err = json.Unmarshal(dto.RawAmount, &amount)
+	// amounts are sent as strings, so they are never rounded by a float
+	err = json.Unmarshal(dto.RawAmount, &dto.Amount)
 	if err != nil {
 		h.logger.Error().Err(err).Msg("failed to unmarshal raw amount")
 		errors.HandleHTTPError(w, errors.NewBadRequestError("invalid amount"))
 		return
 	}
-	dto.Amount = amount.(string)
 	sourceType := r.Header.Get("Source-Type")
 	userId := chi.URLParam(r, http2.UserIDParam)
-	transaction, err := h.interactor.ProcessTransaction(userId, sourceType, &dto)
+	transaction, replayed, err := h.interactor.ProcessTransaction(userId, sourceType, &dto)
+	if replayed {
+		w.Header().Set(http2.IdempotentReplayHeader, "true")
+	}
 	if err != nil {
 		h.logger.Error().Err(err).Msg(errors.ErrFailedProcessTransaction)
 		errors.HandleHTTPError(w, err)
@@ -50,3 +56,130 @@ func (h *TransactionHandler) ProcessTransaction(w http.ResponseWriter, r *http.R
 	w.WriteHeader(http.StatusOK)
 	json.NewEncoder(w).Encode(transaction)
 }
+
+// ProcessBatch applies an ordered batch of transactions and returns the result of every item.
+func (h *TransactionHandler) ProcessBatch(w http.ResponseWriter, r *http.Request) {
+	var dto dtos.TransactionBatchDTO
+	err := json.NewDecoder(r.Body).Decode(&dto)
+	if err != nil {
+		h.logger.Error().Err(err).Msg(errors.ErrFailedDecodeRequestBody)
+		errors.HandleHTTPError(w, errors.NewBadRequestError(errors.ErrInvalidRequestBody))
+		return
+	}
+	for idx := range dto.Transactions {
+		// an amount that is not a string is left empty and rejected as invalid
+		var amount string
+		if json.Unmarshal(dto.Transactions[idx].RawAmount, &amount) == nil {
+			dto.Transactions[idx].Amount = amount
+		}
+	}
+
+	sourceType := r.Header.Get("Source-Type")
+	userId := chi.URLParam(r, http2.UserIDParam)
+	batch, err := h.interactor.ProcessBatch(userId, sourceType, &dto)
+	if err != nil {
+		h.logger.Error().Err(err).Msg(errors.ErrFailedProcessBatch)
+		errors.HandleHTTPError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(batch)
+}
+
+func (h *TransactionHandler) ListTransactions(w http.ResponseWriter, r *http.Request) {
+	query, err := parseTransactionListQuery(r)
+	if err != nil {
+		h.logger.Error().Err(err).Msg(errors.ErrInvalidQueryParameters)
+		errors.HandleHTTPError(w, err)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	userId := chi.URLParam(r, http2.UserIDParam)
+	page, err := h.interactor.ListTransactions(ctx, userId, query)
+	if err != nil {
+		h.logger.Error().Err(err).Msg(errors.ErrFailedListTransactions)
+		errors.HandleHTTPError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(page)
+}
+
+func (h *TransactionHandler) GetTransaction(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	userId := chi.URLParam(r, http2.UserIDParam)
+	transactionId := chi.URLParam(r, http2.TransactionIDParam)
+	transaction, err := h.interactor.GetTransaction(ctx, userId, transactionId)
+	if err != nil {
+		h.logger.Error().Err(err).Msg(errors.ErrFailedGetTransaction)
+		errors.HandleHTTPError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(transaction)
+}
+
+// metadataParamPrefix prefixes the query parameters filtering the history by metadata.
+const metadataParamPrefix = "metadata."
+
+// parseTransactionListQuery reads the history filters from the query string.
+func parseTransactionListQuery(r *http.Request) (*dtos.TransactionListQuery, error) {
+	values := r.URL.Query()
+	query := &dtos.TransactionListQuery{
+		State:    values.Get("state"),
+		Currency: values.Get("currency"),
+		RoundID:  values.Get("roundId"),
+		Source:   values.Get("source"),
+		Status:   values.Get("status"),
+		Cursor:   values.Get("cursor"),
+	}
+
+	if v := values.Get("from"); v != "" {
+		from, err := time.Parse(time.RFC3339, v)
+		if err != nil {
+			return nil, errors.NewBadRequestError("Invalid from")
+		}
+		query.From = &from
+	}
+
+	if v := values.Get("to"); v != "" {
+		to, err := time.Parse(time.RFC3339, v)
+		if err != nil {
+			return nil, errors.NewBadRequestError("Invalid to")
+		}
+		query.To = &to
+	}
+
+	// metadata.<key>=<value> filters by a top-level metadata key
+	for name := range values {
+		if !strings.HasPrefix(name, metadataParamPrefix) {
+			continue
+		}
+		key := strings.TrimPrefix(name, metadataParamPrefix)
+		if key == "" {
+			return nil, errors.NewBadRequestError("Invalid metadata filter")
+		}
+		if query.Metadata == nil {
+			query.Metadata = make(map[string]string)
+		}
+		query.Metadata[key] = values.Get(name)
+	}
+
+	if v := values.Get("limit"); v != "" {
+		limit, err := strconv.Atoi(v)
+		if err != nil || limit <= 0 {
+			return nil, errors.NewBadRequestError("Invalid limit")
+		}
+		query.Limit = limit
+	}
+
+	return query, nil
+}
@@ -0,0 +1,52 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/go-chi/chi/v5"
+	"github.com/mufasadev/enlabs-test/internal/errors"
+	http2 "github.com/mufasadev/enlabs-test/internal/infrastructure/api/http"
+	"github.com/mufasadev/enlabs-test/internal/usecases/dtos"
+	"github.com/mufasadev/enlabs-test/internal/usecases/interactor"
+	"github.com/mufasadev/enlabs-test/pkg/log"
+	"github.com/rs/zerolog"
+	"net/http"
+	"time"
+)
+
+type TransferHandler struct {
+	interactor *interactor.TransferInteractor
+	logger     *zerolog.Logger
+}
+
+func NewTransferHandler(interactor *interactor.TransferInteractor) *TransferHandler {
+	logger := log.GetLogger()
+	return &TransferHandler{interactor: interactor, logger: &logger}
+}
+
+// CreateTransfer moves funds from the user of the path to another user.
+func (h *TransferHandler) CreateTransfer(w http.ResponseWriter, r *http.Request) {
+	var dto dtos.TransferDTO
+	if err := json.NewDecoder(r.Body).Decode(&dto); err != nil {
+		h.logger.Error().Err(err).Msg(errors.ErrFailedDecodeRequestBody)
+		errors.HandleHTTPError(w, errors.NewBadRequestError(errors.ErrInvalidRequestBody))
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	userId := chi.URLParam(r, http2.UserIDParam)
+	transfer, replayed, err := h.interactor.CreateTransfer(ctx, userId, &dto)
+	if replayed {
+		w.Header().Set(http2.IdempotentReplayHeader, "true")
+	}
+	if err != nil {
+		h.logger.Error().Err(err).Msg(errors.ErrFailedCreateTransfer)
+		errors.HandleHTTPError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(transfer)
+}
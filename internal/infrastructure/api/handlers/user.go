@@ -6,6 +6,7 @@ import (
 	"github.com/go-chi/chi/v5"
 	"github.com/mufasadev/enlabs-test/internal/errors"
 	http2 "github.com/mufasadev/enlabs-test/internal/infrastructure/api/http"
+	"github.com/mufasadev/enlabs-test/internal/usecases/dtos"
 	"github.com/mufasadev/enlabs-test/internal/usecases/interactor"
 	"github.com/mufasadev/enlabs-test/pkg/log"
 	"github.com/rs/zerolog"
@@ -14,21 +15,44 @@ import (
 )
 
 type BalanceHandler struct {
-	interactor *interactor.UserInteractor
-	logger     *zerolog.Logger
+	interactor         *interactor.UserInteractor
+	snapshotInteractor *interactor.BalanceSnapshotInteractor
+	logger             *zerolog.Logger
 }
 
-func NewBalanceHandler(interactor *interactor.UserInteractor) *BalanceHandler {
+func NewBalanceHandler(interactor *interactor.UserInteractor, snapshotInteractor *interactor.BalanceSnapshotInteractor) *BalanceHandler {
 	logger := log.GetLogger()
-	return &BalanceHandler{interactor: interactor, logger: &logger}
+	return &BalanceHandler{interactor: interactor, snapshotInteractor: snapshotInteractor, logger: &logger}
 }
 
+// GetBalance returns the current balance of every wallet, or their balance at the instant given in the at query
+// parameter.
 func (uh *BalanceHandler) GetBalance(w http.ResponseWriter, r *http.Request) {
 	userId := chi.URLParam(r, http2.UserIDParam)
 
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
 
+	if v := r.URL.Query().Get("at"); v != "" {
+		at, err := time.Parse(time.RFC3339, v)
+		if err != nil {
+			uh.logger.Error().Err(err).Msg(errors.ErrInvalidQueryParameters)
+			errors.HandleHTTPError(w, errors.NewBadRequestError("Invalid at"))
+			return
+		}
+
+		balance, err := uh.snapshotInteractor.GetBalanceAt(ctx, userId, at)
+		if err != nil {
+			uh.logger.Error().Err(err).Msg(errors.ErrFailedGetBalance)
+			errors.HandleHTTPError(w, err)
+			return
+		}
+
+		w.WriteHeader(http.StatusOK)
+		json.NewEncoder(w).Encode(balance)
+		return
+	}
+
 	balance, err := uh.interactor.GetBalance(ctx, userId)
 	if err != nil {
 		uh.logger.Error().Err(err).Msg("failed to get balance")
@@ -37,7 +61,104 @@ func (uh *BalanceHandler) GetBalance(w http.ResponseWriter, r *http.Request) {
 	}
 
 	w.WriteHeader(http.StatusOK)
-	json.NewEncoder(w).Encode(struct {
-		Balance float64 `json:"balance"`
-	}{Balance: balance})
+	json.NewEncoder(w).Encode(balance)
+}
+
+type UserHandler struct {
+	interactor *interactor.UserInteractor
+	logger     *zerolog.Logger
+}
+
+func NewUserHandler(interactor *interactor.UserInteractor) *UserHandler {
+	logger := log.GetLogger()
+	return &UserHandler{interactor: interactor, logger: &logger}
+}
+
+func (uh *UserHandler) CreateUser(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	user, err := uh.interactor.CreateUser(ctx)
+	if err != nil {
+		uh.logger.Error().Err(err).Msg(errors.ErrFailedCreateUser)
+		errors.HandleHTTPError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(user)
+}
+
+func (uh *UserHandler) GetUser(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	user, err := uh.interactor.GetUser(ctx, chi.URLParam(r, http2.UserIDParam))
+	if err != nil {
+		uh.logger.Error().Err(err).Msg(errors.ErrFailedGetUser)
+		errors.HandleHTTPError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(user)
+}
+
+func (uh *UserHandler) GetUserByAccountNumber(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	user, err := uh.interactor.GetUserByAccountNumber(ctx, chi.URLParam(r, http2.AccountNumberParam))
+	if err != nil {
+		uh.logger.Error().Err(err).Msg(errors.ErrFailedGetUser)
+		errors.HandleHTTPError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(user)
+}
+
+func (uh *UserHandler) ListUsers(w http.ResponseWriter, r *http.Request) {
+	query, err := parsePageQuery(r)
+	if err != nil {
+		uh.logger.Error().Err(err).Msg(errors.ErrInvalidQueryParameters)
+		errors.HandleHTTPError(w, err)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	page, err := uh.interactor.ListUsers(ctx, query)
+	if err != nil {
+		uh.logger.Error().Err(err).Msg(errors.ErrFailedListUsers)
+		errors.HandleHTTPError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(page)
+}
+
+func (uh *UserHandler) SetCreditLimit(w http.ResponseWriter, r *http.Request) {
+	var dto dtos.CreditLimitDTO
+	if err := json.NewDecoder(r.Body).Decode(&dto); err != nil {
+		uh.logger.Error().Err(err).Msg(errors.ErrFailedDecodeRequestBody)
+		errors.HandleHTTPError(w, errors.NewBadRequestError(errors.ErrInvalidRequestBody))
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	user, err := uh.interactor.SetCreditLimit(ctx, chi.URLParam(r, http2.UserIDParam), &dto)
+	if err != nil {
+		uh.logger.Error().Err(err).Msg(errors.ErrFailedSetCreditLimit)
+		errors.HandleHTTPError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(user)
 }
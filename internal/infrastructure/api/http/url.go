@@ -1,3 +1,16 @@
 package http
 
 const UserIDParam = "userID"
+
+const AccountNumberParam = "accountNumber"
+
+const TransactionIDParam = "transactionID"
+
+const RunIDParam = "runID"
+
+const SourceIDParam = "sourceID"
+
+const RoundIDParam = "roundID"
+
+// IdempotentReplayHeader is set on responses that replay an already processed transaction.
+const IdempotentReplayHeader = "Idempotent-Replay"
@@ -0,0 +1,28 @@
+package middlewares
+
+import (
+	"crypto/subtle"
+	"github.com/mufasadev/enlabs-test/internal/errors"
+	"github.com/mufasadev/enlabs-test/pkg/log"
+	"net/http"
+	"strings"
+)
+
+// AdminAuthMiddleware checks the bearer token of admin requests. Every request is rejected when no token is configured.
+func AdminAuthMiddleware(token string) func(next http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			logger := log.GetLogger()
+			header := r.Header.Get("Authorization")
+			provided := strings.TrimPrefix(header, "Bearer ")
+
+			if provided == header || token == "" || subtle.ConstantTimeCompare([]byte(provided), []byte(token)) != 1 {
+				logger.Error().Msg(errors.ErrInvalidAdminToken)
+				errors.HandleHTTPError(w, errors.NewUnauthorizedError(errors.ErrInvalidAdminToken))
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
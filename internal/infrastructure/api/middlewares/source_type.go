@@ -9,7 +9,7 @@ import (
 	"time"
 )
 
-// SourceTypeValidationMiddleware validates the source type header.
+// SourceTypeValidationMiddleware validates the source type header and rejects disabled sources.
 func SourceTypeValidationMiddleware(sourceTypeInt *interactor.SourceTypeInteractor) func(next http.Handler) http.Handler {
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
@@ -26,9 +26,9 @@ func SourceTypeValidationMiddleware(sourceTypeInt *interactor.SourceTypeInteract
 			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 			defer cancel()
 			// TODO: add caching
-			if exists, _ := sourceTypeInt.ExistsByName(ctx, sourceType); !exists {
-				logger.Error().Msg(errors.ErrInvalidSourceType)
-				errors.HandleHTTPError(w, errors.NewBadRequestError(errors.ErrInvalidSourceType))
+			if err := sourceTypeInt.CheckEnabled(ctx, sourceType); err != nil {
+				logger.Error().Err(err).Msg(errors.ErrInvalidSourceType)
+				errors.HandleHTTPError(w, err)
 				return
 			}
 
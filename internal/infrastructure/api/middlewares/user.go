@@ -25,8 +25,12 @@ func UserValidationMiddleware(userInt *interactor.UserInteractor) func(next http
 
 			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 			defer cancel()
-			if exists, _ := userInt.ExistsByID(ctx, userId); !exists {
-				logger.Error().Msg(errors.ErrInvalidUserID)
+			if exists, err := userInt.ExistsByID(ctx, userId); !exists {
+				logger.Error().Err(err).Msg(errors.ErrInvalidUserID)
+				if errors.As(err, new(*errors.NotFoundError)) {
+					errors.HandleHTTPError(w, err)
+					return
+				}
 				errors.HandleHTTPError(w, errors.NewBadRequestError(errors.ErrInvalidUserID))
 				return
 			}
@@ -4,12 +4,13 @@ import (
 	"fmt"
 	"github.com/go-chi/chi/v5"
 	"github.com/go-chi/chi/v5/middleware"
+	"github.com/mufasadev/enlabs-test/internal/config"
 	"github.com/mufasadev/enlabs-test/internal/di"
 	http2 "github.com/mufasadev/enlabs-test/internal/infrastructure/api/http"
 	"github.com/mufasadev/enlabs-test/internal/infrastructure/api/middlewares"
 )
 
-func NewRouter(container *di.Container) *chi.Mux {
+func NewRouter(container *di.Container, adminCfg config.Admin) *chi.Mux {
 	router := chi.NewRouter()
 	router.Use(middleware.Logger)
 
@@ -19,16 +20,72 @@ func NewRouter(container *di.Container) *chi.Mux {
 			r.Route(fmt.Sprintf("/{%s}", http2.UserIDParam), func(r chi.Router) { // test id "f60ae2e1-ee72-4a6a-bef2-7cde5c83782f"
 				r.Use(middlewares.UserValidationMiddleware(container.UserInteractor))
 				r.Route("/transactions", func(r chi.Router) {
-					r.Use(middlewares.SourceTypeValidationMiddleware(container.SourceTypeInteractor))
 					th := container.TransactionHandler
-					r.Post("/", th.ProcessTransaction)
+					r.With(middlewares.SourceTypeValidationMiddleware(container.SourceTypeInteractor)).Post("/", th.ProcessTransaction)
+					r.Get("/", th.ListTransactions)
+					r.Get(fmt.Sprintf("/{%s}", http2.TransactionIDParam), th.GetTransaction)
 				})
+				r.With(middlewares.SourceTypeValidationMiddleware(container.SourceTypeInteractor)).
+					Post("/transactions:batch", container.TransactionHandler.ProcessBatch)
 				r.Route("/balance", func(r chi.Router) {
 					bh := container.BalanceHandler
 					r.Get("/", bh.GetBalance)
 				})
+				r.Get("/statement", container.StatementHandler.GetStatement)
+				r.Route(fmt.Sprintf("/rounds/{%s}", http2.RoundIDParam), func(r chi.Router) {
+					rh := container.RoundHandler
+					r.Get("/", rh.GetRound)
+					r.With(middlewares.SourceTypeValidationMiddleware(container.SourceTypeInteractor)).Post("/close", rh.CloseRound)
+					r.With(middlewares.SourceTypeValidationMiddleware(container.SourceTypeInteractor)).Post("/rollback", rh.RollbackRound)
+				})
+				r.Post("/transfers", container.TransferHandler.CreateTransfer)
 			})
 		})
+		r.Get("/currencies", container.CurrencyHandler.ListCurrencies)
+	})
+
+	// Set up admin routes, all of them require the admin token
+	router.Route("/admin/v1", func(r chi.Router) {
+		r.Use(middlewares.AdminAuthMiddleware(adminCfg.Token))
+		r.Route("/cancellation-runs", func(r chi.Router) {
+			ch := container.CancellationRunHandler
+			r.Get("/", ch.ListRuns)
+			r.Get(fmt.Sprintf("/{%s}", http2.RunIDParam), ch.GetRun)
+		})
+		r.Route("/users", func(r chi.Router) {
+			uh := container.UserHandler
+			r.Post("/", uh.CreateUser)
+			r.Get("/", uh.ListUsers)
+			r.Get(fmt.Sprintf("/accounts/{%s}", http2.AccountNumberParam), uh.GetUserByAccountNumber)
+			r.Route(fmt.Sprintf("/{%s}", http2.UserIDParam), func(r chi.Router) {
+				r.With(middlewares.UserValidationMiddleware(container.UserInteractor)).Get("/", uh.GetUser)
+				r.Put("/credit-limit", uh.SetCreditLimit)
+			})
+		})
+		r.Route("/sources", func(r chi.Router) {
+			sh := container.SourceHandler
+			r.Get("/", sh.ListSources)
+			r.Post("/", sh.CreateSource)
+			r.Get(fmt.Sprintf("/{%s}", http2.SourceIDParam), sh.GetSource)
+			r.Patch(fmt.Sprintf("/{%s}", http2.SourceIDParam), sh.UpdateSource)
+			r.Delete(fmt.Sprintf("/{%s}", http2.SourceIDParam), sh.DeleteSource)
+		})
+		r.Route("/jobs/cancel-odd", func(r chi.Router) {
+			jh := container.CancelJobHandler
+			r.Get("/preview", jh.Preview)
+			r.Post("/run", jh.Run)
+		})
+		r.Route("/fx-rates", func(r chi.Router) {
+			fh := container.FxRateHandler
+			r.Get("/", fh.ListRates)
+			r.Post("/", fh.CreateRate)
+		})
+		r.Route("/reconciliations", func(r chi.Router) {
+			rh := container.ReconciliationHandler
+			r.Get("/", rh.ListRuns)
+			r.Post("/run", rh.Run)
+			r.Get(fmt.Sprintf("/{%s}", http2.RunIDParam), rh.GetRun)
+		})
 	})
 
 	return router
@@ -0,0 +1,83 @@
+package repositories
+
+import (
+	"context"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/mufasadev/enlabs-test/internal/domain/repositories"
+	"github.com/shopspring/decimal"
+	"time"
+)
+
+type BalanceSnapshotRepositoryImpl struct {
+	db *pgxpool.Pool
+}
+
+func NewBalanceSnapshotRepositoryImpl(db *pgxpool.Pool) repositories.BalanceSnapshotRepository {
+	return &BalanceSnapshotRepositoryImpl{
+		db: db,
+	}
+}
+
+// takeSnapshots adds the ledger entries created since the last snapshot of every wallet to that snapshot.
+// Only wallets with new entries get a new snapshot.
+const takeSnapshots = `
+WITH last_snapshots AS (
+  SELECT DISTINCT ON (user_id, currency) user_id, currency, taken_at, balance
+  FROM balance_snapshots
+  WHERE taken_at <= $1
+  ORDER BY user_id, currency, taken_at DESC
+),
+deltas AS (
+  SELECT le.account_id AS user_id, le.currency,
+         SUM(CASE WHEN le.direction = 'credit' THEN le.amount ELSE -le.amount END) AS change
+  FROM ledger_entries le
+  LEFT JOIN last_snapshots ls ON ls.user_id = le.account_id AND ls.currency = le.currency
+  WHERE le.account_type = 'user'
+    AND le.created_at <= $1
+    AND (ls.taken_at IS NULL OR le.created_at > ls.taken_at)
+  GROUP BY le.account_id, le.currency
+)
+INSERT INTO balance_snapshots (user_id, currency, taken_at, balance)
+SELECT d.user_id, d.currency, $1, COALESCE(ls.balance, 0) + d.change
+FROM deltas d
+JOIN users u ON u.id = d.user_id
+LEFT JOIN last_snapshots ls ON ls.user_id = d.user_id AND ls.currency = d.currency
+ON CONFLICT (user_id, currency, taken_at) DO NOTHING;`
+
+// TakeSnapshots stores the balance of every wallet that changed since its last snapshot as of at.
+func (r *BalanceSnapshotRepositoryImpl) TakeSnapshots(ctx context.Context, at time.Time) (int, error) {
+	tag, err := r.db.Exec(ctx, takeSnapshots, at)
+	if err != nil {
+		return 0, err
+	}
+
+	return int(tag.RowsAffected()), nil
+}
+
+// userBalanceAt adds the ledger entries in the currency $3 created between the latest snapshot and the instant to
+// the snapshot.
+const userBalanceAt = `
+WITH snapshot AS (
+  SELECT taken_at, balance
+  FROM balance_snapshots
+  WHERE user_id = $1 AND currency = $3 AND taken_at <= $2
+  ORDER BY taken_at DESC
+  LIMIT 1
+)
+SELECT COALESCE((SELECT balance FROM snapshot), 0) +
+       COALESCE(SUM(CASE WHEN le.direction = 'credit' THEN le.amount ELSE -le.amount END), 0)
+FROM ledger_entries le
+WHERE le.account_type = 'user'
+  AND le.account_id = $1
+  AND le.currency = $3
+  AND le.created_at <= $2
+  AND le.created_at > COALESCE((SELECT taken_at FROM snapshot), '-infinity'::TIMESTAMPTZ);`
+
+// GetUserBalanceAt returns the balance of the user in the currency at the instant, including the changes made at
+// that instant.
+func (r *BalanceSnapshotRepositoryImpl) GetUserBalanceAt(ctx context.Context, userID string, currency string, at time.Time) (decimal.Decimal, error) {
+	var balance decimal.Decimal
+	err := r.db.QueryRow(ctx, userBalanceAt, userID, at, currency).Scan(&balance)
+
+	return balance, err
+}
@@ -0,0 +1,83 @@
+package repositories
+
+import (
+	"context"
+	"github.com/google/uuid"
+	"github.com/mufasadev/enlabs-test/internal/domain/models"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"testing"
+	"time"
+)
+
+func TestUserBalanceAt(t *testing.T) {
+	setupDB()
+	defer db.Close()
+
+	transactionRepo := NewTransactionRepositoryImpl(db)
+	userRepo := NewUserRepositoryImpl(db)
+	snapshotRepo := NewBalanceSnapshotRepositoryImpl(db)
+
+	err := truncateTransactionsTable(db)
+	require.NoError(t, err)
+
+	user, err := createTestUser(db)
+	require.NoError(t, err)
+	defer func() {
+		_ = truncateTransactionsTable(db)
+		_ = deleteTestUser(db, user)
+	}()
+
+	err = userRepo.Update(context.Background(), &models.User{ID: user, Balance: decimal.NewFromInt(100)})
+	require.NoError(t, err)
+
+	states := []string{"win", "lost", "win", "lost"}
+	stored := make([]*models.Transaction, 0, len(states))
+	for _, state := range states {
+		transaction := &models.Transaction{
+			TransactionID: uuid.New().String(),
+			State:         state,
+			Amount:        newMoney(randDecimal(0)),
+			SourceType:    models.SourceType{ID: sourceTypeId},
+			User:          models.User{ID: user},
+		}
+		_, err = transactionRepo.InsertTransactionAndUpdateUserBalanceWithCreatingTransaction(context.Background(), transaction)
+		require.NoError(t, err)
+
+		tx, err := transactionRepo.GetByTransactionID(context.Background(), transaction.TransactionID)
+		require.NoError(t, err)
+		stored = append(stored, tx)
+	}
+
+	assertBalances := func(t *testing.T) {
+		for _, tx := range stored {
+			balance, err := snapshotRepo.GetUserBalanceAt(context.Background(), user, "EUR", tx.CreatedAt)
+			require.NoError(t, err)
+			assert.True(t, tx.BalanceAfter.Equal(balance), "The balance at a transaction must be the balance right after it")
+		}
+
+		balance, err := snapshotRepo.GetUserBalanceAt(context.Background(), user, "EUR", stored[0].CreatedAt.Add(-time.Hour))
+		require.NoError(t, err)
+		assert.True(t, balance.IsZero())
+	}
+
+	t.Run("from_ledger", assertBalances)
+
+	t.Run("from_snapshots", func(t *testing.T) {
+		count, err := snapshotRepo.TakeSnapshots(context.Background(), stored[1].CreatedAt)
+		require.NoError(t, err)
+		assert.Equal(t, 1, count)
+
+		count, err = snapshotRepo.TakeSnapshots(context.Background(), stored[2].CreatedAt)
+		require.NoError(t, err)
+		assert.Equal(t, 1, count)
+
+		// nothing changed since the last snapshot
+		count, err = snapshotRepo.TakeSnapshots(context.Background(), stored[2].CreatedAt.Add(time.Millisecond))
+		require.NoError(t, err)
+		assert.Equal(t, 0, count)
+
+		assertBalances(t)
+	})
+}
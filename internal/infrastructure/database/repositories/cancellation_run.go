@@ -0,0 +1,142 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/mufasadev/enlabs-test/internal/domain/models"
+	"github.com/mufasadev/enlabs-test/internal/domain/repositories"
+)
+
+type CancellationRunRepositoryImpl struct {
+	db *pgxpool.Pool
+}
+
+func NewCancellationRunRepositoryImpl(db *pgxpool.Pool) repositories.CancellationRunRepository {
+	return &CancellationRunRepositoryImpl{
+		db: db,
+	}
+}
+
+// Create starts a new cancellation run for the strategy.
+func (r *CancellationRunRepositoryImpl) Create(ctx context.Context, strategy string) (*models.CancellationRun, error) {
+	run := &models.CancellationRun{Strategy: strategy}
+	err := r.db.QueryRow(
+		ctx,
+		`INSERT INTO cancellation_runs (strategy) VALUES ($1) RETURNING id, started_at`,
+		strategy,
+	).Scan(&run.ID, &run.StartedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	return run, nil
+}
+
+// Finish stores the outcome of a cancellation run.
+func (r *CancellationRunRepositoryImpl) Finish(ctx context.Context, id string, cancelledCount int, runErr error) error {
+	var message *string
+	if runErr != nil {
+		m := runErr.Error()
+		message = &m
+	}
+
+	_, err := r.db.Exec(
+		ctx,
+		`UPDATE cancellation_runs SET finished_at = NOW(), cancelled_count = $2, error = $3 WHERE id = $1`,
+		id,
+		cancelledCount,
+		message,
+	)
+
+	return err
+}
+
+// GetByID returns a cancellation run or nil when it does not exist.
+func (r *CancellationRunRepositoryImpl) GetByID(ctx context.Context, id string) (*models.CancellationRun, error) {
+	var run models.CancellationRun
+	err := r.db.QueryRow(
+		ctx,
+		`SELECT id, strategy, started_at, finished_at, cancelled_count, error FROM cancellation_runs WHERE id = $1`,
+		id,
+	).Scan(&run.ID, &run.Strategy, &run.StartedAt, &run.FinishedAt, &run.CancelledCount, &run.Error)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return &run, nil
+}
+
+// List returns cancellation runs, newest first, starting after the cursor.
+func (r *CancellationRunRepositoryImpl) List(ctx context.Context, after *repositories.Cursor, limit int) ([]models.CancellationRun, error) {
+	var rows pgx.Rows
+	var err error
+	if after == nil {
+		rows, err = r.db.Query(
+			ctx,
+			`SELECT id, strategy, started_at, finished_at, cancelled_count, error FROM cancellation_runs
+			ORDER BY started_at DESC, id DESC LIMIT $1`,
+			limit,
+		)
+	} else {
+		rows, err = r.db.Query(
+			ctx,
+			`SELECT id, strategy, started_at, finished_at, cancelled_count, error FROM cancellation_runs
+			WHERE (started_at, id) < ($2, $3)
+			ORDER BY started_at DESC, id DESC LIMIT $1`,
+			limit,
+			after.CreatedAt,
+			after.ID,
+		)
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	runs := make([]models.CancellationRun, 0, limit)
+	for rows.Next() {
+		var run models.CancellationRun
+		err = rows.Scan(&run.ID, &run.Strategy, &run.StartedAt, &run.FinishedAt, &run.CancelledCount, &run.Error)
+		if err != nil {
+			return nil, err
+		}
+		runs = append(runs, run)
+	}
+
+	return runs, rows.Err()
+}
+
+// ListItems returns the transactions cancelled by a run with their external transaction ids.
+func (r *CancellationRunRepositoryImpl) ListItems(ctx context.Context, runID string) ([]models.Cancellation, error) {
+	rows, err := r.db.Query(
+		ctx,
+		`SELECT c.id, c.run_id, t.transaction_id, c.user_id, cur.code, cur.scale, c.balance_before, c.balance_after, c.created_at
+		FROM cancellations c
+		JOIN transactions t ON t.id = c.transaction_id
+		JOIN currencies cur ON cur.code = t.currency
+		WHERE c.run_id = $1
+		ORDER BY c.user_id, t.currency, t.created_at, t.id`,
+		runID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	items := make([]models.Cancellation, 0)
+	for rows.Next() {
+		var c models.Cancellation
+		err = rows.Scan(&c.ID, &c.RunID, &c.TransactionID, &c.UserID, &c.Currency.Code, &c.Currency.Scale, &c.BalanceBefore, &c.BalanceAfter, &c.CreatedAt)
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, c)
+	}
+
+	return items, rows.Err()
+}
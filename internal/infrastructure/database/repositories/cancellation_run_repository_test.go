@@ -0,0 +1,170 @@
+package repositories
+
+import (
+	"context"
+	"github.com/google/uuid"
+	"github.com/mufasadev/enlabs-test/internal/domain/models"
+	"github.com/mufasadev/enlabs-test/internal/domain/repositories"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func TestCancellationRunAudit(t *testing.T) {
+	setupDB()
+	defer db.Close()
+
+	transactionRepo := NewTransactionRepositoryImpl(db)
+	runRepo := NewCancellationRunRepositoryImpl(db)
+
+	err := truncateTransactionsTable(db)
+	require.NoError(t, err)
+	err = setInitialUserBalance(db, 1000)
+	require.NoError(t, err)
+
+	for i := 0; i < 10; i++ {
+		state := "win"
+		if i%3 == 0 {
+			state = "lost"
+		}
+		transaction := &models.Transaction{
+			TransactionID: uuid.New().String(),
+			State:         state,
+			Amount:        newMoney(decimal.NewFromInt(int64(i + 1))),
+			SourceType:    models.SourceType{ID: sourceTypeId},
+			User:          models.User{ID: userId},
+		}
+		_, err = transactionRepo.InsertTransactionAndUpdateUserBalanceWithCreatingTransaction(context.Background(), transaction)
+		require.NoError(t, err)
+	}
+
+	strategy := NewGlobalOddStrategy(DefaultCancelBatchSize)
+	run, err := runRepo.Create(context.Background(), strategy.Name())
+	require.NoError(t, err)
+
+	rows, err := transactionRepo.CancelTransactionsAndUpdateBalance(context.Background(), strategy, run.ID)
+	require.NoError(t, err)
+	require.NotEmpty(t, rows)
+
+	err = runRepo.Finish(context.Background(), run.ID, len(rows), nil)
+	require.NoError(t, err)
+
+	t.Run("run_is_finished", func(t *testing.T) {
+		stored, err := runRepo.GetByID(context.Background(), run.ID)
+		require.NoError(t, err)
+		require.NotNil(t, stored)
+		assert.Equal(t, strategy.Name(), stored.Strategy)
+		assert.Equal(t, len(rows), stored.CancelledCount)
+		assert.NotNil(t, stored.FinishedAt)
+		assert.Nil(t, stored.Error)
+	})
+
+	t.Run("items_chain_balances", func(t *testing.T) {
+		items, err := runRepo.ListItems(context.Background(), run.ID)
+		require.NoError(t, err)
+		require.Len(t, items, len(rows))
+
+		// every item starts from the balance the previous one left
+		for i := 1; i < len(items); i++ {
+			assert.True(t, items[i].BalanceBefore.Equal(items[i-1].BalanceAfter))
+		}
+
+		var balance decimal.Decimal
+		err = db.QueryRow(context.Background(), "SELECT balance FROM wallets WHERE user_id = $1 AND currency = 'EUR'", userId).Scan(&balance)
+		require.NoError(t, err)
+		assert.True(t, items[len(items)-1].BalanceAfter.Equal(balance), "The last item must leave the current balance")
+	})
+
+	t.Run("cancel_without_run_is_not_audited", func(t *testing.T) {
+		_, err := transactionRepo.CancelTransactionsAndUpdateBalance(context.Background(), strategy, "")
+		require.NoError(t, err)
+
+		var count int
+		err = db.QueryRow(context.Background(), "SELECT COUNT(*) FROM cancellations WHERE run_id <> $1", run.ID).Scan(&count)
+		require.NoError(t, err)
+		assert.Equal(t, 0, count)
+	})
+
+	t.Run("list_runs", func(t *testing.T) {
+		second, err := runRepo.Create(context.Background(), strategy.Name())
+		require.NoError(t, err)
+
+		runs, err := runRepo.List(context.Background(), nil, 1)
+		require.NoError(t, err)
+		require.Len(t, runs, 1)
+		assert.Equal(t, second.ID, runs[0].ID)
+
+		runs, err = runRepo.List(context.Background(), &repositories.Cursor{CreatedAt: runs[0].StartedAt, ID: runs[0].ID}, 10)
+		require.NoError(t, err)
+		require.Len(t, runs, 1)
+		assert.Equal(t, run.ID, runs[0].ID)
+	})
+
+	t.Run("unknown_run", func(t *testing.T) {
+		stored, err := runRepo.GetByID(context.Background(), uuid.New().String())
+		require.NoError(t, err)
+		assert.Nil(t, stored)
+	})
+}
+
+func TestPreviewCancelTransactions(t *testing.T) {
+	setupDB()
+	defer db.Close()
+
+	transactionRepo := NewTransactionRepositoryImpl(db)
+
+	err := truncateTransactionsTable(db)
+	require.NoError(t, err)
+	err = setInitialUserBalance(db, 0)
+	require.NoError(t, err)
+
+	// the first win is bigger than the final balance, so canceling it has to be skipped
+	amounts := []int64{100, 50, 140, 1, 1}
+	states := []string{"win", "win", "lost", "lost", "win"}
+	for i := range amounts {
+		transaction := &models.Transaction{
+			TransactionID: uuid.New().String(),
+			State:         states[i],
+			Amount:        newMoney(decimal.NewFromInt(amounts[i])),
+			SourceType:    models.SourceType{ID: sourceTypeId},
+			User:          models.User{ID: userId},
+		}
+		_, err = transactionRepo.InsertTransactionAndUpdateUserBalanceWithCreatingTransaction(context.Background(), transaction)
+		require.NoError(t, err)
+	}
+
+	strategy := NewPerUserOddStrategy(DefaultCancelBatchSize)
+	preview, err := transactionRepo.PreviewCancelTransactions(context.Background(), strategy)
+	require.NoError(t, err)
+	require.NotEmpty(t, preview)
+
+	t.Run("preview_changes_nothing", func(t *testing.T) {
+		again, err := transactionRepo.PreviewCancelTransactions(context.Background(), strategy)
+		require.NoError(t, err)
+		assert.Equal(t, preview, again)
+	})
+
+	t.Run("preview_matches_cancel", func(t *testing.T) {
+		rows, err := transactionRepo.CancelTransactionsAndUpdateBalance(context.Background(), strategy, "")
+		require.NoError(t, err)
+
+		cancelled := 0
+		skipped := 0
+		for _, row := range preview {
+			if row.Cancellable {
+				cancelled++
+			} else {
+				skipped++
+				assert.True(t, row.BalanceBefore.Equal(row.BalanceAfter), "A skipped transaction must not change the balance")
+			}
+		}
+		assert.Equal(t, cancelled, len(rows))
+		assert.Equal(t, 1, skipped)
+
+		var balance decimal.Decimal
+		err = db.QueryRow(context.Background(), "SELECT balance FROM wallets WHERE user_id = $1 AND currency = 'EUR'", userId).Scan(&balance)
+		require.NoError(t, err)
+		assert.True(t, preview[0].UserBalance.Equal(balance), "The previewed balance must be the balance after the cancel")
+	})
+}
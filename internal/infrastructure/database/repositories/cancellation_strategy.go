@@ -0,0 +1,178 @@
+package repositories
+
+import (
+	"fmt"
+	"github.com/mufasadev/enlabs-test/internal/config"
+	"github.com/mufasadev/enlabs-test/internal/domain/repositories"
+	"strconv"
+	"time"
+)
+
+// Cancellation strategy names accepted by CANCEL_STRATEGY.
+const (
+	GlobalOddStrategyName    = "global_odd"
+	PerUserOddStrategyName   = "per_user_odd"
+	PerSourceOddStrategyName = "per_source_odd"
+	AgeWindowStrategyName    = "age_window"
+)
+
+const DefaultCancelBatchSize = 20
+
+// The odd strategies only select game transactions: wins and losses that are not part of a transfer. Deposits,
+// withdrawals and the other kinds are never cancelled. Transactions are ranked by ROW_NUMBER with id as the
+// tiebreak, so transactions created at the same time never take more than the batch size.
+
+// GlobalOddStrategy takes the latest applied transactions of all users and selects the odd-ranked ones.
+type GlobalOddStrategy struct {
+	BatchSize int
+}
+
+func NewGlobalOddStrategy(batchSize int) *GlobalOddStrategy {
+	return &GlobalOddStrategy{BatchSize: batchSize}
+}
+
+func (s *GlobalOddStrategy) Name() string {
+	return GlobalOddStrategyName
+}
+
+func (s *GlobalOddStrategy) CandidatesQuery() (string, []interface{}) {
+	return `
+  SELECT id, user_id, state, sign, amount, currency, created_at
+  FROM (
+    SELECT id, state, sign, amount, currency, user_id, created_at,
+           ROW_NUMBER() OVER (ORDER BY created_at DESC, id DESC) AS rank
+    FROM transactions
+    WHERE status = 'applied' AND transfer_id IS NULL AND state IN ('win', 'lost')
+    ORDER BY created_at DESC, id DESC
+    LIMIT $1
+  ) ranked_transactions
+  WHERE rank <= $1 AND rank % 2 = 1`, []interface{}{s.BatchSize}
+}
+
+// PerUserOddStrategy ranks the applied transactions of every user separately and selects
+// the odd-ranked ones among the latest transactions of each user. Which transactions of a user
+// are canceled does not depend on the activity of other users.
+type PerUserOddStrategy struct {
+	BatchSize int
+}
+
+func NewPerUserOddStrategy(batchSize int) *PerUserOddStrategy {
+	return &PerUserOddStrategy{BatchSize: batchSize}
+}
+
+func (s *PerUserOddStrategy) Name() string {
+	return PerUserOddStrategyName
+}
+
+func (s *PerUserOddStrategy) CandidatesQuery() (string, []interface{}) {
+	return `
+  SELECT id, user_id, state, sign, amount, currency, created_at
+  FROM (
+    SELECT id, state, sign, amount, currency, user_id, created_at,
+           ROW_NUMBER() OVER (PARTITION BY user_id ORDER BY created_at DESC, id DESC) AS rank
+    FROM transactions
+    WHERE status = 'applied' AND transfer_id IS NULL AND state IN ('win', 'lost')
+  ) ranked_transactions
+  WHERE rank <= $1 AND rank % 2 = 1`, []interface{}{s.BatchSize}
+}
+
+// PerSourceOddStrategy ranks the applied transactions of every source separately and selects
+// the odd-ranked ones among the latest transactions of each source.
+type PerSourceOddStrategy struct {
+	BatchSize int
+}
+
+func NewPerSourceOddStrategy(batchSize int) *PerSourceOddStrategy {
+	return &PerSourceOddStrategy{BatchSize: batchSize}
+}
+
+func (s *PerSourceOddStrategy) Name() string {
+	return PerSourceOddStrategyName
+}
+
+func (s *PerSourceOddStrategy) CandidatesQuery() (string, []interface{}) {
+	return `
+  SELECT id, user_id, state, sign, amount, currency, created_at
+  FROM (
+    SELECT id, state, sign, amount, currency, user_id, created_at,
+           ROW_NUMBER() OVER (PARTITION BY source_id ORDER BY created_at DESC, id DESC) AS rank
+    FROM transactions
+    WHERE status = 'applied' AND transfer_id IS NULL AND state IN ('win', 'lost')
+  ) ranked_transactions
+  WHERE rank <= $1 AND rank % 2 = 1`, []interface{}{s.BatchSize}
+}
+
+// AgeWindowStrategy takes the applied transactions created within the window and selects
+// the odd-ranked ones among the latest of them.
+type AgeWindowStrategy struct {
+	BatchSize int
+	Window    time.Duration
+}
+
+func NewAgeWindowStrategy(batchSize int, window time.Duration) *AgeWindowStrategy {
+	return &AgeWindowStrategy{BatchSize: batchSize, Window: window}
+}
+
+func (s *AgeWindowStrategy) Name() string {
+	return AgeWindowStrategyName
+}
+
+func (s *AgeWindowStrategy) CandidatesQuery() (string, []interface{}) {
+	return `
+  SELECT id, user_id, state, sign, amount, currency, created_at
+  FROM (
+    SELECT id, state, sign, amount, currency, user_id, created_at,
+           ROW_NUMBER() OVER (ORDER BY created_at DESC, id DESC) AS rank
+    FROM transactions
+    WHERE status = 'applied' AND transfer_id IS NULL AND state IN ('win', 'lost') AND created_at >= NOW() - $2::DOUBLE PRECISION * INTERVAL '1 second'
+    ORDER BY created_at DESC, id DESC
+    LIMIT $1
+  ) ranked_transactions
+  WHERE rank <= $1 AND rank % 2 = 1`, []interface{}{s.BatchSize, s.Window.Seconds()}
+}
+
+// NewCancellationStrategy creates the cancellation strategy selected in the process configuration.
+func NewCancellationStrategy(cfg config.Process) (repositories.CancellationStrategy, error) {
+	batchSize, err := strconv.Atoi(cfg.CancelBatchSize)
+	if err != nil || batchSize <= 0 {
+		return nil, fmt.Errorf("invalid cancel batch size %q", cfg.CancelBatchSize)
+	}
+
+	switch cfg.CancelStrategy {
+	case GlobalOddStrategyName:
+		return NewGlobalOddStrategy(batchSize), nil
+	case PerUserOddStrategyName:
+		return NewPerUserOddStrategy(batchSize), nil
+	case PerSourceOddStrategyName:
+		return NewPerSourceOddStrategy(batchSize), nil
+	case AgeWindowStrategyName:
+		window, err := strconv.Atoi(cfg.CancelWindow)
+		if err != nil || window <= 0 {
+			return nil, fmt.Errorf("invalid cancel window %q", cfg.CancelWindow)
+		}
+		return NewAgeWindowStrategy(batchSize, time.Duration(window)*time.Minute), nil
+	default:
+		return nil, fmt.Errorf("unknown cancel strategy %q", cfg.CancelStrategy)
+	}
+}
+
+// RoundStrategy selects every applied transaction of a game round. It is used to roll a round back, never by the
+// cancel process.
+type RoundStrategy struct {
+	RoundID string
+}
+
+func NewRoundStrategy(roundID string) *RoundStrategy {
+	return &RoundStrategy{RoundID: roundID}
+}
+
+func (s *RoundStrategy) Name() string {
+	return "round"
+}
+
+func (s *RoundStrategy) CandidatesQuery() (string, []interface{}) {
+	return `
+  SELECT id, user_id, state, sign, amount, currency, created_at
+  FROM transactions
+  WHERE round_id = $1 AND status = 'applied'`, []interface{}{s.RoundID}
+}
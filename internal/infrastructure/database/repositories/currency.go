@@ -0,0 +1,54 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/mufasadev/enlabs-test/internal/domain/models"
+	"github.com/mufasadev/enlabs-test/internal/domain/repositories"
+)
+
+type CurrencyRepositoryImpl struct {
+	db *pgxpool.Pool
+}
+
+func NewCurrencyRepositoryImpl(db *pgxpool.Pool) repositories.CurrencyRepository {
+	return &CurrencyRepositoryImpl{
+		db: db,
+	}
+}
+
+// GetByCode returns a currency of the registry or nil when it is not registered.
+func (r *CurrencyRepositoryImpl) GetByCode(ctx context.Context, code string) (*models.Currency, error) {
+	currency := &models.Currency{}
+	err := r.db.QueryRow(ctx, "SELECT code, scale FROM currencies WHERE code = $1", code).Scan(&currency.Code, &currency.Scale)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return currency, nil
+}
+
+// List returns all registered currencies ordered by code.
+func (r *CurrencyRepositoryImpl) List(ctx context.Context) ([]models.Currency, error) {
+	rows, err := r.db.Query(ctx, "SELECT code, scale FROM currencies ORDER BY code")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	currencies := make([]models.Currency, 0)
+	for rows.Next() {
+		var currency models.Currency
+		if err = rows.Scan(&currency.Code, &currency.Scale); err != nil {
+			return nil, err
+		}
+		currencies = append(currencies, currency)
+	}
+
+	return currencies, rows.Err()
+}
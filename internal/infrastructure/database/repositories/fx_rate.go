@@ -0,0 +1,96 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/mufasadev/enlabs-test/internal/domain/models"
+	"github.com/mufasadev/enlabs-test/internal/domain/repositories"
+	apperrors "github.com/mufasadev/enlabs-test/internal/errors"
+	"time"
+)
+
+// FxRateRepositoryImpl loads rates from the fx_rates table. It is the default rate provider.
+type FxRateRepositoryImpl struct {
+	db *pgxpool.Pool
+}
+
+func NewFxRateRepositoryImpl(db *pgxpool.Pool) repositories.FxRateRepository {
+	return &FxRateRepositoryImpl{
+		db: db,
+	}
+}
+
+// GetRate returns the latest rate of the pair valid at the instant, or nil when there is none.
+func (r *FxRateRepositoryImpl) GetRate(ctx context.Context, base string, quote string, at time.Time) (*models.FxRate, error) {
+	rate := &models.FxRate{}
+	err := r.db.QueryRow(
+		ctx,
+		`SELECT id, base_currency, quote_currency, rate, valid_from, created_at
+		FROM fx_rates
+		WHERE base_currency = $1 AND quote_currency = $2 AND valid_from <= $3
+		ORDER BY valid_from DESC
+		LIMIT 1`,
+		base,
+		quote,
+		at,
+	).Scan(&rate.ID, &rate.BaseCurrency, &rate.QuoteCurrency, &rate.Rate, &rate.ValidFrom, &rate.CreatedAt)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return rate, nil
+}
+
+// Create stores a rate and fills in its id and creation time.
+func (r *FxRateRepositoryImpl) Create(ctx context.Context, rate *models.FxRate) error {
+	err := r.db.QueryRow(
+		ctx,
+		`INSERT INTO fx_rates (base_currency, quote_currency, rate, valid_from)
+		VALUES ($1, $2, $3::NUMERIC(28,12), $4)
+		RETURNING id, created_at`,
+		rate.BaseCurrency,
+		rate.QuoteCurrency,
+		rate.Rate,
+		rate.ValidFrom,
+	).Scan(&rate.ID, &rate.CreatedAt)
+
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.SQLState() == repositories.UniqueViolationError {
+		return apperrors.NewConflictError("A rate of the pair is already valid from this instant")
+	}
+
+	return err
+}
+
+// ListCurrent returns the rate of every pair valid now, ordered by pair. Rates valid from a future instant are
+// not listed.
+func (r *FxRateRepositoryImpl) ListCurrent(ctx context.Context) ([]models.FxRate, error) {
+	rows, err := r.db.Query(
+		ctx,
+		`SELECT DISTINCT ON (base_currency, quote_currency) id, base_currency, quote_currency, rate, valid_from, created_at
+		FROM fx_rates
+		WHERE valid_from <= NOW()
+		ORDER BY base_currency, quote_currency, valid_from DESC`,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	rates := make([]models.FxRate, 0)
+	for rows.Next() {
+		var rate models.FxRate
+		if err = rows.Scan(&rate.ID, &rate.BaseCurrency, &rate.QuoteCurrency, &rate.Rate, &rate.ValidFrom, &rate.CreatedAt); err != nil {
+			return nil, err
+		}
+		rates = append(rates, rate)
+	}
+
+	return rates, rows.Err()
+}
@@ -0,0 +1,123 @@
+package repositories
+
+import (
+	"context"
+	"github.com/google/uuid"
+	"github.com/mufasadev/enlabs-test/internal/domain/models"
+	apperrors "github.com/mufasadev/enlabs-test/internal/errors"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"testing"
+	"time"
+)
+
+func TestFxRateRepository(t *testing.T) {
+	setupDB()
+	defer db.Close()
+
+	fxRateRepo := NewFxRateRepositoryImpl(db)
+	_, err := db.Exec(context.Background(), "DELETE FROM fx_rates WHERE base_currency = 'USD' AND quote_currency = 'EUR'")
+	require.NoError(t, err)
+
+	now := time.Now().UTC().Truncate(time.Microsecond)
+	for _, r := range []struct {
+		rate      string
+		validFrom time.Time
+	}{
+		{"0.9", now.Add(-2 * time.Hour)},
+		{"0.92", now.Add(-time.Hour)},
+		{"0.95", now.Add(time.Hour)},
+	} {
+		err = fxRateRepo.Create(context.Background(), &models.FxRate{
+			BaseCurrency:  "USD",
+			QuoteCurrency: "EUR",
+			Rate:          decimal.RequireFromString(r.rate),
+			ValidFrom:     r.validFrom,
+		})
+		require.NoError(t, err)
+	}
+
+	t.Run("latest_valid_rate", func(t *testing.T) {
+		rate, err := fxRateRepo.GetRate(context.Background(), "USD", "EUR", now)
+		require.NoError(t, err)
+		require.NotNil(t, rate)
+		assert.True(t, decimal.RequireFromString("0.92").Equal(rate.Rate))
+
+		rate, err = fxRateRepo.GetRate(context.Background(), "USD", "EUR", now.Add(-90*time.Minute))
+		require.NoError(t, err)
+		require.NotNil(t, rate)
+		assert.True(t, decimal.RequireFromString("0.9").Equal(rate.Rate))
+	})
+
+	t.Run("no_rate", func(t *testing.T) {
+		rate, err := fxRateRepo.GetRate(context.Background(), "USD", "EUR", now.Add(-3*time.Hour))
+		require.NoError(t, err)
+		assert.Nil(t, rate)
+
+		// rates are not inverted
+		rate, err = fxRateRepo.GetRate(context.Background(), "EUR", "USD", now)
+		require.NoError(t, err)
+		assert.Nil(t, rate)
+	})
+
+	t.Run("duplicate_valid_from", func(t *testing.T) {
+		err := fxRateRepo.Create(context.Background(), &models.FxRate{
+			BaseCurrency:  "USD",
+			QuoteCurrency: "EUR",
+			Rate:          decimal.RequireFromString("1"),
+			ValidFrom:     now.Add(-time.Hour),
+		})
+		assert.True(t, apperrors.As(err, new(*apperrors.ConflictError)))
+	})
+
+	t.Run("list_current", func(t *testing.T) {
+		rates, err := fxRateRepo.ListCurrent(context.Background())
+		require.NoError(t, err)
+		var found bool
+		for _, rate := range rates {
+			if rate.BaseCurrency == "USD" && rate.QuoteCurrency == "EUR" {
+				found = true
+				assert.True(t, decimal.RequireFromString("0.92").Equal(rate.Rate))
+			}
+		}
+		assert.True(t, found)
+	})
+}
+
+func TestConvertedTransaction(t *testing.T) {
+	setupDB()
+	defer db.Close()
+
+	transactionRepo := NewTransactionRepositoryImpl(db)
+
+	err := truncateTransactionsTable(db)
+	require.NoError(t, err)
+	err = setInitialUserBalance(db, 0)
+	require.NoError(t, err)
+
+	rate := decimal.RequireFromString("0.923456789012")
+	original := models.NewMoney(decimal.RequireFromString("10.00"), models.Currency{Code: "USD", Scale: 2})
+	transaction := &models.Transaction{
+		TransactionID:  uuid.New().String(),
+		State:          "win",
+		Amount:         models.FxRate{Rate: rate}.Convert(original.Decimal, models.Currency{Code: "EUR", Scale: 2}),
+		OriginalAmount: &original,
+		FxRate:         &rate,
+		SourceType:     models.SourceType{ID: sourceTypeId},
+		User:           models.User{ID: userId},
+	}
+	data, err := transactionRepo.InsertTransactionAndUpdateUserBalanceWithCreatingTransaction(context.Background(), transaction)
+	require.NoError(t, err)
+	assert.Equal(t, "9.23", data.UserBalance.String())
+
+	stored, err := transactionRepo.GetByTransactionID(context.Background(), transaction.TransactionID)
+	require.NoError(t, err)
+	require.NotNil(t, stored.OriginalAmount)
+	require.NotNil(t, stored.FxRate)
+	assert.Equal(t, "EUR", stored.Amount.Currency.Code)
+	assert.Equal(t, "9.23", stored.Amount.String())
+	assert.Equal(t, "USD", stored.OriginalAmount.Currency.Code)
+	assert.Equal(t, "10.00", stored.OriginalAmount.String())
+	assert.True(t, rate.Equal(*stored.FxRate))
+}
@@ -0,0 +1,138 @@
+package repositories
+
+import (
+	"context"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/mufasadev/enlabs-test/internal/domain/models"
+	"github.com/mufasadev/enlabs-test/internal/domain/repositories"
+	"github.com/shopspring/decimal"
+	"time"
+)
+
+type LedgerRepositoryImpl struct {
+	db *pgxpool.Pool
+}
+
+func NewLedgerRepositoryImpl(db *pgxpool.Pool) repositories.LedgerRepository {
+	return &LedgerRepositoryImpl{
+		db: db,
+	}
+}
+
+// GetAccountBalance returns the balance of a ledger account in the currency as the sum of its entries.
+func (r *LedgerRepositoryImpl) GetAccountBalance(ctx context.Context, accountType string, accountID string, currency string) (decimal.Decimal, error) {
+	var balance decimal.Decimal
+	err := r.db.QueryRow(
+		ctx,
+		`SELECT COALESCE(SUM(CASE WHEN direction = 'credit' THEN amount ELSE -amount END), 0)
+		FROM ledger_entries WHERE account_type = $1 AND account_id = $2 AND currency = $3`,
+		accountType,
+		accountID,
+		currency,
+	).Scan(&balance)
+
+	return balance, err
+}
+
+// GetByTransactionID returns all ledger entries posted for a transaction.
+func (r *LedgerRepositoryImpl) GetByTransactionID(ctx context.Context, transactionID string) ([]models.LedgerEntry, error) {
+	rows, err := r.db.Query(
+		ctx,
+		`SELECT id, posting_id, transaction_id, entry_type, account_type, account_id, direction, amount, currency, created_at
+		FROM ledger_entries WHERE transaction_id = $1 ORDER BY created_at, entry_type, account_type`,
+		transactionID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	entries := make([]models.LedgerEntry, 0)
+	for rows.Next() {
+		var e models.LedgerEntry
+		err = rows.Scan(&e.ID, &e.PostingID, &e.TransactionID, &e.EntryType, &e.AccountType, &e.AccountID, &e.Direction, &e.Amount, &e.Currency, &e.CreatedAt)
+		if err != nil {
+			return nil, err
+		}
+		entries = append(entries, e)
+	}
+
+	return entries, rows.Err()
+}
+
+// CountUnbalancedPostings returns the number of postings whose debits and credits do not match.
+func (r *LedgerRepositoryImpl) CountUnbalancedPostings(ctx context.Context) (int, error) {
+	var count int
+	err := r.db.QueryRow(
+		ctx,
+		`SELECT COUNT(*) FROM (
+			SELECT posting_id
+			FROM ledger_entries
+			GROUP BY posting_id, currency
+			HAVING SUM(CASE WHEN direction = 'credit' THEN amount ELSE -amount END) <> 0
+		) unbalanced`,
+	).Scan(&count)
+
+	return count, err
+}
+
+// userStatementLines returns the entries of a user account in a currency in a period with the transaction they
+// were posted for.
+const userStatementLines = `
+SELECT le.entry_type,
+       t.transaction_id,
+       t.state,
+       s.name,
+       CASE WHEN le.direction = 'credit' THEN le.amount ELSE -le.amount END,
+       le.created_at
+FROM ledger_entries le
+LEFT JOIN transactions t ON t.id = le.transaction_id
+LEFT JOIN sources s ON s.id = t.source_id
+WHERE le.account_type = 'user'
+  AND le.account_id = $1
+  AND le.currency = $4
+  AND le.created_at >= $2
+  AND le.created_at <= $3
+ORDER BY le.created_at, le.id;`
+
+// StreamUserStatement calls opening with the user balance in the currency right before from and then line with
+// every change of that balance in [from, to], oldest first. Rows are passed on as they are read, and all of them come from the same
+// snapshot as the opening balance.
+func (r *LedgerRepositoryImpl) StreamUserStatement(ctx context.Context, userID string, currency string, from time.Time, to time.Time, opening func(balance decimal.Decimal) error, line func(l *models.StatementLine) error) error {
+	tx, err := r.db.BeginTx(ctx, pgx.TxOptions{IsoLevel: pgx.RepeatableRead, AccessMode: pgx.ReadOnly})
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	// timestamps are stored with microsecond precision, so this excludes exactly the entries made at from
+	var balance decimal.Decimal
+	if err = tx.QueryRow(ctx, userBalanceAt, userID, from.Add(-time.Microsecond), currency).Scan(&balance); err != nil {
+		return err
+	}
+	if err = opening(balance); err != nil {
+		return err
+	}
+
+	rows, err := tx.Query(ctx, userStatementLines, userID, from, to, currency)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var l models.StatementLine
+		if err = rows.Scan(&l.EntryType, &l.TransactionID, &l.State, &l.Source, &l.Amount, &l.CreatedAt); err != nil {
+			return err
+		}
+		if err = line(&l); err != nil {
+			return err
+		}
+	}
+	if err = rows.Err(); err != nil {
+		return err
+	}
+
+	return tx.Commit(ctx)
+}
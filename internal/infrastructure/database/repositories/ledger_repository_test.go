@@ -0,0 +1,206 @@
+package repositories
+
+import (
+	"context"
+	"github.com/google/uuid"
+	"github.com/mufasadev/enlabs-test/internal/domain/models"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestLedgerMatchesUserBalance(t *testing.T) {
+	setupDB()
+	defer db.Close()
+
+	transactionRepo := NewTransactionRepositoryImpl(db)
+	userRepo := NewUserRepositoryImpl(db)
+	ledgerRepo := NewLedgerRepositoryImpl(db)
+
+	err := truncateTransactionsTable(db)
+	require.NoError(t, err)
+	err = setInitialUserBalance(db, 0)
+	require.NoError(t, err)
+
+	// open the ledger with an adjustment, so the balance and the entries start in sync
+	err = userRepo.Update(context.Background(), &models.User{ID: userId, Balance: decimal.NewFromInt(1000)})
+	require.NoError(t, err)
+
+	t.Run("transaction_posting", func(t *testing.T) {
+		transaction := &models.Transaction{
+			TransactionID: uuid.New().String(),
+			State:         "win",
+			Amount:        newMoney(decimal.NewFromFloat(10.5)),
+			SourceType:    models.SourceType{ID: sourceTypeId},
+			User:          models.User{ID: userId},
+		}
+		_, err = transactionRepo.InsertTransactionAndUpdateUserBalanceWithCreatingTransaction(context.Background(), transaction)
+		require.NoError(t, err)
+
+		stored, err := transactionRepo.GetByTransactionID(context.Background(), transaction.TransactionID)
+		require.NoError(t, err)
+
+		entries, err := ledgerRepo.GetByTransactionID(context.Background(), stored.ID)
+		require.NoError(t, err)
+		require.Len(t, entries, 2)
+
+		for _, entry := range entries {
+			assert.True(t, entry.Amount.Equal(transaction.Amount.Decimal))
+			assert.Equal(t, stored.ID, entry.PostingID)
+			switch entry.AccountType {
+			case models.AccountTypeUser:
+				assert.Equal(t, models.DirectionCredit, entry.Direction)
+			case models.AccountTypeSource:
+				assert.Equal(t, models.DirectionDebit, entry.Direction)
+			default:
+				t.Errorf("unexpected account type %s", entry.AccountType)
+			}
+		}
+	})
+
+	t.Run("rejected_transaction_not_posted", func(t *testing.T) {
+		transaction := &models.Transaction{
+			TransactionID: uuid.New().String(),
+			State:         "lost",
+			Amount:        newMoney(decimal.NewFromInt(1000000)),
+			SourceType:    models.SourceType{ID: sourceTypeId},
+			User:          models.User{ID: userId},
+		}
+		_, _ = transactionRepo.InsertTransactionAndUpdateUserBalanceWithCreatingTransaction(context.Background(), transaction)
+
+		stored, err := transactionRepo.GetByTransactionID(context.Background(), transaction.TransactionID)
+		require.NoError(t, err)
+
+		entries, err := ledgerRepo.GetByTransactionID(context.Background(), stored.ID)
+		require.NoError(t, err)
+		assert.Len(t, entries, 0)
+	})
+
+	t.Run("concurrent_with_cancellations", func(t *testing.T) {
+		n := 50
+		var wg sync.WaitGroup
+		wg.Add(n)
+
+		for i := 0; i < n; i++ {
+			go func(i int) {
+				defer wg.Done()
+
+				state := "win"
+				if i%2 == 0 {
+					state = "lost"
+				}
+
+				transaction := &models.Transaction{
+					TransactionID: uuid.New().String(),
+					State:         state,
+					Amount:        newMoney(randDecimal(0)),
+					SourceType:    models.SourceType{ID: sourceTypeId},
+					User:          models.User{ID: userId},
+				}
+				_, _ = transactionRepo.InsertTransactionAndUpdateUserBalanceWithCreatingTransaction(context.Background(), transaction)
+				_, _ = transactionRepo.CancelOddTransactionsAndUpdateBalance(context.Background())
+			}(i)
+		}
+
+		wg.Wait()
+
+		var balance decimal.Decimal
+		err = db.QueryRow(context.Background(), "SELECT balance FROM wallets WHERE user_id = $1 AND currency = 'EUR'", userId).Scan(&balance)
+		require.NoError(t, err)
+
+		ledgerBalance, err := ledgerRepo.GetAccountBalance(context.Background(), models.AccountTypeUser, userId, "EUR")
+		require.NoError(t, err)
+		assert.True(t, balance.Equal(ledgerBalance), "The balance must be equal to the sum of ledger entries")
+
+		unbalanced, err := ledgerRepo.CountUnbalancedPostings(context.Background())
+		require.NoError(t, err)
+		assert.Equal(t, 0, unbalanced, "Every posting must balance")
+	})
+}
+
+func TestStreamUserStatement(t *testing.T) {
+	setupDB()
+	defer db.Close()
+
+	transactionRepo := NewTransactionRepositoryImpl(db)
+	userRepo := NewUserRepositoryImpl(db)
+	ledgerRepo := NewLedgerRepositoryImpl(db)
+
+	err := truncateTransactionsTable(db)
+	require.NoError(t, err)
+
+	user, err := createTestUser(db)
+	require.NoError(t, err)
+	defer func() {
+		_ = truncateTransactionsTable(db)
+		_ = deleteTestUser(db, user)
+	}()
+
+	err = userRepo.Update(context.Background(), &models.User{ID: user, Balance: decimal.NewFromInt(100)})
+	require.NoError(t, err)
+
+	stored := make([]*models.Transaction, 0)
+	for _, state := range []string{"win", "lost", "win", "lost"} {
+		transaction := &models.Transaction{
+			TransactionID: uuid.New().String(),
+			State:         state,
+			Amount:        newMoney(randDecimal(0)),
+			SourceType:    models.SourceType{ID: sourceTypeId},
+			User:          models.User{ID: user},
+		}
+		_, err = transactionRepo.InsertTransactionAndUpdateUserBalanceWithCreatingTransaction(context.Background(), transaction)
+		require.NoError(t, err)
+
+		tx, err := transactionRepo.GetByTransactionID(context.Background(), transaction.TransactionID)
+		require.NoError(t, err)
+		stored = append(stored, tx)
+	}
+
+	cancelled, err := transactionRepo.CancelTransactionsAndUpdateBalance(context.Background(), NewGlobalOddStrategy(20), "")
+	require.NoError(t, err)
+	require.NotEmpty(t, cancelled)
+
+	current, err := userRepo.GetByID(context.Background(), user)
+	require.NoError(t, err)
+
+	// the period starts at the second transaction, so the adjustment and the first one make the opening balance
+	var opening decimal.Decimal
+	lines := make([]models.StatementLine, 0)
+	err = ledgerRepo.StreamUserStatement(
+		context.Background(),
+		user,
+		"EUR",
+		stored[1].CreatedAt,
+		time.Now(),
+		func(balance decimal.Decimal) error {
+			opening = balance
+			return nil
+		},
+		func(l *models.StatementLine) error {
+			lines = append(lines, *l)
+			return nil
+		},
+	)
+	require.NoError(t, err)
+
+	assert.True(t, stored[0].BalanceAfter.Equal(opening), "The opening balance must be the balance before the period")
+	require.Len(t, lines, 3+len(cancelled))
+
+	closing := opening
+	cancellations := 0
+	for idx, l := range lines {
+		closing = closing.Add(l.Amount)
+		if idx > 0 {
+			assert.False(t, l.CreatedAt.Before(lines[idx-1].CreatedAt), "Lines must be ordered by date")
+		}
+		require.NotNil(t, l.TransactionID)
+		if l.EntryType == models.EntryTypeCancellation {
+			cancellations++
+		}
+	}
+	assert.Equal(t, len(cancelled), cancellations, "Every cancellation must be a separate line")
+	assert.True(t, current.Balance.Equal(closing), "The closing balance must be the current balance")
+}
@@ -0,0 +1,221 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/mufasadev/enlabs-test/internal/domain/models"
+	"github.com/mufasadev/enlabs-test/internal/domain/repositories"
+)
+
+type ReconciliationRepositoryImpl struct {
+	db *pgxpool.Pool
+}
+
+func NewReconciliationRepositoryImpl(db *pgxpool.Pool) repositories.ReconciliationRepository {
+	return &ReconciliationRepositoryImpl{
+		db: db,
+	}
+}
+
+// reconcileBalances stores every wallet whose balance differs from the expected balance or from the ledger.
+// The expected balance is the signed sum of applied transactions, cancelled ones are netted out, plus the
+// opening and adjustment entries of the ledger. Transactions older than the ledger were booked by migration 021
+// and the openings hold the balance before them, so every applied transaction counts.
+const reconcileBalances = `
+WITH transaction_totals AS (
+  SELECT t.user_id, t.currency,
+         SUM(t.amount * t.sign) AS total
+  FROM transactions t
+  WHERE t.status = 'applied'
+  GROUP BY t.user_id, t.currency
+),
+ledger_totals AS (
+  SELECT le.account_id AS user_id, le.currency,
+         SUM(CASE WHEN le.direction = 'credit' THEN le.amount ELSE -le.amount END) AS total,
+         SUM(CASE WHEN le.entry_type IN ('opening', 'adjustment')
+                  THEN CASE WHEN le.direction = 'credit' THEN le.amount ELSE -le.amount END
+                  ELSE 0 END) AS booked_total
+  FROM ledger_entries le
+  WHERE le.account_type = 'user'
+  GROUP BY le.account_id, le.currency
+),
+balances AS (
+  SELECT w.user_id,
+         w.currency,
+         w.balance AS actual_balance,
+         COALESCE(tt.total, 0) + COALESCE(lt.booked_total, 0) AS expected_balance,
+         COALESCE(lt.total, 0) AS ledger_balance
+  FROM wallets w
+  LEFT JOIN transaction_totals tt ON tt.user_id = w.user_id AND tt.currency = w.currency
+  LEFT JOIN ledger_totals lt ON lt.user_id = w.user_id AND lt.currency = w.currency
+),
+drifts AS (
+  INSERT INTO reconciliation_drifts (run_id, user_id, currency, expected_balance, actual_balance, ledger_balance)
+  SELECT $1, user_id, currency, expected_balance, actual_balance, ledger_balance
+  FROM balances
+  WHERE expected_balance <> actual_balance OR ledger_balance <> actual_balance
+  RETURNING id, run_id, user_id, currency, expected_balance, actual_balance, ledger_balance, created_at
+)
+SELECT d.id, d.run_id, d.user_id, c.code, c.scale, d.expected_balance, d.actual_balance, d.ledger_balance, d.created_at
+FROM drifts d
+JOIN currencies c ON c.code = d.currency;`
+
+// Create starts a new reconciliation run.
+func (r *ReconciliationRepositoryImpl) Create(ctx context.Context) (*models.ReconciliationRun, error) {
+	run := &models.ReconciliationRun{}
+	err := r.db.QueryRow(
+		ctx,
+		`INSERT INTO reconciliation_runs DEFAULT VALUES RETURNING id, started_at`,
+	).Scan(&run.ID, &run.StartedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	return run, nil
+}
+
+// Reconcile compares the balance of every wallet with the expected balance and stores the drifts under the run.
+// Balances, transactions and ledger entries are read from the same snapshot.
+func (r *ReconciliationRepositoryImpl) Reconcile(ctx context.Context, runID string) (int, []models.BalanceDrift, error) {
+	tx, err := r.db.BeginTx(ctx, pgx.TxOptions{IsoLevel: pgx.RepeatableRead})
+	if err != nil {
+		return 0, nil, err
+	}
+	defer tx.Rollback(ctx)
+
+	rows, err := tx.Query(ctx, reconcileBalances, runID)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	drifts, err := scanBalanceDrifts(rows)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	var walletsChecked int
+	if err = tx.QueryRow(ctx, "SELECT COUNT(*) FROM wallets").Scan(&walletsChecked); err != nil {
+		return 0, nil, err
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		return 0, nil, err
+	}
+
+	return walletsChecked, drifts, nil
+}
+
+// Finish stores the outcome of a reconciliation run.
+func (r *ReconciliationRepositoryImpl) Finish(ctx context.Context, id string, walletsChecked int, driftedCount int, runErr error) error {
+	var message *string
+	if runErr != nil {
+		m := runErr.Error()
+		message = &m
+	}
+
+	_, err := r.db.Exec(
+		ctx,
+		`UPDATE reconciliation_runs SET finished_at = NOW(), wallets_checked = $2, drifted_count = $3, error = $4 WHERE id = $1`,
+		id,
+		walletsChecked,
+		driftedCount,
+		message,
+	)
+
+	return err
+}
+
+// GetByID returns a reconciliation run or nil when it does not exist.
+func (r *ReconciliationRepositoryImpl) GetByID(ctx context.Context, id string) (*models.ReconciliationRun, error) {
+	var run models.ReconciliationRun
+	err := r.db.QueryRow(
+		ctx,
+		`SELECT id, started_at, finished_at, wallets_checked, drifted_count, error FROM reconciliation_runs WHERE id = $1`,
+		id,
+	).Scan(&run.ID, &run.StartedAt, &run.FinishedAt, &run.WalletsChecked, &run.DriftedCount, &run.Error)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return &run, nil
+}
+
+// List returns reconciliation runs, newest first, starting after the cursor.
+func (r *ReconciliationRepositoryImpl) List(ctx context.Context, after *repositories.Cursor, limit int) ([]models.ReconciliationRun, error) {
+	var rows pgx.Rows
+	var err error
+	if after == nil {
+		rows, err = r.db.Query(
+			ctx,
+			`SELECT id, started_at, finished_at, wallets_checked, drifted_count, error FROM reconciliation_runs
+			ORDER BY started_at DESC, id DESC LIMIT $1`,
+			limit,
+		)
+	} else {
+		rows, err = r.db.Query(
+			ctx,
+			`SELECT id, started_at, finished_at, wallets_checked, drifted_count, error FROM reconciliation_runs
+			WHERE (started_at, id) < ($2, $3)
+			ORDER BY started_at DESC, id DESC LIMIT $1`,
+			limit,
+			after.CreatedAt,
+			after.ID,
+		)
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	runs := make([]models.ReconciliationRun, 0, limit)
+	for rows.Next() {
+		var run models.ReconciliationRun
+		err = rows.Scan(&run.ID, &run.StartedAt, &run.FinishedAt, &run.WalletsChecked, &run.DriftedCount, &run.Error)
+		if err != nil {
+			return nil, err
+		}
+		runs = append(runs, run)
+	}
+
+	return runs, rows.Err()
+}
+
+// ListDrifts returns the wallets found drifting by a run.
+func (r *ReconciliationRepositoryImpl) ListDrifts(ctx context.Context, runID string) ([]models.BalanceDrift, error) {
+	rows, err := r.db.Query(
+		ctx,
+		`SELECT d.id, d.run_id, d.user_id, c.code, c.scale, d.expected_balance, d.actual_balance, d.ledger_balance, d.created_at
+		FROM reconciliation_drifts d
+		JOIN currencies c ON c.code = d.currency
+		WHERE d.run_id = $1
+		ORDER BY d.user_id, d.currency`,
+		runID,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return scanBalanceDrifts(rows)
+}
+
+// scanBalanceDrifts reads and closes rows of reconciliation drifts.
+func scanBalanceDrifts(rows pgx.Rows) ([]models.BalanceDrift, error) {
+	defer rows.Close()
+
+	drifts := make([]models.BalanceDrift, 0)
+	for rows.Next() {
+		var d models.BalanceDrift
+		err := rows.Scan(&d.ID, &d.RunID, &d.UserID, &d.Currency.Code, &d.Currency.Scale, &d.ExpectedBalance, &d.ActualBalance, &d.LedgerBalance, &d.CreatedAt)
+		if err != nil {
+			return nil, err
+		}
+		drifts = append(drifts, d)
+	}
+
+	return drifts, rows.Err()
+}
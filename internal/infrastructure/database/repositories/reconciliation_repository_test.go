@@ -0,0 +1,183 @@
+package repositories
+
+import (
+	"context"
+	"github.com/google/uuid"
+	"github.com/mufasadev/enlabs-test/internal/domain/models"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"os"
+	"testing"
+	"time"
+)
+
+func TestReconcileBalances(t *testing.T) {
+	setupDB()
+	defer db.Close()
+
+	transactionRepo := NewTransactionRepositoryImpl(db)
+	userRepo := NewUserRepositoryImpl(db)
+	reconciliationRepo := NewReconciliationRepositoryImpl(db)
+
+	err := truncateTransactionsTable(db)
+	require.NoError(t, err)
+
+	user, err := createTestUser(db)
+	require.NoError(t, err)
+	defer func() {
+		_ = truncateTransactionsTable(db)
+		_ = deleteTestUser(db, user)
+	}()
+
+	err = userRepo.Update(context.Background(), &models.User{ID: user, Balance: decimal.NewFromInt(100)})
+	require.NoError(t, err)
+
+	for _, state := range []string{"win", "lost", "win"} {
+		_, err = transactionRepo.InsertTransactionAndUpdateUserBalanceWithCreatingTransaction(context.Background(), &models.Transaction{
+			TransactionID: uuid.New().String(),
+			State:         state,
+			Amount:        newMoney(randDecimal(0)),
+			SourceType:    models.SourceType{ID: sourceTypeId},
+			User:          models.User{ID: user},
+		})
+		require.NoError(t, err)
+	}
+
+	_, err = transactionRepo.CancelTransactionsAndUpdateBalance(context.Background(), NewGlobalOddStrategy(20), "")
+	require.NoError(t, err)
+
+	findDrift := func(drifts []models.BalanceDrift) *models.BalanceDrift {
+		for idx := range drifts {
+			if drifts[idx].UserID == user {
+				return &drifts[idx]
+			}
+		}
+		return nil
+	}
+
+	t.Run("consistent balance", func(t *testing.T) {
+		run, err := reconciliationRepo.Create(context.Background())
+		require.NoError(t, err)
+
+		walletsChecked, drifts, err := reconciliationRepo.Reconcile(context.Background(), run.ID)
+		require.NoError(t, err)
+		assert.GreaterOrEqual(t, walletsChecked, 1)
+		assert.Nil(t, findDrift(drifts), "Applied and cancelled transactions must add up to the balance")
+	})
+
+	t.Run("drifted balance", func(t *testing.T) {
+		stored, err := userRepo.GetByID(context.Background(), user)
+		require.NoError(t, err)
+
+		// change the balance behind the ledger's back
+		_, err = db.Exec(context.Background(), "UPDATE wallets SET balance = balance + 5 WHERE user_id = $1 AND currency = 'EUR'", user)
+		require.NoError(t, err)
+
+		run, err := reconciliationRepo.Create(context.Background())
+		require.NoError(t, err)
+
+		walletsChecked, drifts, err := reconciliationRepo.Reconcile(context.Background(), run.ID)
+		require.NoError(t, err)
+
+		drift := findDrift(drifts)
+		require.NotNil(t, drift)
+		assert.True(t, stored.Balance.Equal(drift.ExpectedBalance))
+		assert.True(t, stored.Balance.Equal(drift.LedgerBalance))
+		assert.True(t, stored.Balance.Add(decimal.NewFromInt(5)).Equal(drift.ActualBalance))
+
+		err = reconciliationRepo.Finish(context.Background(), run.ID, walletsChecked, len(drifts), nil)
+		require.NoError(t, err)
+
+		finished, err := reconciliationRepo.GetByID(context.Background(), run.ID)
+		require.NoError(t, err)
+		require.NotNil(t, finished)
+		assert.NotNil(t, finished.FinishedAt)
+		assert.Equal(t, walletsChecked, finished.WalletsChecked)
+		assert.Equal(t, len(drifts), finished.DriftedCount)
+
+		runDrifts, err := reconciliationRepo.ListDrifts(context.Background(), run.ID)
+		require.NoError(t, err)
+		assert.Len(t, runDrifts, len(drifts))
+		assert.NotNil(t, findDrift(runDrifts))
+	})
+}
+
+func TestReconcileMigratedPreLedgerTransactions(t *testing.T) {
+	setupDB()
+	defer db.Close()
+
+	transactionRepo := NewTransactionRepositoryImpl(db)
+	reconciliationRepo := NewReconciliationRepositoryImpl(db)
+
+	err := truncateTransactionsTable(db)
+	require.NoError(t, err)
+
+	// a held 100 before its first transaction, b held nothing
+	a, err := createTestUser(db)
+	require.NoError(t, err)
+	b, err := createTestUser(db)
+	require.NoError(t, err)
+	defer func() {
+		_ = truncateTransactionsTable(db)
+		_ = deleteTestUser(db, a)
+		_ = deleteTestUser(db, b)
+	}()
+
+	// history before the ledger was opened at ledgerStart: b played before a, and a lost bet of a was cancelled
+	ledgerStart := time.Now().Add(-24 * time.Hour)
+	insert := func(user string, state string, sign int, amount int64, status string, createdAt time.Time, updatedAt time.Time) {
+		_, err := db.Exec(
+			context.Background(),
+			`INSERT INTO transactions (transaction_id, state, sign, amount, currency, source_id, user_id, status, created_at, updated_at)
+			VALUES ($1, $2, $3, $4, 'EUR', $5, $6, $7, $8, $9)`,
+			uuid.New().String(), state, sign, amount, sourceTypeId, user, status, createdAt, updatedAt,
+		)
+		require.NoError(t, err)
+	}
+	insert(b, "win", 1, 40, "applied", ledgerStart.Add(-5*time.Hour), ledgerStart.Add(-5*time.Hour))
+	insert(a, "win", 1, 30, "applied", ledgerStart.Add(-3*time.Hour), ledgerStart.Add(-3*time.Hour))
+	insert(a, "lost", -1, 10, "cancelled", ledgerStart.Add(-2*time.Hour), ledgerStart.Add(-90*time.Minute))
+	insert(a, "win", 1, 20, "applied", ledgerStart.Add(-time.Hour), ledgerStart.Add(-time.Hour))
+
+	// the ledger opened with the balances held at ledgerStart
+	for user, balance := range map[string]int64{a: 150, b: 40} {
+		_, err = db.Exec(context.Background(), "UPDATE wallets SET balance = $1 WHERE user_id = $2 AND currency = 'EUR'", balance, user)
+		require.NoError(t, err)
+		_, err = db.Exec(
+			context.Background(),
+			`WITH opening AS (SELECT gen_random_uuid() AS posting_id)
+			INSERT INTO ledger_entries (posting_id, entry_type, account_type, account_id, direction, amount, currency, created_at)
+			SELECT o.posting_id, 'opening', e.account_type, e.account_id, e.direction, $2, 'EUR', $3
+			FROM opening o
+			CROSS JOIN LATERAL (VALUES
+			  ('user', $1::UUID, 'credit'),
+			  ('equity', '00000000-0000-0000-0000-000000000000'::UUID, 'debit')
+			) AS e (account_type, account_id, direction)`,
+			user, balance, ledgerStart,
+		)
+		require.NoError(t, err)
+	}
+
+	migration, err := os.ReadFile("../../../../migrations/021_backfill_pre_ledger_transactions.up.sql")
+	require.NoError(t, err)
+	_, err = db.Exec(context.Background(), string(migration))
+	require.NoError(t, err)
+
+	_, err = transactionRepo.InsertTransactionAndUpdateUserBalanceWithCreatingTransaction(context.Background(), &models.Transaction{
+		TransactionID: uuid.New().String(),
+		State:         "win",
+		Amount:        newMoney(decimal.NewFromInt(5)),
+		SourceType:    models.SourceType{ID: sourceTypeId},
+		User:          models.User{ID: a},
+	})
+	require.NoError(t, err)
+
+	run, err := reconciliationRepo.Create(context.Background())
+	require.NoError(t, err)
+	_, drifts, err := reconciliationRepo.Reconcile(context.Background(), run.ID)
+	require.NoError(t, err)
+	for _, drift := range drifts {
+		assert.NotContains(t, []string{a, b}, drift.UserID, "A migrated ledger must not drift")
+	}
+}
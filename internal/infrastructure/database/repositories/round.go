@@ -0,0 +1,248 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/mufasadev/enlabs-test/internal/domain/models"
+	"github.com/mufasadev/enlabs-test/internal/domain/repositories"
+	apperrors "github.com/mufasadev/enlabs-test/internal/errors"
+)
+
+type RoundRepositoryImpl struct {
+	db *pgxpool.Pool
+}
+
+func NewRoundRepositoryImpl(db *pgxpool.Pool) repositories.RoundRepository {
+	return &RoundRepositoryImpl{
+		db: db,
+	}
+}
+
+// selectRound selects a round of a user with the sums of its bets and wins. Rolled back transactions are counted,
+// so the sums show what was played in the round.
+const selectRound = `
+SELECT r.id, r.round_id, r.user_id, r.source_id, s.name, c.code, c.scale, r.status,
+       COALESCE(SUM(t.amount) FILTER (WHERE t.state = 'lost' AND t.status IN ('applied', 'cancelled')), 0),
+       COALESCE(SUM(t.amount) FILTER (WHERE t.state = 'win' AND t.status IN ('applied', 'cancelled')), 0),
+       r.created_at, r.updated_at, r.finished_at
+FROM rounds r
+JOIN sources s ON s.id = r.source_id
+JOIN currencies c ON c.code = r.currency
+LEFT JOIN transactions t ON t.round_id = r.id
+WHERE r.user_id = $1 AND r.round_id = $2
+GROUP BY r.id, s.name, c.code, c.scale`
+
+// rowQuerier is a pool or a transaction.
+type rowQuerier interface {
+	QueryRow(ctx context.Context, sql string, args ...interface{}) pgx.Row
+}
+
+// getRound returns a round of the user, or nil when it does not exist.
+func getRound(ctx context.Context, q rowQuerier, userID string, roundID string) (*models.Round, error) {
+	round := &models.Round{}
+	err := q.QueryRow(ctx, selectRound, userID, roundID).Scan(
+		&round.ID,
+		&round.RoundID,
+		&round.UserID,
+		&round.SourceType.ID,
+		&round.SourceType.Name,
+		&round.Currency.Code,
+		&round.Currency.Scale,
+		&round.Status,
+		&round.BetAmount,
+		&round.WinAmount,
+		&round.CreatedAt,
+		&round.UpdatedAt,
+		&round.FinishedAt,
+	)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return round, nil
+}
+
+// lockRound locks a round of the user for the rest of the transaction and returns its id, source, currency and
+// status, or nil when it does not exist.
+func lockRound(ctx context.Context, tx pgx.Tx, userID string, roundID string) (*models.Round, error) {
+	round := &models.Round{RoundID: roundID, UserID: userID}
+	err := tx.QueryRow(
+		ctx,
+		"SELECT id, source_id, currency, status FROM rounds WHERE user_id = $1 AND round_id = $2 FOR UPDATE",
+		userID,
+		roundID,
+	).Scan(&round.ID, &round.SourceType.ID, &round.Currency.Code, &round.Status)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return round, nil
+}
+
+// joinRound adds a transaction to its round in the transaction that stores it. A bet joins the round when it exists
+// and reports that it opens the round otherwise, a win needs the round of its bet. The round is locked, so it cannot
+// be closed or rolled back before the transaction is stored, and must be open and belong to the source and wallet
+// currency of the transaction.
+func joinRound(ctx context.Context, tx pgx.Tx, transaction *models.Transaction) (bool, error) {
+	if transaction.RoundID == "" {
+		return false, nil
+	}
+
+	round, err := lockRound(ctx, tx, transaction.User.ID, transaction.RoundID)
+	if err != nil {
+		return false, err
+	}
+	if round == nil {
+		if transaction.State == models.StateLost {
+			return true, nil
+		}
+		return false, apperrors.NewBadRequestError(fmt.Sprintf("Round %s has no bet", transaction.RoundID))
+	}
+	if round.Status != models.RoundStatusOpen {
+		return false, apperrors.NewConflictError(fmt.Sprintf("Round %s is %s", round.RoundID, round.Status))
+	}
+	if round.SourceType.ID != transaction.SourceType.ID {
+		return false, apperrors.NewBadRequestError(fmt.Sprintf("Round %s belongs to another source", round.RoundID))
+	}
+	if round.Currency.Code != transaction.Amount.Currency.Code {
+		return false, apperrors.NewBadRequestError(fmt.Sprintf("Round %s is played in %s", round.RoundID, round.Currency.Code))
+	}
+
+	return false, nil
+}
+
+// openRound opens the round of a stored bet and links the bet to it. It runs only for an applied bet, so a bet
+// rejected for insufficient funds opens no round. A round opened concurrently fails the transaction with a
+// serialization error, the retry joins it.
+func openRound(ctx context.Context, tx pgx.Tx, transaction *models.Transaction) error {
+	_, err := tx.Exec(
+		ctx,
+		`WITH new_round AS (
+			INSERT INTO rounds (round_id, user_id, source_id, currency) VALUES ($1, $2, $3, $4)
+			ON CONFLICT (user_id, round_id) DO NOTHING
+			RETURNING id
+		)
+		UPDATE transactions SET round_id = (SELECT id FROM new_round) WHERE transaction_id = $5`,
+		transaction.RoundID,
+		transaction.User.ID,
+		transaction.SourceType.ID,
+		transaction.Amount.Currency.Code,
+		transaction.TransactionID,
+	)
+
+	return err
+}
+
+// isRoundError reports whether err is a rejection of joinRound rather than a database error.
+func isRoundError(err error) bool {
+	return apperrors.As(err, new(*apperrors.BadRequestError)) || apperrors.As(err, new(*apperrors.ConflictError))
+}
+
+// GetByRoundID returns a round of the user, or nil when it does not exist.
+func (r *RoundRepositoryImpl) GetByRoundID(ctx context.Context, userID string, roundID string) (*models.Round, error) {
+	return getRound(ctx, r.db, userID, roundID)
+}
+
+// Close settles an open round, its transactions stay applied. Closing a closed round changes nothing.
+func (r *RoundRepositoryImpl) Close(ctx context.Context, userID string, roundID string) (*models.Round, error) {
+	for {
+		tx, err := r.db.BeginTx(ctx, pgx.TxOptions{IsoLevel: pgx.RepeatableRead})
+		if err != nil {
+			return nil, err
+		}
+
+		round, err := r.finish(ctx, tx, userID, roundID, models.RoundStatusClosed, func(*models.Round) error { return nil })
+		if err == nil {
+			err = tx.Commit(ctx)
+		}
+		if err == nil {
+			return round, nil
+		}
+		tx.Rollback(ctx)
+
+		if isSerializationError(err) {
+			continue
+		}
+		return nil, err
+	}
+}
+
+// Rollback reverses every applied transaction of an open round and marks it rolled back, all in one transaction.
+// The transactions are reversed like the cancel process does, with the same balance guard: if any of them would
+// take its wallet below the credit limit, nothing is reversed and an InsufficientFundsError is returned. Rolling
+// back a rolled back round changes nothing.
+func (r *RoundRepositoryImpl) Rollback(ctx context.Context, userID string, roundID string) (*models.Round, []repositories.CancelOddTransactionsAndUpdateBalanceRow, error) {
+	for {
+		tx, err := r.db.BeginTx(ctx, pgx.TxOptions{IsoLevel: pgx.Serializable})
+		if err != nil {
+			return nil, nil, err
+		}
+
+		rows := make([]repositories.CancelOddTransactionsAndUpdateBalanceRow, 0)
+		round, err := r.finish(ctx, tx, userID, roundID, models.RoundStatusRolledBack, func(round *models.Round) error {
+			var applied int
+			err := tx.QueryRow(ctx, "SELECT COUNT(*) FROM transactions WHERE round_id = $1 AND status = 'applied'", round.ID).Scan(&applied)
+			if err != nil {
+				return err
+			}
+
+			query, args := cancelTransactionsQuery(NewRoundStrategy(round.ID), "")
+			rows, err = queryCancelTransactions(ctx, tx, query, args...)
+			if err != nil {
+				return err
+			}
+			if len(rows) < applied {
+				return apperrors.NewInsufficientFundsError()
+			}
+			return nil
+		})
+		if err == nil {
+			err = tx.Commit(ctx)
+		}
+		if err == nil {
+			return round, rows, nil
+		}
+		tx.Rollback(ctx)
+
+		if isSerializationError(err) {
+			continue
+		}
+		return nil, nil, err
+	}
+}
+
+// finish moves a locked round to a final status after running apply, and returns the stored round. A round
+// already in the status is returned unchanged.
+func (r *RoundRepositoryImpl) finish(ctx context.Context, tx pgx.Tx, userID string, roundID string, status models.RoundStatus, apply func(*models.Round) error) (*models.Round, error) {
+	round, err := lockRound(ctx, tx, userID, roundID)
+	if err != nil {
+		return nil, err
+	}
+	if round == nil {
+		return nil, apperrors.NewNotFoundError("Round not found")
+	}
+
+	if round.Status != status {
+		if err = round.TransitionTo(status); err != nil {
+			return nil, err
+		}
+		if err = apply(round); err != nil {
+			return nil, err
+		}
+		_, err = tx.Exec(ctx, "UPDATE rounds SET status = $2, finished_at = NOW() WHERE id = $1", round.ID, string(status))
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return getRound(ctx, tx, userID, roundID)
+}
@@ -0,0 +1,149 @@
+package repositories
+
+import (
+	"context"
+	"github.com/google/uuid"
+	"github.com/mufasadev/enlabs-test/internal/domain/models"
+	"github.com/mufasadev/enlabs-test/internal/domain/repositories"
+	apperrors "github.com/mufasadev/enlabs-test/internal/errors"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func TestRounds(t *testing.T) {
+	setupDB()
+	defer db.Close()
+
+	roundRepo := NewRoundRepositoryImpl(db)
+	transactionRepo := NewTransactionRepositoryImpl(db)
+
+	err := truncateTransactionsTable(db)
+	require.NoError(t, err)
+	err = setInitialUserBalance(db, 10)
+	require.NoError(t, err)
+
+	apply := func(state string, amount int64, roundID string) (repositories.TransactionRow, error) {
+		return transactionRepo.InsertTransactionAndUpdateUserBalanceWithCreatingTransaction(context.Background(), &models.Transaction{
+			TransactionID: uuid.New().String(),
+			State:         state,
+			Amount:        newMoney(decimal.NewFromInt(amount)),
+			RoundID:       roundID,
+			SourceType:    models.SourceType{ID: sourceTypeId},
+			User:          models.User{ID: userId},
+		})
+	}
+	balance := func() decimal.Decimal {
+		b, err := transactionRepo.GetUserBalance(context.Background(), userId, "EUR")
+		require.NoError(t, err)
+		return *b
+	}
+
+	t.Run("win_needs_a_bet", func(t *testing.T) {
+		_, err := apply("win", 5, "no-bet")
+		assert.True(t, apperrors.As(err, new(*apperrors.BadRequestError)))
+
+		round, err := roundRepo.GetByRoundID(context.Background(), userId, "no-bet")
+		require.NoError(t, err)
+		assert.Nil(t, round)
+	})
+
+	t.Run("rollback", func(t *testing.T) {
+		_, err := apply("lost", 10, "r-1")
+		require.NoError(t, err)
+		_, err = apply("win", 25, "r-1")
+		require.NoError(t, err)
+		assert.True(t, decimal.NewFromInt(25).Equal(balance()))
+
+		round, err := roundRepo.GetByRoundID(context.Background(), userId, "r-1")
+		require.NoError(t, err)
+		require.NotNil(t, round)
+		assert.Equal(t, models.RoundStatusOpen, round.Status)
+		assert.Equal(t, "game", round.SourceType.Name)
+		assert.True(t, decimal.NewFromInt(10).Equal(round.BetAmount))
+		assert.True(t, decimal.NewFromInt(25).Equal(round.WinAmount))
+
+		round, rows, err := roundRepo.Rollback(context.Background(), userId, "r-1")
+		require.NoError(t, err)
+		assert.Len(t, rows, 2)
+		assert.Equal(t, models.RoundStatusRolledBack, round.Status)
+		assert.NotNil(t, round.FinishedAt)
+		assert.True(t, decimal.NewFromInt(10).Equal(balance()))
+
+		// a rolled back round takes no more transactions and a second rollback changes nothing
+		_, err = apply("win", 5, "r-1")
+		assert.True(t, apperrors.As(err, new(*apperrors.ConflictError)))
+		_, rows, err = roundRepo.Rollback(context.Background(), userId, "r-1")
+		require.NoError(t, err)
+		assert.Len(t, rows, 0)
+		assert.True(t, decimal.NewFromInt(10).Equal(balance()))
+	})
+
+	t.Run("close", func(t *testing.T) {
+		_, err := apply("lost", 4, "r-2")
+		require.NoError(t, err)
+
+		round, err := roundRepo.Close(context.Background(), userId, "r-2")
+		require.NoError(t, err)
+		assert.Equal(t, models.RoundStatusClosed, round.Status)
+
+		round, err = roundRepo.Close(context.Background(), userId, "r-2")
+		require.NoError(t, err)
+		assert.Equal(t, models.RoundStatusClosed, round.Status)
+
+		_, _, err = roundRepo.Rollback(context.Background(), userId, "r-2")
+		assert.True(t, apperrors.As(err, new(*apperrors.ConflictError)))
+		assert.True(t, decimal.NewFromInt(6).Equal(balance()))
+
+		_, err = roundRepo.Close(context.Background(), userId, "unknown")
+		assert.True(t, apperrors.As(err, new(*apperrors.NotFoundError)))
+	})
+
+	t.Run("rollback_is_atomic", func(t *testing.T) {
+		_, err := apply("lost", 6, "r-3")
+		require.NoError(t, err)
+		_, err = apply("win", 50, "r-3")
+		require.NoError(t, err)
+		// spending the win leaves too little to reverse it
+		_, err = apply("lost", 45, "")
+		require.NoError(t, err)
+		assert.True(t, decimal.NewFromInt(5).Equal(balance()))
+
+		_, _, err = roundRepo.Rollback(context.Background(), userId, "r-3")
+		assert.True(t, apperrors.As(err, new(*apperrors.InsufficientFundsError)))
+
+		round, err := roundRepo.GetByRoundID(context.Background(), userId, "r-3")
+		require.NoError(t, err)
+		assert.Equal(t, models.RoundStatusOpen, round.Status)
+		assert.True(t, decimal.NewFromInt(5).Equal(balance()))
+
+		page, err := transactionRepo.List(context.Background(), repositories.TransactionFilter{UserID: userId, RoundID: "r-3", Limit: 10})
+		require.NoError(t, err)
+		require.Len(t, page, 2)
+		for _, tx := range page {
+			assert.Equal(t, models.StatusApplied, tx.Status)
+			assert.Equal(t, "r-3", tx.RoundID)
+		}
+	})
+	t.Run("rejected_bet_opens_no_round", func(t *testing.T) {
+		_, err := apply("lost", 50, "r-4")
+		assert.True(t, apperrors.As(err, new(*apperrors.InsufficientFundsError)))
+
+		round, err := roundRepo.GetByRoundID(context.Background(), userId, "r-4")
+		require.NoError(t, err)
+		assert.Nil(t, round)
+		_, err = apply("win", 5, "r-4")
+		assert.True(t, apperrors.As(err, new(*apperrors.BadRequestError)))
+
+		// a later bet with enough funds opens the round
+		_, err = apply("lost", 5, "r-4")
+		require.NoError(t, err)
+		round, err = roundRepo.GetByRoundID(context.Background(), userId, "r-4")
+		require.NoError(t, err)
+		require.NotNil(t, round)
+		assert.Equal(t, models.RoundStatusOpen, round.Status)
+		assert.True(t, decimal.NewFromInt(5).Equal(round.BetAmount))
+		assert.True(t, decimal.Zero.Equal(balance()))
+	})
+}
@@ -2,12 +2,19 @@ package repositories
 
 import (
 	"context"
+	"errors"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
 	"github.com/jackc/pgx/v5/pgxpool"
 	"github.com/mufasadev/enlabs-test/internal/domain/models"
 	"github.com/mufasadev/enlabs-test/internal/domain/repositories"
+	apperrors "github.com/mufasadev/enlabs-test/internal/errors"
 	"strings"
 )
 
+const selectSources = `SELECT id, name, display_name, enabled, settings, max_amount, max_daily_win, max_daily_loss, states, created_at, updated_at
+FROM sources`
+
 type SourceTypeRepositoryImpl struct {
 	db *pgxpool.Pool
 }
@@ -20,15 +27,145 @@ func NewSourceTypeRepositoryImpl(db *pgxpool.Pool) repositories.SourceTypeReposi
 
 func (r *SourceTypeRepositoryImpl) GetByName(ctx context.Context, name string) (*models.SourceType, error) {
 	sourceType := &models.SourceType{}
-	err := r.db.QueryRow(
+	err := scanSource(r.db.QueryRow(
 		ctx,
-		"SELECT id, name FROM sources WHERE name = $1",
+		selectSources+" WHERE name = $1",
 		strings.ToLower(name),
-	).Scan(&sourceType.ID, &sourceType.Name)
+	), sourceType)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return sourceType, nil
+}
+
+// GetByID returns a source or nil when it does not exist.
+func (r *SourceTypeRepositoryImpl) GetByID(ctx context.Context, id string) (*models.SourceType, error) {
+	sourceType := &models.SourceType{}
+	err := scanSource(r.db.QueryRow(
+		ctx,
+		selectSources+" WHERE id = $1",
+		id,
+	), sourceType)
 
 	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, nil
+		}
 		return nil, err
 	}
 
 	return sourceType, nil
 }
+
+// List returns all sources ordered by name.
+func (r *SourceTypeRepositoryImpl) List(ctx context.Context) ([]models.SourceType, error) {
+	rows, err := r.db.Query(ctx, selectSources+" ORDER BY name")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	sources := make([]models.SourceType, 0)
+	for rows.Next() {
+		var source models.SourceType
+		if err = scanSource(rows, &source); err != nil {
+			return nil, err
+		}
+		sources = append(sources, source)
+	}
+
+	return sources, rows.Err()
+}
+
+// Create stores a new source and fills in its id and timestamps. A source without states gets the default ones.
+func (r *SourceTypeRepositoryImpl) Create(ctx context.Context, source *models.SourceType) error {
+	if source.States == nil {
+		source.States = append([]string(nil), models.DefaultSourceStates...)
+	}
+	err := r.db.QueryRow(
+		ctx,
+		`INSERT INTO sources (name, display_name, enabled, settings, max_amount, max_daily_win, max_daily_loss, states)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		RETURNING id, created_at, updated_at`,
+		strings.ToLower(source.Name),
+		source.DisplayName,
+		source.Enabled,
+		source.Settings,
+		source.Limits.MaxAmount,
+		source.Limits.MaxDailyWin,
+		source.Limits.MaxDailyLoss,
+		source.States,
+	).Scan(&source.ID, &source.CreatedAt, &source.UpdatedAt)
+
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.SQLState() == repositories.UniqueViolationError {
+		return apperrors.NewConflictError("Source already exists")
+	}
+
+	return err
+}
+
+// Update stores the display name, enabled flag, settings, limits and states of a source. The name of a source never
+// changes.
+func (r *SourceTypeRepositoryImpl) Update(ctx context.Context, source *models.SourceType) error {
+	err := r.db.QueryRow(
+		ctx,
+		`UPDATE sources
+		SET display_name = $2, enabled = $3, settings = $4, max_amount = $5, max_daily_win = $6, max_daily_loss = $7,
+		    states = $8
+		WHERE id = $1
+		RETURNING updated_at`,
+		source.ID,
+		source.DisplayName,
+		source.Enabled,
+		source.Settings,
+		source.Limits.MaxAmount,
+		source.Limits.MaxDailyWin,
+		source.Limits.MaxDailyLoss,
+		source.States,
+	).Scan(&source.UpdatedAt)
+
+	if errors.Is(err, pgx.ErrNoRows) {
+		return apperrors.NewNotFoundError("Source not found")
+	}
+
+	return err
+}
+
+// Delete removes a source that has no transactions. Sources with transactions can only be disabled.
+func (r *SourceTypeRepositoryImpl) Delete(ctx context.Context, id string) error {
+	tag, err := r.db.Exec(ctx, "DELETE FROM sources WHERE id = $1", id)
+
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.SQLState() == repositories.ForeignKeyViolationError {
+		return apperrors.NewConflictError("Source has transactions, disable it instead")
+	}
+	if err != nil {
+		return err
+	}
+
+	if tag.RowsAffected() == 0 {
+		return apperrors.NewNotFoundError("Source not found")
+	}
+
+	return nil
+}
+
+// scanSource reads a row selected by selectSources.
+func scanSource(row pgx.Row, source *models.SourceType) error {
+	return row.Scan(
+		&source.ID,
+		&source.Name,
+		&source.DisplayName,
+		&source.Enabled,
+		&source.Settings,
+		&source.Limits.MaxAmount,
+		&source.Limits.MaxDailyWin,
+		&source.Limits.MaxDailyLoss,
+		&source.States,
+		&source.CreatedAt,
+		&source.UpdatedAt,
+	)
+}
@@ -0,0 +1,214 @@
+package repositories
+
+import (
+	"context"
+	"github.com/google/uuid"
+	"github.com/mufasadev/enlabs-test/internal/domain/models"
+	apperrors "github.com/mufasadev/enlabs-test/internal/errors"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"sync"
+	"testing"
+)
+
+func TestSourceTypeRepository(t *testing.T) {
+	setupDB()
+	defer db.Close()
+
+	sourceRepo := NewSourceTypeRepositoryImpl(db)
+	transactionRepo := NewTransactionRepositoryImpl(db)
+
+	source := &models.SourceType{
+		Name:        "provider_" + uuid.New().String()[:8],
+		DisplayName: "Provider",
+		Enabled:     true,
+		Settings:    map[string]interface{}{"currency": "EUR"},
+	}
+	err := sourceRepo.Create(context.Background(), source)
+	require.NoError(t, err)
+	require.NotEmpty(t, source.ID)
+
+	t.Run("duplicate_name", func(t *testing.T) {
+		err := sourceRepo.Create(context.Background(), &models.SourceType{Name: source.Name, Settings: map[string]interface{}{}})
+		assert.True(t, apperrors.As(err, new(*apperrors.ConflictError)))
+	})
+
+	t.Run("disable", func(t *testing.T) {
+		source.Enabled = false
+		err := sourceRepo.Update(context.Background(), source)
+		require.NoError(t, err)
+
+		stored, err := sourceRepo.GetByName(context.Background(), source.Name)
+		require.NoError(t, err)
+		assert.False(t, stored.Enabled)
+		assert.Equal(t, "EUR", stored.Settings["currency"])
+		assert.Equal(t, models.DefaultSourceStates, stored.States)
+	})
+
+	t.Run("states", func(t *testing.T) {
+		source.States = []string{models.StateWin, models.StateAdjustment, models.StateAdjustmentDebit}
+		err := sourceRepo.Update(context.Background(), source)
+		require.NoError(t, err)
+
+		stored, err := sourceRepo.GetByID(context.Background(), source.ID)
+		require.NoError(t, err)
+		assert.Equal(t, source.States, stored.States)
+		assert.True(t, stored.AllowsState(models.StateAdjustmentDebit))
+		assert.False(t, stored.AllowsState(models.StateLost))
+	})
+
+	t.Run("delete_source_with_transactions", func(t *testing.T) {
+		transaction := &models.Transaction{
+			TransactionID: uuid.New().String(),
+			State:         "win",
+			Amount:        newMoney(decimal.NewFromInt(1)),
+			SourceType:    models.SourceType{ID: source.ID},
+			User:          models.User{ID: userId},
+		}
+		_, err := transactionRepo.InsertTransactionAndUpdateUserBalanceWithCreatingTransaction(context.Background(), transaction)
+		require.NoError(t, err)
+
+		err = sourceRepo.Delete(context.Background(), source.ID)
+		assert.True(t, apperrors.As(err, new(*apperrors.ConflictError)))
+	})
+
+	t.Run("delete", func(t *testing.T) {
+		err := truncateTransactionsTable(db)
+		require.NoError(t, err)
+
+		err = sourceRepo.Delete(context.Background(), source.ID)
+		require.NoError(t, err)
+
+		stored, err := sourceRepo.GetByID(context.Background(), source.ID)
+		require.NoError(t, err)
+		assert.Nil(t, stored)
+
+		err = sourceRepo.Delete(context.Background(), source.ID)
+		assert.True(t, apperrors.As(err, new(*apperrors.NotFoundError)))
+	})
+}
+
+func TestSourceLimits(t *testing.T) {
+	setupDB()
+	defer db.Close()
+
+	sourceRepo := NewSourceTypeRepositoryImpl(db)
+	transactionRepo := NewTransactionRepositoryImpl(db)
+
+	err := truncateTransactionsTable(db)
+	require.NoError(t, err)
+	err = setInitialUserBalance(db, 1000)
+	require.NoError(t, err)
+
+	maxAmount := decimal.NewFromInt(50)
+	maxDailyWin := decimal.NewFromInt(100)
+	source := &models.SourceType{
+		Name:     "limited_" + uuid.New().String()[:8],
+		Enabled:  true,
+		Settings: map[string]interface{}{},
+		Limits:   models.SourceLimits{MaxAmount: &maxAmount, MaxDailyWin: &maxDailyWin},
+	}
+	err = sourceRepo.Create(context.Background(), source)
+	require.NoError(t, err)
+	defer func() {
+		_ = truncateTransactionsTable(db)
+		_ = sourceRepo.Delete(context.Background(), source.ID)
+	}()
+
+	newTransaction := func(state string, amount int64) *models.Transaction {
+		return &models.Transaction{
+			TransactionID: uuid.New().String(),
+			State:         state,
+			Amount:        newMoney(decimal.NewFromInt(amount)),
+			SourceType:    models.SourceType{ID: source.ID},
+			User:          models.User{ID: userId},
+		}
+	}
+
+	t.Run("max_amount", func(t *testing.T) {
+		transaction := newTransaction("lost", 51)
+		_, err := transactionRepo.InsertTransactionAndUpdateUserBalanceWithCreatingTransaction(context.Background(), transaction)
+		var limitErr *apperrors.LimitExceededError
+		require.True(t, apperrors.As(err, &limitErr))
+		assert.Equal(t, "max_amount", limitErr.Limit)
+
+		stored, err := transactionRepo.GetByTransactionID(context.Background(), transaction.TransactionID)
+		require.NoError(t, err)
+		assert.Nil(t, stored, "A transaction over the limit must not be stored")
+	})
+
+	t.Run("max_daily_win_concurrent", func(t *testing.T) {
+		n := 30
+		var wg sync.WaitGroup
+		var mu sync.Mutex
+		applied, exceeded := 0, 0
+		wg.Add(n)
+		for i := 0; i < n; i++ {
+			go func() {
+				defer wg.Done()
+				_, err := transactionRepo.InsertTransactionAndUpdateUserBalanceWithCreatingTransaction(context.Background(), newTransaction("win", 10))
+				mu.Lock()
+				defer mu.Unlock()
+				if err == nil {
+					applied++
+				} else if apperrors.As(err, new(*apperrors.LimitExceededError)) {
+					exceeded++
+				}
+			}()
+		}
+		wg.Wait()
+
+		assert.Equal(t, 10, applied, "The daily win volume must never exceed the limit")
+		assert.Equal(t, n-10, exceeded)
+	})
+
+	t.Run("cancelled_win_frees_the_daily_volume", func(t *testing.T) {
+		rows, err := transactionRepo.CancelTransactionsAndUpdateBalance(context.Background(), NewGlobalOddStrategy(1), "")
+		require.NoError(t, err)
+		require.Len(t, rows, 1)
+
+		_, err = transactionRepo.InsertTransactionAndUpdateUserBalanceWithCreatingTransaction(context.Background(), newTransaction("win", 10))
+		assert.NoError(t, err)
+
+		_, err = transactionRepo.InsertTransactionAndUpdateUserBalanceWithCreatingTransaction(context.Background(), newTransaction("win", 10))
+		assert.True(t, apperrors.As(err, new(*apperrors.LimitExceededError)))
+	})
+
+	t.Run("losses_are_not_limited_by_daily_win", func(t *testing.T) {
+		_, err := transactionRepo.InsertTransactionAndUpdateUserBalanceWithCreatingTransaction(context.Background(), newTransaction("lost", 10))
+		assert.NoError(t, err)
+	})
+
+	t.Run("daily_limit_set_during_the_day", func(t *testing.T) {
+		// the loss of 10 above was counted before a daily loss limit existed
+		maxDailyLoss := decimal.NewFromInt(15)
+		source.Limits.MaxDailyLoss = &maxDailyLoss
+		require.NoError(t, sourceRepo.Update(context.Background(), source))
+		defer func() {
+			source.Limits.MaxDailyLoss = nil
+			_ = sourceRepo.Update(context.Background(), source)
+		}()
+
+		_, err := transactionRepo.InsertTransactionAndUpdateUserBalanceWithCreatingTransaction(context.Background(), newTransaction("lost", 10))
+		var limitErr *apperrors.LimitExceededError
+		require.True(t, apperrors.As(err, &limitErr))
+		assert.Equal(t, "max_daily_loss", limitErr.Limit)
+	})
+
+	t.Run("limits_in_the_default_currency", func(t *testing.T) {
+		defer func() {
+			_, _ = db.Exec(context.Background(), "DELETE FROM wallets WHERE user_id = $1 AND currency = 'USD'", userId)
+		}()
+
+		// 40 USD are within the limit of 50 EUR, but not once converted
+		transaction := newTransaction("win", 40)
+		transaction.Amount = models.NewMoney(decimal.NewFromInt(40), models.Currency{Code: "USD", Scale: 2})
+		limitAmount := newMoney(decimal.NewFromInt(60))
+		transaction.LimitAmount = &limitAmount
+		_, err := transactionRepo.InsertTransactionAndUpdateUserBalanceWithCreatingTransaction(context.Background(), transaction)
+		var limitErr *apperrors.LimitExceededError
+		require.True(t, apperrors.As(err, &limitErr))
+		assert.Equal(t, "max_amount", limitErr.Limit)
+	})
+}
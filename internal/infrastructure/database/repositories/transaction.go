@@ -13,6 +13,8 @@ import (
 	"github.com/mufasadev/enlabs-test/pkg/log"
 	"github.com/rs/zerolog"
 	"github.com/shopspring/decimal"
+	"sort"
+	"strings"
 )
 
 type TransactionRepositoryImpl struct {
@@ -31,28 +33,39 @@ func NewTransactionRepositoryImpl(db *pgxpool.Pool) repositories.TransactionRepo
 
 const withoutCreatingTransaction = `
 WITH new_transaction AS (
-  INSERT INTO transactions (transaction_id, state, amount, source_id, user_id, processed)
-  VALUES ($1, $2, $3::NUMERIC(10,2), $4, $5, $6)
-  RETURNING transaction_id, state, amount, user_id, processed
+  INSERT INTO transactions (transaction_id, state, sign, amount, currency, source_id, user_id, status, balance_after)
+  VALUES ($1, $2, $7::SMALLINT, $3::NUMERIC(28,8), $8, $4, $5, $6, (
+    SELECT balance + $3::NUMERIC(28,8) * $7::SMALLINT
+    FROM wallets WHERE user_id = $5 AND currency = $8
+  ))
+  RETURNING id, transaction_id, state, sign, amount, currency, source_id, user_id, status
 ),
 amount_change AS (
-  SELECT (CASE
-            WHEN state = 'win' THEN amount
-            WHEN state = 'lost' THEN -amount
-          END) AS change
+  SELECT amount * sign AS change
   FROM new_transaction
 ),
 updated_balance AS (
-  UPDATE users
+  UPDATE wallets
   SET balance = balance + (SELECT change FROM amount_change)
-  WHERE id = (SELECT user_id FROM new_transaction) AND balance + (SELECT change FROM amount_change) >= 0
-  RETURNING id, balance
+  WHERE user_id = (SELECT user_id FROM new_transaction) AND currency = $8
+    AND balance + (SELECT change FROM amount_change) >= -credit_limit
+  RETURNING user_id AS id, balance
+),
+ledger AS (
+  INSERT INTO ledger_entries (posting_id, transaction_id, entry_type, account_type, account_id, direction, amount, currency)
+  SELECT nt.id, nt.id, 'transaction', e.account_type, e.account_id, e.direction, nt.amount, nt.currency
+  FROM new_transaction nt
+  CROSS JOIN LATERAL (VALUES
+    ('user', nt.user_id, CASE WHEN nt.sign > 0 THEN 'credit' ELSE 'debit' END),
+    ('source', nt.source_id, CASE WHEN nt.sign > 0 THEN 'debit' ELSE 'credit' END)
+  ) AS e (account_type, account_id, direction)
+  WHERE EXISTS (SELECT 1 FROM updated_balance)
 ),
 final_result AS (
-  SELECT updated_balance.id AS user_id, updated_balance.balance AS user_balance, new_transaction.transaction_id, new_transaction.processed
+  SELECT updated_balance.id AS user_id, updated_balance.balance AS user_balance, new_transaction.transaction_id, new_transaction.status
   FROM updated_balance, new_transaction
 )
-SELECT user_id, user_balance, transaction_id, processed FROM final_result;`
+SELECT user_id, user_balance, transaction_id, status FROM final_result;`
 
 // InsertTransactionAndUpdateUserBalanceWithoutCreatingTransaction inserts transaction and updates user balance in a single transaction.
 func (r *TransactionRepositoryImpl) InsertTransactionAndUpdateUserBalanceWithoutCreatingTransaction(ctx context.Context, transaction *models.Transaction) (repositories.TransactionRow, error) {
@@ -60,10 +73,16 @@ func (r *TransactionRepositoryImpl) InsertTransactionAndUpdateUserBalanceWithout
 	args := []interface{}{
 		transaction.TransactionID,
 		transaction.State,
-		transaction.Amount,
+		transaction.Amount.Decimal,
 		transaction.SourceType.ID,
 		transaction.User.ID,
-		true,
+		string(models.StatusApplied),
+		transaction.Sign(),
+		transaction.Amount.Currency.Code,
+	}
+
+	if err := ensureWallet(ctx, r.db, transaction.User.ID, transaction.Amount.Currency.Code); err != nil {
+		return repositories.TransactionRow{}, fmt.Errorf("transaction error: %w", err)
 	}
 
 	var data repositories.TransactionRow
@@ -72,7 +91,7 @@ func (r *TransactionRepositoryImpl) InsertTransactionAndUpdateUserBalanceWithout
 		data, err = r.processTransactionWithQuery(ctx, withoutCreatingTransaction, true, args...)
 
 		if err == nil {
-			_ = data
+			data.UserBalance.Currency = transaction.Amount.Currency
 			return data, nil
 		}
 
@@ -88,39 +107,94 @@ func (r *TransactionRepositoryImpl) InsertTransactionAndUpdateUserBalanceWithout
 	}
 }
 
+// withCreatingTransaction applies a transaction to the wallet of its currency, or stores it as rejected when the
+// balance is insufficient. The last column names the source limit the transaction exceeds, the caller must roll
+// back when it is set. Source limits are amounts of the default currency and are checked with $8, the amount in
+// the default currency. Every applied transaction adds $8 to the daily volume of its source, whether or not a
+// daily limit is set, so a limit set during the day counts the volume applied before it. Without $8 no limit
+// applies and the volume is not counted.
+// $9 to $11 hold the amount as sent, its currency and the applied rate of a converted transaction, NULL otherwise,
+// $12 the metadata and $13 the round id of the sender, which must have been joined in the same transaction. A bet
+// that opens its round is linked to it by openRound once it is applied.
 const withCreatingTransaction = `
-WITH updated_balance AS (
-  UPDATE users
-  SET balance = balance + (CASE WHEN $2 = 'win' THEN $3::NUMERIC(10,2) WHEN $2 = 'lost' THEN $3::NUMERIC(10,2) * -1 END)
-  WHERE id = $5 AND balance + (CASE WHEN $2 = 'win' THEN $3::NUMERIC(10,2) WHEN $2 = 'lost' THEN $3::NUMERIC(10,2) * -1 END) >= 0
-  RETURNING id, balance
+WITH source_limits AS (
+  SELECT max_amount,
+         CASE WHEN $6::SMALLINT > 0 THEN max_daily_win ELSE max_daily_loss END AS max_daily
+  FROM sources
+  WHERE id = $4 AND $8::NUMERIC(28,8) IS NOT NULL
+),
+updated_balance AS (
+  UPDATE wallets
+  SET balance = balance + $3::NUMERIC(28,8) * $6::SMALLINT
+  WHERE user_id = $5 AND currency = $7 AND balance + $3::NUMERIC(28,8) * $6::SMALLINT >= -credit_limit
+  RETURNING user_id AS id, balance
 ),
 new_transaction AS (
-  INSERT INTO transactions (transaction_id, state, amount, source_id, user_id, processed)
-  VALUES ($1, $2, $3::NUMERIC(10,2), $4, $5, EXISTS (SELECT 1 FROM updated_balance))
-  RETURNING transaction_id, processed
+  INSERT INTO transactions (transaction_id, state, sign, amount, currency, source_id, user_id, status, balance_after,
+                            original_amount, original_currency, fx_rate, metadata, round_id, source_volume)
+  VALUES ($1, $2, $6::SMALLINT, $3::NUMERIC(28,8), $7, $4, $5,
+          CASE WHEN EXISTS (SELECT 1 FROM updated_balance) THEN 'applied' ELSE 'rejected_insufficient_funds' END,
+          COALESCE((SELECT balance FROM updated_balance), (SELECT balance FROM wallets WHERE user_id = $5 AND currency = $7)),
+          $9::NUMERIC(28,8), $10, $11::NUMERIC(28,12), $12::JSONB,
+          (SELECT id FROM rounds WHERE user_id = $5 AND round_id = $13),
+          CASE WHEN EXISTS (SELECT 1 FROM updated_balance) THEN $8::NUMERIC(28,8) END)
+  RETURNING id, transaction_id, state, sign, amount, currency, source_id, user_id, status, balance_after, source_volume
+),
+-- credits count towards the daily win volume and debits towards the daily loss volume
+daily_volume AS (
+  INSERT INTO source_daily_volumes (source_id, day, state, volume)
+  SELECT nt.source_id, (NOW() AT TIME ZONE 'UTC')::DATE, CASE WHEN nt.sign > 0 THEN 'win' ELSE 'lost' END, nt.source_volume
+  FROM new_transaction nt
+  WHERE nt.source_volume IS NOT NULL
+  ON CONFLICT (source_id, day, state) DO UPDATE SET volume = source_daily_volumes.volume + EXCLUDED.volume
+  RETURNING volume
+),
+ledger AS (
+  INSERT INTO ledger_entries (posting_id, transaction_id, entry_type, account_type, account_id, direction, amount, currency)
+  SELECT nt.id, nt.id, 'transaction', e.account_type, e.account_id, e.direction, nt.amount, nt.currency
+  FROM new_transaction nt
+  CROSS JOIN LATERAL (VALUES
+    ('user', nt.user_id, CASE WHEN nt.sign > 0 THEN 'credit' ELSE 'debit' END),
+    ('source', nt.source_id, CASE WHEN nt.sign > 0 THEN 'debit' ELSE 'credit' END)
+  ) AS e (account_type, account_id, direction)
+  WHERE nt.status = 'applied'
 ),
 final_result AS (
   SELECT
     COALESCE((SELECT id FROM updated_balance), $5) AS uid,
-    COALESCE((SELECT balance FROM updated_balance), (SELECT balance FROM users WHERE id = $5)) AS ub,
+    new_transaction.balance_after AS ub,
     new_transaction.transaction_id AS tid,
-    new_transaction.processed AS p
+    new_transaction.status AS s,
+    CASE
+      WHEN $8::NUMERIC(28,8) > sl.max_amount THEN 'max_amount'
+      WHEN (SELECT volume FROM daily_volume) > sl.max_daily THEN
+        CASE WHEN $6::SMALLINT > 0 THEN 'max_daily_win' ELSE 'max_daily_loss' END
+    END AS l
   FROM new_transaction
+  LEFT JOIN source_limits sl ON TRUE
 )
-SELECT uid, ub, tid, p FROM final_result;`
+SELECT uid, ub, tid, s, l FROM final_result;`
 
 // InsertTransactionAndUpdateUserBalanceWithCreatingTransaction inserts transaction and updates user balance in a single transaction.
 func (r *TransactionRepositoryImpl) InsertTransactionAndUpdateUserBalanceWithCreatingTransaction(ctx context.Context, transaction *models.Transaction) (repositories.TransactionRow, error) {
 	args := []interface{}{
 		transaction.TransactionID,
 		transaction.State,
-		transaction.Amount,
+		transaction.Amount.Decimal,
 		transaction.SourceType.ID,
 		transaction.User.ID,
+		transaction.Sign(),
+		transaction.Amount.Currency.Code,
+		limitArg(transaction),
 	}
+	args = append(args, conversionArgs(transaction)...)
+	args = append(args, metadataArg(transaction), roundArg(transaction))
 
 	var data repositories.TransactionRow
+	if err := ensureWallet(ctx, r.db, transaction.User.ID, transaction.Amount.Currency.Code); err != nil {
+		return data, fmt.Errorf("transaction error: %w", err)
+	}
+
 	var pgErr *pgconn.PgError
 	for {
 		tx, err := r.db.BeginTx(ctx, pgx.TxOptions{IsoLevel: pgx.RepeatableRead})
@@ -128,15 +202,25 @@ func (r *TransactionRepositoryImpl) InsertTransactionAndUpdateUserBalanceWithCre
 			return data, err
 		}
 
-		err = tx.QueryRow(ctx, withCreatingTransaction, args...).Scan(&data.UserId, &data.UserBalance, &data.TransactionId, &data.Processed)
+		var exceededLimit *string
+		opensRound, err := joinRound(ctx, tx, transaction)
+		if err == nil {
+			err = tx.QueryRow(ctx, withCreatingTransaction, args...).Scan(&data.UserId, &data.UserBalance.Decimal, &data.TransactionId, &data.Status, &exceededLimit)
+		}
+		if err == nil && exceededLimit == nil && opensRound && data.Status == models.StatusApplied {
+			err = openRound(ctx, tx, transaction)
+		}
 		if err != nil {
 			r.logger.Error().Err(err).Msg("transaction error")
 			tx.Rollback(ctx)
+		} else if exceededLimit != nil {
+			tx.Rollback(ctx)
+			return repositories.TransactionRow{}, apperrors.NewLimitExceededError(*exceededLimit)
 		} else {
 			err = tx.Commit(ctx)
 			if err == nil {
-				_ = data
-				if data.Processed {
+				data.UserBalance.Currency = transaction.Amount.Currency
+				if data.Status == models.StatusApplied {
 					return data, nil
 				} else {
 					return data, apperrors.NewInsufficientFundsError()
@@ -154,11 +238,132 @@ func (r *TransactionRepositoryImpl) InsertTransactionAndUpdateUserBalanceWithCre
 			if of := errors.As(err, &pgErr); of && pgErr.SQLState() == repositories.UniqueViolationError {
 				return data, apperrors.NewTransactionDuplicateError()
 			}
+			if isRoundError(err) {
+				return data, err
+			}
 			return data, fmt.Errorf("transaction error: %w", err)
 		}
 	}
 }
 
+// InsertTransactionsAndUpdateUserBalance applies the transactions in order in a single transaction. Either all of
+// them are applied or none is stored: a transaction with insufficient funds or over a source limit fails the whole
+// batch. On failure the returned rows hold the results of the transactions before the failing one.
+func (r *TransactionRepositoryImpl) InsertTransactionsAndUpdateUserBalance(ctx context.Context, transactions []*models.Transaction) ([]repositories.TransactionRow, error) {
+	for _, transaction := range transactions {
+		if err := ensureWallet(ctx, r.db, transaction.User.ID, transaction.Amount.Currency.Code); err != nil {
+			return nil, fmt.Errorf("transaction error: %w", err)
+		}
+	}
+
+	var pgErr *pgconn.PgError
+	for {
+		rows := make([]repositories.TransactionRow, 0, len(transactions))
+		tx, err := r.db.BeginTx(ctx, pgx.TxOptions{IsoLevel: pgx.RepeatableRead})
+		if err != nil {
+			return rows, err
+		}
+
+		for _, transaction := range transactions {
+			var data repositories.TransactionRow
+			var exceededLimit *string
+			args := []interface{}{
+				transaction.TransactionID,
+				transaction.State,
+				transaction.Amount.Decimal,
+				transaction.SourceType.ID,
+				transaction.User.ID,
+				transaction.Sign(),
+				transaction.Amount.Currency.Code,
+				limitArg(transaction),
+			}
+			args = append(args, conversionArgs(transaction)...)
+			args = append(args, metadataArg(transaction), roundArg(transaction))
+			var opensRound bool
+			if opensRound, err = joinRound(ctx, tx, transaction); err != nil {
+				break
+			}
+			err = tx.QueryRow(ctx, withCreatingTransaction, args...).Scan(&data.UserId, &data.UserBalance.Decimal, &data.TransactionId, &data.Status, &exceededLimit)
+			if err != nil {
+				break
+			}
+			if exceededLimit != nil {
+				tx.Rollback(ctx)
+				return rows, apperrors.NewLimitExceededError(*exceededLimit)
+			}
+			if data.Status != models.StatusApplied {
+				tx.Rollback(ctx)
+				return rows, apperrors.NewInsufficientFundsError()
+			}
+			if opensRound {
+				if err = openRound(ctx, tx, transaction); err != nil {
+					break
+				}
+			}
+			data.UserBalance.Currency = transaction.Amount.Currency
+			rows = append(rows, data)
+		}
+
+		if err == nil {
+			err = tx.Commit(ctx)
+			if err == nil {
+				return rows, nil
+			}
+		}
+		r.logger.Error().Err(err).Msg("transaction error")
+		tx.Rollback(ctx)
+
+		if isSerializationError(err) {
+			// retry the whole batch if serialization error occurs (SQLSTATE 40001)
+			continue
+		}
+		if errors.As(err, &pgErr) && pgErr.SQLState() == repositories.UniqueViolationError {
+			return rows, apperrors.NewTransactionDuplicateError()
+		}
+		if isRoundError(err) {
+			return rows, err
+		}
+		return rows, fmt.Errorf("transaction error: %w", err)
+	}
+}
+
+// conversionArgs returns the amount as sent, its currency and the applied rate of a converted transaction, or
+// NULLs for a transaction sent in the currency of its wallet.
+func conversionArgs(transaction *models.Transaction) []interface{} {
+	if transaction.OriginalAmount == nil {
+		return []interface{}{nil, nil, nil}
+	}
+	return []interface{}{transaction.OriginalAmount.Decimal, transaction.OriginalAmount.Currency.Code, transaction.FxRate}
+}
+
+// limitArg returns the amount of the transaction in the default currency, NULL when a transaction in another
+// currency was not converted because its source has no limits.
+func limitArg(transaction *models.Transaction) interface{} {
+	if transaction.Amount.Currency.Code == models.DefaultCurrency().Code {
+		return transaction.Amount.Decimal
+	}
+	if transaction.LimitAmount == nil {
+		return nil
+	}
+	return transaction.LimitAmount.Decimal
+}
+
+// metadataArg returns the metadata of the transaction, an empty object when it has none.
+func metadataArg(transaction *models.Transaction) map[string]interface{} {
+	if transaction.Metadata == nil {
+		return map[string]interface{}{}
+	}
+	return transaction.Metadata
+}
+
+// roundArg returns the round id of the sender, NULL for a transaction outside of any round.
+func roundArg(transaction *models.Transaction) interface{} {
+	if transaction.RoundID == "" {
+		return nil
+	}
+	return transaction.RoundID
+}
+
 // processTransactionWithQuery processes transaction with given query.
 func (r *TransactionRepositoryImpl) processTransactionWithQuery(ctx context.Context, query string, rollbackOnNoFunds bool, args ...interface{}) (repositories.TransactionRow, error) {
 	var tr repositories.TransactionRow
@@ -167,7 +372,7 @@ func (r *TransactionRepositoryImpl) processTransactionWithQuery(ctx context.Cont
 		return tr, err
 	}
 
-	err = tx.QueryRow(ctx, query, args...).Scan(&tr.UserId, &tr.UserBalance, &tr.TransactionId, &tr.Processed)
+	err = tx.QueryRow(ctx, query, args...).Scan(&tr.UserId, &tr.UserBalance.Decimal, &tr.TransactionId, &tr.Status)
 
 	if err != nil {
 		if errors.Is(err, pgx.ErrNoRows) && !rollbackOnNoFunds {
@@ -187,69 +392,142 @@ func (r *TransactionRepositoryImpl) processTransactionWithQuery(ctx context.Cont
 	return tr, nil
 }
 
-const cancelOddTransactions = `
-WITH odd_transactions_to_cancel AS (
-  SELECT id, user_id, state, amount, created_at, transaction_id
-  FROM (
-    SELECT id, state, amount, user_id, transaction_id, created_at,
-           DENSE_RANK() OVER (ORDER BY created_at DESC) AS rank
-    FROM transactions
-    WHERE processed = TRUE
-    LIMIT 20
-  ) ranked_transactions
-  WHERE rank <= 20 AND rank % 2 = 1
+// cancellationCandidates selects the candidates of a cancellation strategy. The candidates query is inserted
+// as the first CTE and must return the id, user_id, state, sign, amount, currency and created_at of applied transactions.
+const cancellationCandidates = `
+WITH transactions_to_cancel AS (
+%[1]s
 ),
 processable_transactions AS (
-  SELECT t.id, t.state, t.user_id, t.amount,
-         SUM(CASE
-               WHEN t.state = 'win' THEN -t.amount
-               WHEN t.state = 'lost' THEN t.amount
-             END) OVER (PARTITION BY t.user_id ORDER BY t.created_at) AS cumulative_change
-  FROM odd_transactions_to_cancel t
-),
+  SELECT t.id, t.state, t.sign, t.user_id, t.currency, t.amount, t.created_at,
+         SUM(-t.amount * t.sign) OVER (PARTITION BY t.user_id, t.currency ORDER BY t.created_at) AS cumulative_change
+  FROM transactions_to_cancel t
+),`
+
+// cancelTransactions reverses the candidates selected by a cancellation strategy on the wallets of their currency.
+// Every cancelled transaction is recorded in the cancellations table when a run id is given.
+const cancelTransactions = cancellationCandidates + `
 transactions_with_sufficient_balance AS (
   SELECT pt.id, pt.state, pt.user_id, pt.amount
   FROM processable_transactions pt
-  JOIN users u ON pt.user_id = u.id
-  WHERE u.balance + pt.cumulative_change >= 0
--- Uncomment this line to lock the user row in case of isolation level not serializable
+  JOIN wallets w ON w.user_id = pt.user_id AND w.currency = pt.currency
+  WHERE w.balance + pt.cumulative_change >= -w.credit_limit
+-- Uncomment this line to lock the wallet row in case of isolation level not serializable
 -- to avoid phantom reads
---   FOR UPDATE OF u
-),
-balance_changes AS (
-  SELECT user_id,
-         SUM(CASE
-               WHEN state = 'win' THEN -amount
-               WHEN state = 'lost' THEN amount
-             END) AS change
-  FROM transactions_with_sufficient_balance
-  GROUP BY user_id
+--   FOR UPDATE OF w
 ),
 updated_transactions AS (
   UPDATE transactions
-  SET processed = FALSE
-  WHERE id IN (SELECT id FROM transactions_with_sufficient_balance)
-  RETURNING id, state, user_id, amount
+  SET status = $%[3]d
+  WHERE id IN (SELECT id FROM transactions_with_sufficient_balance) AND status = ANY($%[2]d::VARCHAR[])
+  RETURNING id, state, sign, user_id, currency, source_id, amount, created_at, source_volume
+),
+-- a cancelled transaction no longer counts towards the daily volume of its source on the day it was applied
+source_volume_changes AS (
+  SELECT source_id, (created_at AT TIME ZONE 'UTC')::DATE AS day, CASE WHEN sign > 0 THEN 'win' ELSE 'lost' END AS state,
+         SUM(source_volume) AS volume
+  FROM updated_transactions
+  WHERE source_volume IS NOT NULL
+  GROUP BY 1, 2, 3
+),
+updated_source_volumes AS (
+  UPDATE source_daily_volumes v
+  SET volume = GREATEST(v.volume - svc.volume, 0)
+  FROM source_volume_changes svc
+  WHERE v.source_id = svc.source_id AND v.day = svc.day AND v.state = svc.state
+),
+balance_changes AS (
+  SELECT user_id, currency, SUM(-amount * sign) AS change
+  FROM updated_transactions
+  GROUP BY user_id, currency
+),
+cancellation_postings AS MATERIALIZED (
+  SELECT id, state, sign, user_id, currency, source_id, amount, gen_random_uuid() AS posting_id
+  FROM updated_transactions
+),
+ledger AS (
+  INSERT INTO ledger_entries (posting_id, transaction_id, entry_type, account_type, account_id, direction, amount, currency)
+  SELECT cp.posting_id, cp.id, 'cancellation', e.account_type, e.account_id, e.direction, cp.amount, cp.currency
+  FROM cancellation_postings cp
+  CROSS JOIN LATERAL (VALUES
+    ('user', cp.user_id, CASE WHEN cp.sign > 0 THEN 'debit' ELSE 'credit' END),
+    ('source', cp.source_id, CASE WHEN cp.sign > 0 THEN 'credit' ELSE 'debit' END)
+  ) AS e (account_type, account_id, direction)
+),
+updated_wallets AS (
+  UPDATE wallets w
+  SET balance = w.balance + bc.change
+  FROM balance_changes bc
+  WHERE w.user_id = bc.user_id AND w.currency = bc.currency
+  RETURNING w.user_id, w.currency, w.balance
+),
+cancellation_items AS (
+  SELECT ut.id, ut.user_id, ut.change,
+         w.balance + SUM(ut.change) OVER (PARTITION BY ut.user_id, ut.currency ORDER BY ut.created_at, ut.id) AS balance_after
+  FROM (
+    SELECT id, user_id, currency, created_at, -amount * sign AS change
+    FROM updated_transactions
+  ) ut
+  JOIN wallets w ON w.user_id = ut.user_id AND w.currency = ut.currency
+),
+audit AS (
+  INSERT INTO cancellations (run_id, transaction_id, user_id, balance_before, balance_after)
+  SELECT $%[4]d::UUID, ci.id, ci.user_id, ci.balance_after - ci.change, ci.balance_after
+  FROM cancellation_items ci
+  WHERE $%[4]d::UUID IS NOT NULL
 ),
-updated_users AS (
-    UPDATE users
-    SET balance = balance + COALESCE (bc.change, 0)
-    FROM balance_changes bc
-    WHERE users.id = bc.user_id
-           RETURNING users.id, users.balance
-    ),
 final_result AS (
-  SELECT uu.id AS user_id, uu.balance AS user_balance, ut.id AS transaction_id, ut.state AS state, ut.amount AS amount
-  FROM updated_users uu
-  JOIN updated_transactions ut ON uu.id = ut.user_id
+  SELECT uw.user_id, uw.balance AS user_balance, ut.id AS transaction_id, ut.state AS state, ut.amount AS amount,
+         c.code AS currency, c.scale AS scale,
+         ci.balance_after - ci.change AS balance_before, ci.balance_after AS balance_after
+  FROM updated_wallets uw
+  JOIN updated_transactions ut ON uw.user_id = ut.user_id AND uw.currency = ut.currency
+  JOIN cancellation_items ci ON ci.id = ut.id
+  JOIN currencies c ON c.code = ut.currency
 )
-SELECT user_id, user_balance, transaction_id, state, amount FROM final_result;
+SELECT user_id, user_balance, transaction_id, state, amount, currency, scale, balance_before, balance_after FROM final_result;
+`
+
+// previewCancelTransactions is the read-only variant of cancelTransactions. It returns every candidate with
+// the balance the cancellation would leave, and whether it would be cancelled or skipped for insufficient balance.
+const previewCancelTransactions = cancellationCandidates + `
+checked_transactions AS (
+  SELECT pt.id, pt.user_id, pt.currency, pt.state, pt.amount, pt.created_at,
+         -pt.amount * pt.sign AS change,
+         w.balance AS balance,
+         w.balance + pt.cumulative_change >= -w.credit_limit AS cancellable
+  FROM processable_transactions pt
+  JOIN wallets w ON w.user_id = pt.user_id AND w.currency = pt.currency
+),
+preview AS (
+  SELECT ct.id, ct.user_id, ct.currency, ct.state, ct.amount, ct.cancellable,
+         CASE WHEN ct.cancellable THEN ct.change ELSE 0 END AS change,
+         ct.balance + SUM(CASE WHEN ct.cancellable THEN ct.change ELSE 0 END)
+           OVER (PARTITION BY ct.user_id, ct.currency ORDER BY ct.created_at, ct.id) AS balance_after,
+         ct.balance + SUM(CASE WHEN ct.cancellable THEN ct.change ELSE 0 END)
+           OVER (PARTITION BY ct.user_id, ct.currency) AS user_balance
+  FROM checked_transactions ct
+)
+SELECT p.user_id, p.user_balance, t.transaction_id, p.state, p.amount, c.code, c.scale, p.cancellable,
+       p.balance_after - p.change AS balance_before, p.balance_after
+FROM preview p
+JOIN transactions t ON t.id = p.id
+JOIN currencies c ON c.code = p.currency
+ORDER BY p.user_id, p.currency, t.created_at, t.id;
 `
 
 // CancelOddTransactionsAndUpdateBalance cancels odd transactions and updates user balance.
 func (r *TransactionRepositoryImpl) CancelOddTransactionsAndUpdateBalance(ctx context.Context) ([]repositories.CancelOddTransactionsAndUpdateBalanceRow, error) {
+	return r.CancelTransactionsAndUpdateBalance(ctx, NewGlobalOddStrategy(DefaultCancelBatchSize), "")
+}
+
+// CancelTransactionsAndUpdateBalance cancels the transactions selected by the strategy and updates user balance.
+// The cancelled transactions are recorded under the cancellation run runID, unless it is empty.
+func (r *TransactionRepositoryImpl) CancelTransactionsAndUpdateBalance(ctx context.Context, strategy repositories.CancellationStrategy, runID string) ([]repositories.CancelOddTransactionsAndUpdateBalanceRow, error) {
+	query, args := cancelTransactionsQuery(strategy, runID)
+
 	for {
-		ids, err := r.processCancelTransaction(ctx, cancelOddTransactions)
+		ids, err := r.processCancelTransaction(ctx, query, args...)
 
 		if err == nil {
 			return ids, nil
@@ -267,16 +545,23 @@ func (r *TransactionRepositoryImpl) CancelOddTransactionsAndUpdateBalance(ctx co
 	}
 }
 
-// processCancelTransaction processes cancel odd transactions and updates user balance.
-func (r *TransactionRepositoryImpl) processCancelTransaction(ctx context.Context, query string) ([]repositories.CancelOddTransactionsAndUpdateBalanceRow, error) {
-	tx, err := r.db.BeginTx(ctx, pgx.TxOptions{IsoLevel: pgx.Serializable})
-	if err != nil {
-		return nil, err
+// cancelTransactionsQuery builds the cancelTransactions query of the strategy and its arguments. The cancelled
+// transactions are recorded under the cancellation run runID, unless it is empty.
+func cancelTransactionsQuery(strategy repositories.CancellationStrategy, runID string) (string, []interface{}) {
+	var run interface{}
+	if runID != "" {
+		run = runID
 	}
 
-	rows, err := tx.Query(ctx, query)
+	candidates, args := strategy.CandidatesQuery()
+	query := fmt.Sprintf(cancelTransactions, candidates, len(args)+1, len(args)+2, len(args)+3)
+	return query, append(args, cancellableStatuses(), string(models.StatusCancelled), run)
+}
+
+// queryCancelTransactions runs a cancelTransactions query in the transaction and returns the cancelled transactions.
+func queryCancelTransactions(ctx context.Context, tx pgx.Tx, query string, args ...interface{}) ([]repositories.CancelOddTransactionsAndUpdateBalanceRow, error) {
+	rows, err := tx.Query(ctx, query, args...)
 	if err != nil {
-		tx.Rollback(ctx)
 		return nil, err
 	}
 	defer rows.Close()
@@ -284,15 +569,24 @@ func (r *TransactionRepositoryImpl) processCancelTransaction(ctx context.Context
 	ids := make([]repositories.CancelOddTransactionsAndUpdateBalanceRow, 0)
 	for rows.Next() {
 		var row repositories.CancelOddTransactionsAndUpdateBalanceRow
-		err = rows.Scan(&row.UserId, &row.UserBalance, &row.TransactionId, &row.State, &row.Amount)
+		err = rows.Scan(&row.UserId, &row.UserBalance, &row.TransactionId, &row.State, &row.Amount, &row.Currency.Code, &row.Currency.Scale, &row.BalanceBefore, &row.BalanceAfter)
 		if err != nil {
-			tx.Rollback(ctx)
 			return nil, err
 		}
 		ids = append(ids, row)
 	}
 
-	err = rows.Err()
+	return ids, rows.Err()
+}
+
+// processCancelTransaction processes cancel odd transactions and updates user balance.
+func (r *TransactionRepositoryImpl) processCancelTransaction(ctx context.Context, query string, args ...interface{}) ([]repositories.CancelOddTransactionsAndUpdateBalanceRow, error) {
+	tx, err := r.db.BeginTx(ctx, pgx.TxOptions{IsoLevel: pgx.Serializable})
+	if err != nil {
+		return nil, err
+	}
+
+	ids, err := queryCancelTransactions(ctx, tx, query, args...)
 	if err != nil {
 		tx.Rollback(ctx)
 		return nil, err
@@ -307,15 +601,47 @@ func (r *TransactionRepositoryImpl) processCancelTransaction(ctx context.Context
 	return ids, nil
 }
 
-// GetUserBalance returns users balance.
-func (r *TransactionRepositoryImpl) GetUserBalance(ctx context.Context, userId string) (*decimal.Decimal, error) {
+// PreviewCancelTransactions returns what CancelTransactionsAndUpdateBalance would do with the strategy
+// without changing any data.
+func (r *TransactionRepositoryImpl) PreviewCancelTransactions(ctx context.Context, strategy repositories.CancellationStrategy) ([]repositories.CancellationPreviewRow, error) {
+	candidates, args := strategy.CandidatesQuery()
+	query := fmt.Sprintf(previewCancelTransactions, candidates)
+
+	// a read-only transaction reads the candidates and balances from the same snapshot
+	tx, err := r.db.BeginTx(ctx, pgx.TxOptions{IsoLevel: pgx.RepeatableRead, AccessMode: pgx.ReadOnly})
+	if err != nil {
+		return nil, fmt.Errorf("begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	rows, err := tx.Query(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	preview := make([]repositories.CancellationPreviewRow, 0)
+	for rows.Next() {
+		var row repositories.CancellationPreviewRow
+		err = rows.Scan(&row.UserId, &row.UserBalance, &row.TransactionId, &row.State, &row.Amount, &row.Currency.Code, &row.Currency.Scale, &row.Cancellable, &row.BalanceBefore, &row.BalanceAfter)
+		if err != nil {
+			return nil, err
+		}
+		preview = append(preview, row)
+	}
+
+	return preview, rows.Err()
+}
+
+// GetUserBalance returns the balance of the user in the currency.
+func (r *TransactionRepositoryImpl) GetUserBalance(ctx context.Context, userId string, currency string) (*decimal.Decimal, error) {
 	tx, err := r.db.BeginTx(ctx, pgx.TxOptions{IsoLevel: pgx.RepeatableRead})
 	if err != nil {
 		return nil, fmt.Errorf("begin transaction: %w", err)
 	}
 
 	var balance decimal.Decimal
-	err = tx.QueryRow(ctx, "SELECT balance FROM users WHERE id = $1", userId).Scan(&balance)
+	err = tx.QueryRow(ctx, "SELECT balance FROM wallets WHERE user_id = $1 AND currency = $2", userId, currency).Scan(&balance)
 	if err != nil {
 		tx.Rollback(ctx)
 		return nil, fmt.Errorf("get user balance: %w", err)
@@ -332,11 +658,21 @@ func (r *TransactionRepositoryImpl) GetUserBalance(ctx context.Context, userId s
 // GetByTransactionID returns transaction by transaction id.
 func (r *TransactionRepositoryImpl) GetByTransactionID(ctx context.Context, transactionID string) (*models.Transaction, error) {
 	tx := &models.Transaction{}
+	var original originalAmount
 	err := r.db.QueryRow(
 		ctx,
-		"SELECT id, state, amount, source_id, user_id, processed FROM transactions WHERE transaction_id = $1",
+		`SELECT t.id, t.transaction_id, t.state, t.amount, c.code, c.scale, t.source_id, s.name, t.user_id, t.status, t.balance_after, t.created_at, t.updated_at,
+			(SELECT MIN(le.created_at) FROM ledger_entries le WHERE le.transaction_id = t.id AND le.entry_type = 'cancellation'),
+			t.original_amount, oc.code, oc.scale, t.fx_rate, t.metadata, COALESCE(rd.round_id, '')
+		FROM transactions t
+		JOIN sources s ON s.id = t.source_id
+		JOIN currencies c ON c.code = t.currency
+		LEFT JOIN currencies oc ON oc.code = t.original_currency
+		LEFT JOIN rounds rd ON rd.id = t.round_id
+		WHERE t.transaction_id = $1`,
 		transactionID,
-	).Scan(&tx.ID, &tx.State, &tx.Amount, &tx.SourceType.ID, &tx.User.ID, &tx.Processed)
+	).Scan(&tx.ID, &tx.TransactionID, &tx.State, &tx.Amount.Decimal, &tx.Amount.Currency.Code, &tx.Amount.Currency.Scale, &tx.SourceType.ID, &tx.SourceType.Name, &tx.User.ID, &tx.Status, &tx.BalanceAfter, &tx.CreatedAt, &tx.UpdatedAt, &tx.CancelledAt,
+		&original.amount, &original.code, &original.scale, &tx.FxRate, &tx.Metadata, &tx.RoundID)
 
 	if err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
@@ -344,10 +680,120 @@ func (r *TransactionRepositoryImpl) GetByTransactionID(ctx context.Context, tran
 		}
 		return nil, err
 	}
+	tx.OriginalAmount = original.money()
 
 	return tx, nil
 }
 
+const listTransactions = `
+SELECT t.id, t.transaction_id, t.state, t.amount, c.code, c.scale, t.source_id, s.name, t.status, t.user_id, t.created_at, t.updated_at,
+  (SELECT MIN(le.created_at) FROM ledger_entries le WHERE le.transaction_id = t.id AND le.entry_type = 'cancellation'),
+  t.original_amount, oc.code, oc.scale, t.fx_rate, t.metadata, COALESCE(rd.round_id, '')
+FROM transactions t
+JOIN sources s ON s.id = t.source_id
+JOIN currencies c ON c.code = t.currency
+LEFT JOIN currencies oc ON oc.code = t.original_currency
+LEFT JOIN rounds rd ON rd.id = t.round_id
+WHERE %s
+ORDER BY t.created_at DESC, t.id DESC
+LIMIT %d`
+
+// List returns user transactions matching the filter, newest first.
+func (r *TransactionRepositoryImpl) List(ctx context.Context, filter repositories.TransactionFilter) ([]models.Transaction, error) {
+	conditions := []string{"t.user_id = $1"}
+	args := []interface{}{filter.UserID}
+	addCondition := func(condition string, arg ...interface{}) {
+		placeholders := make([]interface{}, len(arg))
+		for i := range arg {
+			placeholders[i] = len(args) + i + 1
+		}
+		conditions = append(conditions, fmt.Sprintf(condition, placeholders...))
+		args = append(args, arg...)
+	}
+
+	if filter.State != "" {
+		addCondition("t.state = $%d", filter.State)
+	}
+	if filter.Currency != "" {
+		addCondition("t.currency = $%d", filter.Currency)
+	}
+	if filter.Source != "" {
+		addCondition("s.name = $%d", strings.ToLower(filter.Source))
+	}
+	if filter.Status != "" {
+		addCondition("t.status = $%d", filter.Status)
+	}
+	if filter.RoundID != "" {
+		addCondition("rd.round_id = $%d", filter.RoundID)
+	}
+	// the history is narrowed down by the user index first, so the metadata is matched without an index of its own
+	keys := make([]string, 0, len(filter.Metadata))
+	for key := range filter.Metadata {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		addCondition("t.metadata ->> $%d = $%d", key, filter.Metadata[key])
+	}
+	if filter.From != nil {
+		addCondition("t.created_at >= $%d", *filter.From)
+	}
+	if filter.To != nil {
+		addCondition("t.created_at < $%d", *filter.To)
+	}
+	if filter.After != nil {
+		addCondition("(t.created_at, t.id) < ($%d, $%d)", filter.After.CreatedAt, filter.After.ID)
+	}
+
+	query := fmt.Sprintf(listTransactions, strings.Join(conditions, " AND "), filter.Limit)
+	rows, err := r.db.Query(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	transactions := make([]models.Transaction, 0, filter.Limit)
+	for rows.Next() {
+		var t models.Transaction
+		var original originalAmount
+		err = rows.Scan(&t.ID, &t.TransactionID, &t.State, &t.Amount.Decimal, &t.Amount.Currency.Code, &t.Amount.Currency.Scale, &t.SourceType.ID, &t.SourceType.Name, &t.Status, &t.User.ID, &t.CreatedAt, &t.UpdatedAt, &t.CancelledAt,
+			&original.amount, &original.code, &original.scale, &t.FxRate, &t.Metadata, &t.RoundID)
+		if err != nil {
+			return nil, err
+		}
+		t.OriginalAmount = original.money()
+		transactions = append(transactions, t)
+	}
+
+	return transactions, rows.Err()
+}
+
+// originalAmount holds the nullable columns of the amount a converted transaction was sent with.
+type originalAmount struct {
+	amount *decimal.Decimal
+	code   *string
+	scale  *int32
+}
+
+// money returns the amount as sent, or nil when the transaction was not converted.
+func (o originalAmount) money() *models.Money {
+	if o.amount == nil || o.code == nil || o.scale == nil {
+		return nil
+	}
+	money := models.NewMoney(*o.amount, models.Currency{Code: *o.code, Scale: *o.scale})
+	return &money
+}
+
+// cancellableStatuses returns the statuses the domain allows to move to cancelled.
+func cancellableStatuses() []string {
+	statuses := models.StatusesTransitionableTo(models.StatusCancelled)
+	names := make([]string, len(statuses))
+	for i, status := range statuses {
+		names[i] = string(status)
+	}
+	return names
+}
+
 func isSerializationError(err error) bool {
 	var pgErr *pgconn.PgError
 	return errors.As(err, &pgErr) && pgErr.SQLState() == repositories.SerializationError
@@ -10,6 +10,7 @@ import (
 	"github.com/jackc/pgx/v5/pgxpool"
 	"github.com/mufasadev/enlabs-test/internal/config"
 	"github.com/mufasadev/enlabs-test/internal/domain/models"
+	"github.com/mufasadev/enlabs-test/internal/domain/repositories"
 	apperr "github.com/mufasadev/enlabs-test/internal/errors"
 	"github.com/shopspring/decimal"
 	"github.com/stretchr/testify/assert"
@@ -27,6 +28,10 @@ var (
 	db           *pgxpool.Pool
 )
 
+func newMoney(amount decimal.Decimal) models.Money {
+	return models.NewMoney(amount, models.DefaultCurrency())
+}
+
 func randDecimal(add int) decimal.Decimal {
 	rand.Seed(time.Now().UnixNano())
 	min := 0    // min amount
@@ -55,7 +60,7 @@ func TestInsertTransactionAndUpdateUserBalanceWithoutCreatingTransaction(t *test
 		transaction := &models.Transaction{
 			TransactionID: uuid.New().String(),
 			State:         "win",
-			Amount:        randDecimal(0),
+			Amount:        newMoney(randDecimal(0)),
 			SourceType:    models.SourceType{ID: sourceTypeId},
 			User:          models.User{ID: userId},
 		}
@@ -69,7 +74,7 @@ func TestInsertTransactionAndUpdateUserBalanceWithoutCreatingTransaction(t *test
 		transaction := &models.Transaction{
 			TransactionID: uuid.New().String(),
 			State:         "lost",
-			Amount:        decimal.NewFromInt(1000000),
+			Amount:        newMoney(decimal.NewFromInt(1000000)),
 			SourceType:    models.SourceType{ID: sourceTypeId},
 			User:          models.User{ID: userId},
 		}
@@ -94,7 +99,7 @@ func TestInsertTransactionAndUpdateUserBalanceWithoutCreatingTransaction(t *test
 				transaction := &models.Transaction{
 					TransactionID: uuid.New().String(),
 					State:         "win",
-					Amount:        randDecimal(0),
+					Amount:        newMoney(randDecimal(0)),
 					SourceType:    models.SourceType{ID: sourceTypeId},
 					User:          models.User{ID: userId},
 				}
@@ -110,7 +115,7 @@ func TestInsertTransactionAndUpdateUserBalanceWithoutCreatingTransaction(t *test
 				transaction := &models.Transaction{
 					TransactionID: uuid.New().String(),
 					State:         "lost",
-					Amount:        randDecimal(100),
+					Amount:        newMoney(randDecimal(100)),
 					SourceType:    models.SourceType{ID: sourceTypeId},
 					User:          models.User{ID: userId},
 				}
@@ -134,7 +139,7 @@ func TestInsertTransactionAndUpdateUserBalanceWithoutCreatingTransaction(t *test
 
 		// check final balance is non-negative
 		var balance float64
-		err = db.QueryRow(context.Background(), fmt.Sprintf("SELECT balance FROM users WHERE id = '%s'", userId)).Scan(&balance)
+		err = db.QueryRow(context.Background(), fmt.Sprintf("SELECT balance FROM wallets WHERE user_id = '%s' AND currency = 'EUR'", userId)).Scan(&balance)
 		require.NoError(t, err)
 		assert.True(t, balance >= 0, "The final balance must be non-negative")
 	})
@@ -171,7 +176,7 @@ func TestInsertTransactionAndUpdateUserBalanceWithoutCreatingTransaction(t *test
 				transaction := &models.Transaction{
 					TransactionID: uuid.New().String(),
 					State:         state,
-					Amount:        decimal.NewFromFloat(math.Abs(amounts[i])),
+					Amount:        newMoney(decimal.NewFromFloat(math.Abs(amounts[i]))),
 					SourceType:    models.SourceType{ID: sourceTypeId},
 					User:          models.User{ID: userId},
 				}
@@ -187,7 +192,7 @@ func TestInsertTransactionAndUpdateUserBalanceWithoutCreatingTransaction(t *test
 
 		// check final balance
 		var finalBalance float64
-		err = db.QueryRow(context.Background(), "SELECT balance FROM users WHERE id = $1", userId).Scan(&finalBalance)
+		err = db.QueryRow(context.Background(), "SELECT balance FROM wallets WHERE user_id = $1 AND currency = 'EUR'", userId).Scan(&finalBalance)
 		require.NoError(t, err)
 
 		for _, amount := range amounts {
@@ -224,7 +229,7 @@ func TestInsertTransactionAndUpdateUserBalanceWithoutCreatingTransaction(t *test
 			defer wgRead.Done()
 
 			var balance float64
-			err = db.QueryRow(context.Background(), "SELECT balance FROM users WHERE id = $1", userId).Scan(&balance)
+			err = db.QueryRow(context.Background(), "SELECT balance FROM wallets WHERE user_id = $1 AND currency = 'EUR'", userId).Scan(&balance)
 			if err != nil {
 				t.Error(err)
 			}
@@ -243,7 +248,7 @@ func TestInsertTransactionAndUpdateUserBalanceWithoutCreatingTransaction(t *test
 				transaction := &models.Transaction{
 					TransactionID: uuid.New().String(),
 					State:         state,
-					Amount:        decimal.NewFromFloat(math.Abs(amounts[i])),
+					Amount:        newMoney(decimal.NewFromFloat(math.Abs(amounts[i]))),
 					SourceType:    models.SourceType{ID: sourceTypeId},
 					User:          models.User{ID: userId},
 				}
@@ -260,7 +265,7 @@ func TestInsertTransactionAndUpdateUserBalanceWithoutCreatingTransaction(t *test
 
 		// check final balance
 		var finalBalance float64
-		err = db.QueryRow(context.Background(), "SELECT balance FROM users WHERE id = $1", userId).Scan(&finalBalance)
+		err = db.QueryRow(context.Background(), "SELECT balance FROM wallets WHERE user_id = $1 AND currency = 'EUR'", userId).Scan(&finalBalance)
 		require.NoError(t, err)
 
 		expectedBalance := initialBalance
@@ -287,7 +292,7 @@ func TestInsertTransactionAndUpdateUserBalanceWithCreatingTransaction(t *testing
 		transaction := &models.Transaction{
 			TransactionID: uuid.New().String(),
 			State:         "win",
-			Amount:        randDecimal(0),
+			Amount:        newMoney(randDecimal(0)),
 			SourceType:    models.SourceType{ID: sourceTypeId},
 			User:          models.User{ID: userId},
 		}
@@ -301,7 +306,7 @@ func TestInsertTransactionAndUpdateUserBalanceWithCreatingTransaction(t *testing
 		transaction := &models.Transaction{
 			TransactionID: uuid.New().String(),
 			State:         "lost",
-			Amount:        decimal.NewFromInt(1000000),
+			Amount:        newMoney(decimal.NewFromInt(1000000)),
 			SourceType:    models.SourceType{ID: sourceTypeId},
 			User:          models.User{ID: userId},
 		}
@@ -326,7 +331,7 @@ func TestInsertTransactionAndUpdateUserBalanceWithCreatingTransaction(t *testing
 				transaction := &models.Transaction{
 					TransactionID: uuid.New().String(),
 					State:         "win",
-					Amount:        randDecimal(0),
+					Amount:        newMoney(randDecimal(0)),
 					SourceType:    models.SourceType{ID: sourceTypeId},
 					User:          models.User{ID: userId},
 				}
@@ -342,7 +347,7 @@ func TestInsertTransactionAndUpdateUserBalanceWithCreatingTransaction(t *testing
 				transaction := &models.Transaction{
 					TransactionID: uuid.New().String(),
 					State:         "lost",
-					Amount:        randDecimal(100),
+					Amount:        newMoney(randDecimal(100)),
 					SourceType:    models.SourceType{ID: sourceTypeId},
 					User:          models.User{ID: userId},
 				}
@@ -366,7 +371,7 @@ func TestInsertTransactionAndUpdateUserBalanceWithCreatingTransaction(t *testing
 
 		// check final balance is non-negative
 		var balance float64
-		err = db.QueryRow(context.Background(), fmt.Sprintf("SELECT balance FROM users WHERE id = '%s'", userId)).Scan(&balance)
+		err = db.QueryRow(context.Background(), fmt.Sprintf("SELECT balance FROM wallets WHERE user_id = '%s' AND currency = 'EUR'", userId)).Scan(&balance)
 		require.NoError(t, err)
 		assert.True(t, balance >= 0, "The final balance must be non-negative")
 	})
@@ -403,7 +408,7 @@ func TestInsertTransactionAndUpdateUserBalanceWithCreatingTransaction(t *testing
 				transaction := &models.Transaction{
 					TransactionID: uuid.New().String(),
 					State:         state,
-					Amount:        decimal.NewFromFloat(math.Abs(amounts[i])),
+					Amount:        newMoney(decimal.NewFromFloat(math.Abs(amounts[i]))),
 					SourceType:    models.SourceType{ID: sourceTypeId},
 					User:          models.User{ID: userId},
 				}
@@ -419,7 +424,7 @@ func TestInsertTransactionAndUpdateUserBalanceWithCreatingTransaction(t *testing
 
 		// check final balance
 		var finalBalance float64
-		err = db.QueryRow(context.Background(), "SELECT balance FROM users WHERE id = $1", userId).Scan(&finalBalance)
+		err = db.QueryRow(context.Background(), "SELECT balance FROM wallets WHERE user_id = $1 AND currency = 'EUR'", userId).Scan(&finalBalance)
 		require.NoError(t, err)
 
 		for _, amount := range amounts {
@@ -456,7 +461,7 @@ func TestInsertTransactionAndUpdateUserBalanceWithCreatingTransaction(t *testing
 			defer wgRead.Done()
 
 			var balance float64
-			err = db.QueryRow(context.Background(), "SELECT balance FROM users WHERE id = $1", userId).Scan(&balance)
+			err = db.QueryRow(context.Background(), "SELECT balance FROM wallets WHERE user_id = $1 AND currency = 'EUR'", userId).Scan(&balance)
 			if err != nil {
 				t.Error(err)
 			}
@@ -475,7 +480,7 @@ func TestInsertTransactionAndUpdateUserBalanceWithCreatingTransaction(t *testing
 				transaction := &models.Transaction{
 					TransactionID: uuid.New().String(),
 					State:         state,
-					Amount:        decimal.NewFromFloat(math.Abs(amounts[i])),
+					Amount:        newMoney(decimal.NewFromFloat(math.Abs(amounts[i]))),
 					SourceType:    models.SourceType{ID: sourceTypeId},
 					User:          models.User{ID: userId},
 				}
@@ -492,7 +497,7 @@ func TestInsertTransactionAndUpdateUserBalanceWithCreatingTransaction(t *testing
 
 		// check final balance
 		var finalBalance float64
-		err = db.QueryRow(context.Background(), "SELECT balance FROM users WHERE id = $1", userId).Scan(&finalBalance)
+		err = db.QueryRow(context.Background(), "SELECT balance FROM wallets WHERE user_id = $1 AND currency = 'EUR'", userId).Scan(&finalBalance)
 		require.NoError(t, err)
 
 		expectedBalance := initialBalance
@@ -506,6 +511,155 @@ func TestInsertTransactionAndUpdateUserBalanceWithCreatingTransaction(t *testing
 	})
 }
 
+func TestInsertTransactionsAndUpdateUserBalance(t *testing.T) {
+	setupDB()
+	defer db.Close()
+
+	err := truncateTransactionsTable(db)
+	require.NoError(t, err)
+
+	transactionRepo := NewTransactionRepositoryImpl(db)
+
+	user, err := createTestUser(db)
+	require.NoError(t, err)
+	defer func() {
+		_ = truncateTransactionsTable(db)
+		_ = deleteTestUser(db, user)
+	}()
+
+	newBatch := func(states []string, amounts []int64) []*models.Transaction {
+		batch := make([]*models.Transaction, 0, len(states))
+		for idx, state := range states {
+			batch = append(batch, &models.Transaction{
+				TransactionID: uuid.New().String(),
+				State:         state,
+				Amount:        newMoney(decimal.NewFromInt(amounts[idx])),
+				SourceType:    models.SourceType{ID: sourceTypeId},
+				User:          models.User{ID: user},
+			})
+		}
+		return batch
+	}
+
+	t.Run("applied in order", func(t *testing.T) {
+		// the loss is only covered by the win before it
+		batch := newBatch([]string{"win", "lost", "win"}, []int64{50, 30, 5})
+
+		rows, err := transactionRepo.InsertTransactionsAndUpdateUserBalance(context.Background(), batch)
+		require.NoError(t, err)
+		require.Len(t, rows, 3)
+
+		expected := []int64{50, 20, 25}
+		for idx, row := range rows {
+			assert.Equal(t, models.StatusApplied, row.Status)
+			assert.Equal(t, batch[idx].TransactionID, row.TransactionId)
+			assert.True(t, decimal.NewFromInt(expected[idx]).Equal(row.UserBalance.Decimal))
+		}
+	})
+
+	t.Run("rolled back as a whole", func(t *testing.T) {
+		batch := newBatch([]string{"win", "lost", "win"}, []int64{10, 1000, 10})
+
+		rows, err := transactionRepo.InsertTransactionsAndUpdateUserBalance(context.Background(), batch)
+		assert.True(t, errors.Is(err, apperr.NewInsufficientFundsError()))
+		assert.Len(t, rows, 1, "The rows must stop before the failing transaction")
+
+		for _, transaction := range batch {
+			stored, err := transactionRepo.GetByTransactionID(context.Background(), transaction.TransactionID)
+			require.NoError(t, err)
+			assert.Nil(t, stored, "No transaction of a failed batch may be stored")
+		}
+
+		balance, err := transactionRepo.GetUserBalance(context.Background(), user, "EUR")
+		require.NoError(t, err)
+		assert.True(t, decimal.NewFromInt(25).Equal(*balance))
+	})
+
+	t.Run("duplicate transaction", func(t *testing.T) {
+		batch := newBatch([]string{"win", "win"}, []int64{1, 1})
+		_, err := transactionRepo.InsertTransactionsAndUpdateUserBalance(context.Background(), batch[:1])
+		require.NoError(t, err)
+
+		rows, err := transactionRepo.InsertTransactionsAndUpdateUserBalance(context.Background(), []*models.Transaction{batch[1], batch[0]})
+		assert.True(t, errors.Is(err, apperr.NewTransactionDuplicateError()))
+		assert.Len(t, rows, 1)
+
+		stored, err := transactionRepo.GetByTransactionID(context.Background(), batch[1].TransactionID)
+		require.NoError(t, err)
+		assert.Nil(t, stored)
+	})
+}
+
+func TestTransactionKinds(t *testing.T) {
+	setupDB()
+	defer db.Close()
+
+	err := truncateTransactionsTable(db)
+	require.NoError(t, err)
+
+	transactionRepo := NewTransactionRepositoryImpl(db)
+	ledgerRepo := NewLedgerRepositoryImpl(db)
+
+	user, err := createTestUser(db)
+	require.NoError(t, err)
+	defer func() {
+		_ = truncateTransactionsTable(db)
+		_ = deleteTestUser(db, user)
+	}()
+
+	paymentSourceId := "138075f8-059e-4fd9-a590-c85c8d97a33a"
+	states := []string{models.StateDeposit, models.StateFee, models.StateWithdrawal}
+	amounts := []int64{100, 5, 20}
+	stored := make([]*models.Transaction, 0, len(states))
+	for idx, state := range states {
+		transaction := &models.Transaction{
+			TransactionID: uuid.New().String(),
+			State:         state,
+			Amount:        newMoney(decimal.NewFromInt(amounts[idx])),
+			SourceType:    models.SourceType{ID: paymentSourceId},
+			User:          models.User{ID: user},
+		}
+		row, err := transactionRepo.InsertTransactionAndUpdateUserBalanceWithCreatingTransaction(context.Background(), transaction)
+		require.NoError(t, err)
+		assert.Equal(t, models.StatusApplied, row.Status)
+
+		tx, err := transactionRepo.GetByTransactionID(context.Background(), transaction.TransactionID)
+		require.NoError(t, err)
+		stored = append(stored, tx)
+	}
+
+	balance, err := transactionRepo.GetUserBalance(context.Background(), user, "EUR")
+	require.NoError(t, err)
+	assert.True(t, decimal.NewFromInt(75).Equal(*balance), "A deposit must credit and a fee and a withdrawal must debit the balance")
+
+	for idx, tx := range stored {
+		entries, err := ledgerRepo.GetByTransactionID(context.Background(), tx.ID)
+		require.NoError(t, err)
+		for _, entry := range entries {
+			if entry.AccountType != models.AccountTypeUser {
+				continue
+			}
+			expected := models.DirectionDebit
+			if models.TransactionKinds[states[idx]].Sign > 0 {
+				expected = models.DirectionCredit
+			}
+			assert.Equal(t, expected, entry.Direction)
+		}
+	}
+
+	// the cancel process only reverses game transactions
+	rows, err := transactionRepo.CancelTransactionsAndUpdateBalance(context.Background(), NewGlobalOddStrategy(20), "")
+	require.NoError(t, err)
+	assert.Empty(t, rows)
+
+	balance, err = transactionRepo.GetUserBalance(context.Background(), user, "EUR")
+	require.NoError(t, err)
+	assert.True(t, decimal.NewFromInt(75).Equal(*balance))
+	ledgerBalance, err := ledgerRepo.GetAccountBalance(context.Background(), models.AccountTypeUser, user, "EUR")
+	require.NoError(t, err)
+	assert.True(t, balance.Equal(ledgerBalance), "The balance must be equal to the sum of ledger entries")
+}
+
 func TestCancelOddTransactionsAndUpdateBalance(t *testing.T) {
 	setupDB()
 	defer db.Close()
@@ -537,7 +691,7 @@ func TestCancelOddTransactionsAndUpdateBalance(t *testing.T) {
 				transaction := &models.Transaction{
 					TransactionID: uuid.New().String(),
 					State:         state,
-					Amount:        decimal.NewFromFloat(math.Abs(amounts[i])),
+					Amount:        newMoney(decimal.NewFromFloat(math.Abs(amounts[i]))),
 					SourceType:    models.SourceType{ID: sourceTypeId},
 					User:          models.User{ID: userId},
 				}
@@ -600,7 +754,7 @@ func TestCancelOddTransactionsAndUpdateBalance(t *testing.T) {
 			transaction := &models.Transaction{
 				TransactionID: uuid.New().String(),
 				State:         state,
-				Amount:        randDecimal(0),
+				Amount:        newMoney(randDecimal(0)),
 				SourceType:    models.SourceType{ID: sourceTypeId},
 				User:          models.User{ID: userId},
 			}
@@ -644,7 +798,7 @@ func TestCancelOddTransactionsAndUpdateBalance(t *testing.T) {
 		transaction := &models.Transaction{
 			TransactionID: uuid.New().String(),
 			State:         "win",
-			Amount:        decimal.NewFromFloat(1.0),
+			Amount:        newMoney(decimal.NewFromFloat(1.0)),
 			SourceType:    models.SourceType{ID: sourceTypeId},
 			User:          models.User{ID: userId},
 		}
@@ -657,7 +811,7 @@ func TestCancelOddTransactionsAndUpdateBalance(t *testing.T) {
 		transaction = &models.Transaction{
 			TransactionID: uuid.New().String(),
 			State:         "win",
-			Amount:        decimal.NewFromFloat(100.0),
+			Amount:        newMoney(decimal.NewFromFloat(100.0)),
 			SourceType:    models.SourceType{ID: sourceTypeId},
 			User:          models.User{ID: userId},
 		}
@@ -674,7 +828,7 @@ func TestCancelOddTransactionsAndUpdateBalance(t *testing.T) {
 		assert.True(t, len(r) == 1, "Only one transaction should be canceled")
 
 		var finalBalance decimal.Decimal
-		err = db.QueryRow(context.Background(), "SELECT balance FROM users WHERE id = $1", userId).Scan(&finalBalance)
+		err = db.QueryRow(context.Background(), "SELECT balance FROM wallets WHERE user_id = $1 AND currency = 'EUR'", userId).Scan(&finalBalance)
 		require.NoError(t, err)
 
 		assert.True(t, finalBalance.Equal(decimal.NewFromFloat(1.0)), "Balance should be 1.0")
@@ -691,7 +845,7 @@ func TestCancelOddTransactionsAndUpdateBalance(t *testing.T) {
 		transaction := &models.Transaction{
 			TransactionID: uuid.New().String(),
 			State:         "lost",
-			Amount:        decimal.NewFromFloat(100.0),
+			Amount:        newMoney(decimal.NewFromFloat(100.0)),
 			SourceType:    models.SourceType{ID: sourceTypeId},
 			User:          models.User{ID: userId},
 		}
@@ -703,7 +857,7 @@ func TestCancelOddTransactionsAndUpdateBalance(t *testing.T) {
 		transaction = &models.Transaction{
 			TransactionID: uuid.New().String(),
 			State:         "lost",
-			Amount:        decimal.NewFromFloat(100.0),
+			Amount:        newMoney(decimal.NewFromFloat(100.0)),
 			SourceType:    models.SourceType{ID: sourceTypeId},
 			User:          models.User{ID: userId},
 		}
@@ -715,7 +869,7 @@ func TestCancelOddTransactionsAndUpdateBalance(t *testing.T) {
 		transaction = &models.Transaction{
 			TransactionID: uuid.New().String(),
 			State:         "lost",
-			Amount:        decimal.NewFromFloat(1.0),
+			Amount:        newMoney(decimal.NewFromFloat(1.0)),
 			SourceType:    models.SourceType{ID: sourceTypeId},
 			User:          models.User{ID: userId},
 		}
@@ -728,7 +882,7 @@ func TestCancelOddTransactionsAndUpdateBalance(t *testing.T) {
 		transaction = &models.Transaction{
 			TransactionID: uuid.New().String(),
 			State:         "lost",
-			Amount:        decimal.NewFromFloat(1.0),
+			Amount:        newMoney(decimal.NewFromFloat(1.0)),
 			SourceType:    models.SourceType{ID: sourceTypeId},
 			User:          models.User{ID: userId},
 		}
@@ -748,7 +902,7 @@ func TestCancelOddTransactionsAndUpdateBalance(t *testing.T) {
 		assert.True(t, len(r) == 2, "Two transactions should be canceled")
 
 		var finalBalance decimal.Decimal
-		err = db.QueryRow(context.Background(), "SELECT balance FROM users WHERE id = $1", userId).Scan(&finalBalance)
+		err = db.QueryRow(context.Background(), "SELECT balance FROM wallets WHERE user_id = $1 AND currency = 'EUR'", userId).Scan(&finalBalance)
 		require.NoError(t, err)
 
 		assert.True(t, finalBalance.Equal(decimal.NewFromFloat(199.0)), "Balance should be 199.0")
@@ -766,7 +920,7 @@ func TestCancelOddTransactionsAndUpdateBalance(t *testing.T) {
 			transaction := &models.Transaction{
 				TransactionID: uuid.New().String(),
 				State:         "win",
-				Amount:        decimal.NewFromFloat(50.0),
+				Amount:        newMoney(decimal.NewFromFloat(50.0)),
 				SourceType:    models.SourceType{ID: sourceTypeId},
 				User:          models.User{ID: userId},
 			}
@@ -789,7 +943,7 @@ func TestCancelOddTransactionsAndUpdateBalance(t *testing.T) {
 		assert.True(t, len(r) == 2, "Only 2 of 3 transaction should be canceled")
 
 		var finalBalance decimal.Decimal
-		err = db.QueryRow(context.Background(), "SELECT balance FROM users WHERE id = $1", userId).Scan(&finalBalance)
+		err = db.QueryRow(context.Background(), "SELECT balance FROM wallets WHERE user_id = $1 AND currency = 'EUR'", userId).Scan(&finalBalance)
 		require.NoError(t, err)
 
 		assert.True(t, finalBalance.Equal(decimal.NewFromFloat(0.0)), "Balance should be 0.0")
@@ -827,7 +981,7 @@ func TestCancelOddTransactionsAndUpdateBalance(t *testing.T) {
 					transaction := &models.Transaction{
 						TransactionID: uuid.New().String(),
 						State:         state,
-						Amount:        decimal.NewFromFloat(math.Abs(amount)),
+						Amount:        newMoney(decimal.NewFromFloat(math.Abs(amount))),
 						SourceType:    models.SourceType{ID: sourceTypeId},
 						User:          models.User{ID: userId},
 					}
@@ -873,7 +1027,7 @@ func TestCancelOddTransactionsAndUpdateBalance(t *testing.T) {
 		assert.True(t, successCount > 0, "At least one call should succeed")
 
 		var actualBalance decimal.Decimal
-		err = db.QueryRow(context.Background(), "SELECT balance FROM users WHERE id = $1", userId).Scan(&actualBalance)
+		err = db.QueryRow(context.Background(), "SELECT balance FROM wallets WHERE user_id = $1 AND currency = 'EUR'", userId).Scan(&actualBalance)
 		require.NoError(t, err)
 
 		ib := decimal.NewFromFloat(initialBalance).Round(2)
@@ -883,6 +1037,289 @@ func TestCancelOddTransactionsAndUpdateBalance(t *testing.T) {
 	})
 }
 
+func TestCancelOddTransactionsPerUser(t *testing.T) {
+	setupDB()
+	defer db.Close()
+
+	transactionRepo := NewTransactionRepositoryImpl(db)
+
+	// applyWins applies a win of the given amount for every user in order and returns the transaction ids
+	applyWins := func(t *testing.T, userIds []string, amount float64) []string {
+		ids := make([]string, 0, len(userIds))
+		for _, uid := range userIds {
+			transaction := &models.Transaction{
+				TransactionID: uuid.New().String(),
+				State:         "win",
+				Amount:        newMoney(decimal.NewFromFloat(amount)),
+				SourceType:    models.SourceType{ID: sourceTypeId},
+				User:          models.User{ID: uid},
+			}
+			_, err := transactionRepo.InsertTransactionAndUpdateUserBalanceWithCreatingTransaction(context.Background(), transaction)
+			require.NoError(t, err)
+			stored, err := transactionRepo.GetByTransactionID(context.Background(), transaction.TransactionID)
+			require.NoError(t, err)
+			ids = append(ids, stored.ID)
+		}
+		return ids
+	}
+
+	// cancelledIds returns the ids of the cancelled transactions
+	cancelledIds := func(rows []repositories.CancelOddTransactionsAndUpdateBalanceRow) []string {
+		ids := make([]string, 0, len(rows))
+		for _, row := range rows {
+			ids = append(ids, row.TransactionId)
+		}
+		return ids
+	}
+
+	// prepare creates two users with empty history: a for the default test user and b for a new one
+	prepare := func(t *testing.T) (string, string) {
+		err := truncateTransactionsTable(db)
+		require.NoError(t, err)
+		err = setInitialUserBalance(db, 0)
+		require.NoError(t, err)
+		otherUserId, err := createTestUser(db)
+		require.NoError(t, err)
+		t.Cleanup(func() {
+			_ = truncateTransactionsTable(db)
+			_ = deleteTestUser(db, otherUserId)
+		})
+		return userId, otherUserId
+	}
+
+	t.Run("independent_ranking", func(t *testing.T) {
+		a, b := prepare(t)
+
+		// created order, oldest first: a1, a2, b1, a3, a4
+		a1 := applyWins(t, []string{a}, 10)[0]
+		a2 := applyWins(t, []string{a}, 10)[0]
+		b1 := applyWins(t, []string{b}, 10)[0]
+		a3 := applyWins(t, []string{a}, 10)[0]
+		a4 := applyWins(t, []string{a}, 10)[0]
+		_, _ = a1, a3
+
+		rows, err := transactionRepo.CancelTransactionsAndUpdateBalance(context.Background(), NewPerUserOddStrategy(DefaultCancelBatchSize), "")
+		require.NoError(t, err)
+
+		// a ranks a4, a3, a2, a1 and b ranks b1, regardless of each other
+		assert.ElementsMatch(t, []string{a4, a2, b1}, cancelledIds(rows))
+
+		var balanceA, balanceB decimal.Decimal
+		err = db.QueryRow(context.Background(), "SELECT balance FROM wallets WHERE user_id = $1 AND currency = 'EUR'", a).Scan(&balanceA)
+		require.NoError(t, err)
+		err = db.QueryRow(context.Background(), "SELECT balance FROM wallets WHERE user_id = $1 AND currency = 'EUR'", b).Scan(&balanceB)
+		require.NoError(t, err)
+		assert.True(t, balanceA.Equal(decimal.NewFromFloat(20.0)), "Balance of a should be 20.0")
+		assert.True(t, balanceB.Equal(decimal.NewFromFloat(0.0)), "Balance of b should be 0.0")
+	})
+
+	t.Run("global_ranking_depends_on_other_users", func(t *testing.T) {
+		a, b := prepare(t)
+
+		// same history as above, ranked across users: a4, a3, b1, a2, a1
+		a1 := applyWins(t, []string{a}, 10)[0]
+		applyWins(t, []string{a}, 10)
+		b1 := applyWins(t, []string{b}, 10)[0]
+		applyWins(t, []string{a}, 10)
+		a4 := applyWins(t, []string{a}, 10)[0]
+
+		rows, err := transactionRepo.CancelTransactionsAndUpdateBalance(context.Background(), NewGlobalOddStrategy(DefaultCancelBatchSize), "")
+		require.NoError(t, err)
+
+		assert.ElementsMatch(t, []string{a4, b1, a1}, cancelledIds(rows))
+	})
+
+	t.Run("batch_size_per_user", func(t *testing.T) {
+		a, b := prepare(t)
+
+		for i := 0; i < 6; i++ {
+			applyWins(t, []string{a, b}, 10)
+		}
+
+		rows, err := transactionRepo.CancelTransactionsAndUpdateBalance(context.Background(), NewPerUserOddStrategy(2), "")
+		require.NoError(t, err)
+
+		// every user has its own latest 2 transactions, only the latest one is odd
+		require.Len(t, rows, 2)
+		users := []string{rows[0].UserId, rows[1].UserId}
+		assert.ElementsMatch(t, []string{a, b}, users)
+	})
+
+	t.Run("insufficient_balance_is_per_user", func(t *testing.T) {
+		a, b := prepare(t)
+
+		applyWins(t, []string{a, b}, 10)
+		applyWins(t, []string{a, b}, 10)
+		applyWins(t, []string{a, b}, 10)
+
+		// b spent its winnings, canceling its wins must not affect a
+		_, err := db.Exec(context.Background(), "UPDATE wallets SET balance = 0 WHERE user_id = $1 AND currency = 'EUR'", b)
+		require.NoError(t, err)
+
+		rows, err := transactionRepo.CancelTransactionsAndUpdateBalance(context.Background(), NewPerUserOddStrategy(DefaultCancelBatchSize), "")
+		require.NoError(t, err)
+
+		require.Len(t, rows, 2)
+		for _, row := range rows {
+			assert.Equal(t, a, row.UserId)
+		}
+	})
+
+	t.Run("concurrent_users", func(t *testing.T) {
+		a, b := prepare(t)
+
+		n := 10
+		var wg sync.WaitGroup
+		wg.Add(2)
+		for _, uid := range []string{a, b} {
+			go func(uid string) {
+				defer wg.Done()
+				for i := 0; i < n; i++ {
+					transaction := &models.Transaction{
+						TransactionID: uuid.New().String(),
+						State:         "win",
+						Amount:        newMoney(decimal.NewFromFloat(10)),
+						SourceType:    models.SourceType{ID: sourceTypeId},
+						User:          models.User{ID: uid},
+					}
+					_, err := transactionRepo.InsertTransactionAndUpdateUserBalanceWithCreatingTransaction(context.Background(), transaction)
+					assert.NoError(t, err)
+				}
+			}(uid)
+		}
+		wg.Wait()
+
+		rows, err := transactionRepo.CancelTransactionsAndUpdateBalance(context.Background(), NewPerUserOddStrategy(DefaultCancelBatchSize), "")
+		require.NoError(t, err)
+
+		perUser := make(map[string]int)
+		for _, row := range rows {
+			perUser[row.UserId]++
+		}
+		assert.Equal(t, n/2, perUser[a])
+		assert.Equal(t, n/2, perUser[b])
+	})
+
+	t.Run("ties_keep_the_batch_size", func(t *testing.T) {
+		a, _ := prepare(t)
+
+		for i := 0; i < 6; i++ {
+			applyWins(t, []string{a}, 10)
+		}
+		_, err := db.Exec(context.Background(), "UPDATE transactions SET created_at = NOW() WHERE user_id = $1", a)
+		require.NoError(t, err)
+
+		strategies := []repositories.CancellationStrategy{
+			NewGlobalOddStrategy(2),
+			NewPerUserOddStrategy(2),
+			NewPerSourceOddStrategy(2),
+			NewAgeWindowStrategy(2, time.Hour),
+		}
+		for _, strategy := range strategies {
+			rows, err := transactionRepo.PreviewCancelTransactions(context.Background(), strategy)
+			require.NoError(t, err)
+			assert.Len(t, rows, 1, strategy.Name())
+		}
+	})
+}
+
+func TestListTransactions(t *testing.T) {
+	setupDB()
+	defer db.Close()
+
+	transactionRepo := NewTransactionRepositoryImpl(db)
+
+	err := truncateTransactionsTable(db)
+	require.NoError(t, err)
+	err = setInitialUserBalance(db, 0)
+	require.NoError(t, err)
+
+	ids := make([]string, 0, 5)
+	for i := 0; i < 5; i++ {
+		transaction := &models.Transaction{
+			TransactionID: uuid.New().String(),
+			State:         "win",
+			Amount:        newMoney(randDecimal(0)),
+			SourceType:    models.SourceType{ID: sourceTypeId},
+			User:          models.User{ID: userId},
+		}
+		_, err = transactionRepo.InsertTransactionAndUpdateUserBalanceWithCreatingTransaction(context.Background(), transaction)
+		require.NoError(t, err)
+		ids = append(ids, transaction.TransactionID)
+	}
+
+	t.Run("keyset_pagination", func(t *testing.T) {
+		filter := repositories.TransactionFilter{UserID: userId, Limit: 2}
+		seen := make([]string, 0, len(ids))
+		for {
+			page, err := transactionRepo.List(context.Background(), filter)
+			require.NoError(t, err)
+			for _, tx := range page {
+				seen = append(seen, tx.TransactionID)
+			}
+			if len(page) < filter.Limit {
+				break
+			}
+			last := page[len(page)-1]
+			filter.After = &repositories.Cursor{CreatedAt: last.CreatedAt, ID: last.ID}
+		}
+
+		require.Len(t, seen, len(ids))
+		for i := range ids {
+			assert.Equal(t, ids[len(ids)-1-i], seen[i], "Transactions must be ordered newest first")
+		}
+	})
+
+	t.Run("filters", func(t *testing.T) {
+		page, err := transactionRepo.List(context.Background(), repositories.TransactionFilter{UserID: userId, Status: string(models.StatusCancelled), Limit: 10})
+		require.NoError(t, err)
+		assert.Len(t, page, 0)
+
+		page, err = transactionRepo.List(context.Background(), repositories.TransactionFilter{UserID: userId, State: "lost", Limit: 10})
+		require.NoError(t, err)
+		assert.Len(t, page, 0)
+
+		page, err = transactionRepo.List(context.Background(), repositories.TransactionFilter{UserID: userId, State: "win", Source: "game", Limit: 10})
+		require.NoError(t, err)
+		assert.Len(t, page, len(ids))
+		assert.Equal(t, "game", page[0].SourceType.Name)
+	})
+
+	t.Run("metadata", func(t *testing.T) {
+		transaction := &models.Transaction{
+			TransactionID: uuid.New().String(),
+			State:         "win",
+			Amount:        newMoney(randDecimal(0)),
+			Metadata:      map[string]interface{}{"roundId": "r-42", "tableId": 7, "provider": map[string]interface{}{"ref": "x"}},
+			SourceType:    models.SourceType{ID: sourceTypeId},
+			User:          models.User{ID: userId},
+		}
+		_, err := transactionRepo.InsertTransactionAndUpdateUserBalanceWithCreatingTransaction(context.Background(), transaction)
+		require.NoError(t, err)
+
+		stored, err := transactionRepo.GetByTransactionID(context.Background(), transaction.TransactionID)
+		require.NoError(t, err)
+		assert.Equal(t, "r-42", stored.Metadata["roundId"])
+		assert.Equal(t, float64(7), stored.Metadata["tableId"])
+
+		// numbers are matched by their text
+		page, err := transactionRepo.List(context.Background(), repositories.TransactionFilter{UserID: userId, Metadata: map[string]string{"roundId": "r-42", "tableId": "7"}, Limit: 10})
+		require.NoError(t, err)
+		require.Len(t, page, 1)
+		assert.Equal(t, transaction.TransactionID, page[0].TransactionID)
+
+		page, err = transactionRepo.List(context.Background(), repositories.TransactionFilter{UserID: userId, Metadata: map[string]string{"roundId": "r-43"}, Limit: 10})
+		require.NoError(t, err)
+		assert.Len(t, page, 0)
+
+		// transactions sent without metadata have an empty object
+		page, err = transactionRepo.List(context.Background(), repositories.TransactionFilter{UserID: userId, Limit: 10})
+		require.NoError(t, err)
+		assert.NotNil(t, page[len(page)-1].Metadata)
+		assert.Empty(t, page[len(page)-1].Metadata)
+	})
+}
+
 // Test helpers and setup functions
 // =================================
 // Setup DB
@@ -905,14 +1342,31 @@ func setupDB() {
 	}
 }
 
-// Truncate transactions table
+// Truncate transactions and the tables referencing them
 func truncateTransactionsTable(db *pgxpool.Pool) error {
-	_, err := db.Exec(context.Background(), "TRUNCATE TABLE transactions")
+	_, err := db.Exec(context.Background(), "TRUNCATE TABLE reconciliation_drifts, reconciliation_runs, cancellations, cancellation_runs, ledger_entries, transactions, transfers, rounds")
 	return err
 }
 
 // Set initial user balance
 func setInitialUserBalance(db *pgxpool.Pool, balance float64) error {
-	_, err := db.Exec(context.Background(), "UPDATE users SET balance = $1 WHERE id = $2", balance, userId)
+	_, err := db.Exec(context.Background(), "UPDATE wallets SET balance = $1 WHERE user_id = $2 AND currency = 'EUR'", balance, userId)
+	return err
+}
+
+// Create a user with an empty EUR wallet for tests that need more than one user
+func createTestUser(db *pgxpool.Pool) (string, error) {
+	var id string
+	err := db.QueryRow(
+		context.Background(),
+		`WITH new_user AS (INSERT INTO users (account_number) VALUES (gen_random_uuid()) RETURNING id)
+		INSERT INTO wallets (user_id, currency) SELECT id, 'EUR' FROM new_user RETURNING user_id`,
+	).Scan(&id)
+	return id, err
+}
+
+// Delete a user created by createTestUser
+func deleteTestUser(db *pgxpool.Pool, id string) error {
+	_, err := db.Exec(context.Background(), "DELETE FROM users WHERE id = $1", id)
 	return err
 }
@@ -0,0 +1,179 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/mufasadev/enlabs-test/internal/domain/models"
+	"github.com/mufasadev/enlabs-test/internal/domain/repositories"
+	apperrors "github.com/mufasadev/enlabs-test/internal/errors"
+	"github.com/mufasadev/enlabs-test/pkg/log"
+	"github.com/rs/zerolog"
+)
+
+type TransferRepositoryImpl struct {
+	db     *pgxpool.Pool
+	logger *zerolog.Logger
+}
+
+func NewTransferRepositoryImpl(db *pgxpool.Pool) repositories.TransferRepository {
+	l := log.GetLogger()
+	return &TransferRepositoryImpl{
+		db:     db,
+		logger: &l,
+	}
+}
+
+// createTransfer debits the wallet of the sender and credits the wallet of the recipient in the currency of the
+// transfer, or stores the transfer as rejected when the sender's balance is insufficient. Both sides are stored
+// as transactions and posted to the ledger together.
+const createTransfer = `
+WITH debited AS (
+  UPDATE wallets
+  SET balance = balance - $4::NUMERIC(28,8)
+  WHERE user_id = $2 AND currency = $8 AND balance - $4::NUMERIC(28,8) >= -credit_limit
+  RETURNING user_id, balance
+),
+credited AS (
+  UPDATE wallets
+  SET balance = balance + $4::NUMERIC(28,8)
+  WHERE user_id = $3 AND currency = $8 AND EXISTS (SELECT 1 FROM debited)
+  RETURNING user_id, balance
+),
+new_transfer AS (
+  INSERT INTO transfers (transfer_id, from_user_id, to_user_id, amount, currency, status)
+  VALUES ($1, $2, $3, $4::NUMERIC(28,8), $8,
+          CASE WHEN EXISTS (SELECT 1 FROM credited) THEN 'applied' ELSE 'rejected_insufficient_funds' END)
+  RETURNING id, status, created_at
+),
+sides AS (
+  SELECT $6 AS transaction_id, 'lost' AS state, -1 AS sign, $2::UUID AS user_id,
+         COALESCE((SELECT balance FROM debited), (SELECT balance FROM wallets WHERE user_id = $2 AND currency = $8)) AS balance_after
+  UNION ALL
+  SELECT $7, 'win', 1, $3::UUID,
+         COALESCE((SELECT balance FROM credited), (SELECT balance FROM wallets WHERE user_id = $3 AND currency = $8))
+),
+new_transactions AS (
+  INSERT INTO transactions (transaction_id, state, sign, amount, currency, source_id, user_id, status, balance_after, transfer_id)
+  SELECT s.transaction_id, s.state, s.sign, $4::NUMERIC(28,8), $8, $5, s.user_id, nt.status, s.balance_after, nt.id
+  FROM new_transfer nt
+  CROSS JOIN sides s
+  RETURNING id, state, sign, user_id, amount, currency, status
+),
+ledger AS (
+  INSERT INTO ledger_entries (posting_id, transaction_id, entry_type, account_type, account_id, direction, amount, currency)
+  SELECT nt.id, t.id, 'transaction', 'user', t.user_id, CASE WHEN t.sign > 0 THEN 'credit' ELSE 'debit' END, t.amount, t.currency
+  FROM new_transactions t
+  CROSS JOIN new_transfer nt
+  WHERE t.status = 'applied'
+)
+SELECT nt.id, nt.status, nt.created_at,
+       (SELECT balance_after FROM sides WHERE state = 'lost'),
+       (SELECT balance_after FROM sides WHERE state = 'win')
+FROM new_transfer nt;`
+
+// Create applies a transfer in a single transaction and fills in its id, status, balances and creation time.
+// A transfer with insufficient funds is stored as rejected and an InsufficientFundsError is returned.
+func (r *TransferRepositoryImpl) Create(ctx context.Context, transfer *models.Transfer) error {
+	for _, userID := range []string{transfer.FromUserID, transfer.ToUserID} {
+		if err := ensureWallet(ctx, r.db, userID, transfer.Amount.Currency.Code); err != nil {
+			return fmt.Errorf("transfer error: %w", err)
+		}
+	}
+
+	var pgErr *pgconn.PgError
+	for {
+		tx, err := r.db.BeginTx(ctx, pgx.TxOptions{IsoLevel: pgx.RepeatableRead})
+		if err != nil {
+			return err
+		}
+
+		err = lockTransferWallets(ctx, tx, transfer)
+		if err == nil {
+			err = tx.QueryRow(
+				ctx,
+				createTransfer,
+				transfer.TransferID,
+				transfer.FromUserID,
+				transfer.ToUserID,
+				transfer.Amount.Decimal,
+				models.TransferSourceID,
+				models.TransferTransactionID(transfer.TransferID, models.StateLost),
+				models.TransferTransactionID(transfer.TransferID, models.StateWin),
+				transfer.Amount.Currency.Code,
+			).Scan(&transfer.ID, &transfer.Status, &transfer.CreatedAt, &transfer.FromBalanceAfter, &transfer.ToBalanceAfter)
+		}
+		if err == nil {
+			err = tx.Commit(ctx)
+		}
+		if err == nil {
+			if transfer.Status != models.StatusApplied {
+				return apperrors.NewInsufficientFundsError()
+			}
+			return nil
+		}
+		r.logger.Error().Err(err).Msg("transfer error")
+		tx.Rollback(ctx)
+
+		if isSerializationError(err) && ctx.Err() == nil {
+			// retry transaction if serialization error occurs (SQLSTATE 40001)
+			continue
+		}
+		if errors.As(err, &pgErr) && pgErr.SQLState() == repositories.UniqueViolationError {
+			return apperrors.NewTransactionDuplicateError()
+		}
+		return fmt.Errorf("transfer error: %w", err)
+	}
+}
+
+// lockTransferWallets locks the wallets of both users of a transfer in the order of their user ids, so opposite
+// transfers between the same users wait for each other instead of deadlocking.
+func lockTransferWallets(ctx context.Context, tx pgx.Tx, transfer *models.Transfer) error {
+	_, err := tx.Exec(
+		ctx,
+		`SELECT 1 FROM wallets WHERE user_id IN ($1, $2) AND currency = $3 ORDER BY user_id FOR UPDATE`,
+		transfer.FromUserID,
+		transfer.ToUserID,
+		transfer.Amount.Currency.Code,
+	)
+	return err
+}
+
+// GetByTransferID returns a transfer with the balances after it, or nil when it does not exist.
+func (r *TransferRepositoryImpl) GetByTransferID(ctx context.Context, transferID string) (*models.Transfer, error) {
+	var transfer models.Transfer
+	err := r.db.QueryRow(
+		ctx,
+		`SELECT tr.id, tr.transfer_id, tr.from_user_id, tr.to_user_id, tr.amount, c.code, c.scale, tr.status, tr.created_at,
+			debit.balance_after, credit.balance_after
+		FROM transfers tr
+		JOIN currencies c ON c.code = tr.currency
+		JOIN transactions debit ON debit.transfer_id = tr.id AND debit.state = 'lost'
+		JOIN transactions credit ON credit.transfer_id = tr.id AND credit.state = 'win'
+		WHERE tr.transfer_id = $1`,
+		transferID,
+	).Scan(
+		&transfer.ID,
+		&transfer.TransferID,
+		&transfer.FromUserID,
+		&transfer.ToUserID,
+		&transfer.Amount.Decimal,
+		&transfer.Amount.Currency.Code,
+		&transfer.Amount.Currency.Scale,
+		&transfer.Status,
+		&transfer.CreatedAt,
+		&transfer.FromBalanceAfter,
+		&transfer.ToBalanceAfter,
+	)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return &transfer, nil
+}
@@ -0,0 +1,162 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"github.com/google/uuid"
+	"github.com/mufasadev/enlabs-test/internal/domain/models"
+	apperr "github.com/mufasadev/enlabs-test/internal/errors"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"sync"
+	"testing"
+)
+
+func TestTransferRepository(t *testing.T) {
+	setupDB()
+	defer db.Close()
+
+	transactionRepo := NewTransactionRepositoryImpl(db)
+	transferRepo := NewTransferRepositoryImpl(db)
+	userRepo := NewUserRepositoryImpl(db)
+	ledgerRepo := NewLedgerRepositoryImpl(db)
+
+	err := truncateTransactionsTable(db)
+	require.NoError(t, err)
+
+	sender, err := createTestUser(db)
+	require.NoError(t, err)
+	recipient, err := createTestUser(db)
+	require.NoError(t, err)
+	defer func() {
+		_ = truncateTransactionsTable(db)
+		_ = deleteTestUser(db, sender)
+		_ = deleteTestUser(db, recipient)
+	}()
+
+	err = userRepo.Update(context.Background(), &models.User{ID: sender, Balance: decimal.NewFromInt(100)})
+	require.NoError(t, err)
+
+	t.Run("applied", func(t *testing.T) {
+		transfer := &models.Transfer{
+			TransferID: uuid.New().String(),
+			FromUserID: sender,
+			ToUserID:   recipient,
+			Amount:     newMoney(decimal.NewFromInt(40)),
+		}
+		err := transferRepo.Create(context.Background(), transfer)
+		require.NoError(t, err)
+		assert.Equal(t, models.StatusApplied, transfer.Status)
+		assert.True(t, decimal.NewFromInt(60).Equal(transfer.FromBalanceAfter))
+		assert.True(t, decimal.NewFromInt(40).Equal(transfer.ToBalanceAfter))
+
+		stored, err := transferRepo.GetByTransferID(context.Background(), transfer.TransferID)
+		require.NoError(t, err)
+		require.NotNil(t, stored)
+		assert.Equal(t, transfer.ID, stored.ID)
+		assert.True(t, transfer.FromBalanceAfter.Equal(stored.FromBalanceAfter))
+		assert.True(t, transfer.ToBalanceAfter.Equal(stored.ToBalanceAfter))
+
+		// both sides are linked transactions posted to the ledger
+		debit, err := transactionRepo.GetByTransactionID(context.Background(), models.TransferTransactionID(transfer.TransferID, models.StateLost))
+		require.NoError(t, err)
+		require.NotNil(t, debit)
+		assert.Equal(t, sender, debit.User.ID)
+		assert.Equal(t, models.StatusApplied, debit.Status)
+
+		credit, err := transactionRepo.GetByTransactionID(context.Background(), models.TransferTransactionID(transfer.TransferID, models.StateWin))
+		require.NoError(t, err)
+		require.NotNil(t, credit)
+		assert.Equal(t, recipient, credit.User.ID)
+
+		unbalanced, err := ledgerRepo.CountUnbalancedPostings(context.Background())
+		require.NoError(t, err)
+		assert.Equal(t, 0, unbalanced)
+	})
+
+	t.Run("insufficient funds", func(t *testing.T) {
+		transfer := &models.Transfer{
+			TransferID: uuid.New().String(),
+			FromUserID: recipient,
+			ToUserID:   sender,
+			Amount:     newMoney(decimal.NewFromInt(1000)),
+		}
+		err := transferRepo.Create(context.Background(), transfer)
+		assert.True(t, errors.Is(err, apperr.NewInsufficientFundsError()))
+		assert.Equal(t, models.StatusRejectedInsufficientFunds, transfer.Status)
+
+		stored, err := transferRepo.GetByTransferID(context.Background(), transfer.TransferID)
+		require.NoError(t, err)
+		require.NotNil(t, stored)
+		assert.Equal(t, models.StatusRejectedInsufficientFunds, stored.Status)
+		assert.True(t, decimal.NewFromInt(40).Equal(stored.FromBalanceAfter), "A rejected transfer must not change the balances")
+	})
+
+	t.Run("duplicate transfer id", func(t *testing.T) {
+		transfer := &models.Transfer{
+			TransferID: uuid.New().String(),
+			FromUserID: sender,
+			ToUserID:   recipient,
+			Amount:     newMoney(decimal.NewFromInt(1)),
+		}
+		err := transferRepo.Create(context.Background(), transfer)
+		require.NoError(t, err)
+
+		again := *transfer
+		err = transferRepo.Create(context.Background(), &again)
+		assert.True(t, errors.Is(err, apperr.NewTransactionDuplicateError()))
+	})
+
+	t.Run("never cancelled", func(t *testing.T) {
+		rows, err := transactionRepo.CancelTransactionsAndUpdateBalance(context.Background(), NewPerUserOddStrategy(20), "")
+		require.NoError(t, err)
+		assert.Empty(t, rows, "Only transfers were applied, nothing may be cancelled")
+	})
+
+	t.Run("concurrent opposite transfers", func(t *testing.T) {
+		before := totalBalance(t, sender, recipient)
+
+		n := 50
+		var wg sync.WaitGroup
+		wg.Add(n)
+		for i := 0; i < n; i++ {
+			go func(i int) {
+				defer wg.Done()
+				from, to := sender, recipient
+				if i%2 == 0 {
+					from, to = recipient, sender
+				}
+				err := transferRepo.Create(context.Background(), &models.Transfer{
+					TransferID: uuid.New().String(),
+					FromUserID: from,
+					ToUserID:   to,
+					Amount:     newMoney(randDecimal(0)),
+				})
+				// the wallets are locked in the same order, so a transfer is applied or rejected but never deadlocks
+				assert.True(t, err == nil || apperr.As(err, new(*apperr.InsufficientFundsError)), "unexpected error: %v", err)
+			}(i)
+		}
+		wg.Wait()
+
+		assert.True(t, before.Equal(totalBalance(t, sender, recipient)), "Transfers must not create or destroy funds")
+
+		for _, user := range []string{sender, recipient} {
+			stored, err := userRepo.GetByID(context.Background(), user)
+			require.NoError(t, err)
+			assert.False(t, stored.Balance.IsNegative())
+
+			ledgerBalance, err := ledgerRepo.GetAccountBalance(context.Background(), models.AccountTypeUser, user, "EUR")
+			require.NoError(t, err)
+			assert.True(t, stored.Balance.Equal(ledgerBalance), "The balance must be equal to the sum of ledger entries")
+		}
+	})
+}
+
+// totalBalance returns the sum of the balances of the users.
+func totalBalance(t *testing.T, users ...string) decimal.Decimal {
+	var total decimal.Decimal
+	err := db.QueryRow(context.Background(), "SELECT COALESCE(SUM(balance), 0) FROM wallets WHERE user_id = ANY($1) AND currency = 'EUR'", users).Scan(&total)
+	require.NoError(t, err)
+	return total
+}
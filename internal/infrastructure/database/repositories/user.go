@@ -2,6 +2,7 @@ package repositories
 
 import (
 	"context"
+	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
 	"github.com/mufasadev/enlabs-test/internal/domain/models"
 	"github.com/mufasadev/enlabs-test/internal/domain/repositories"
@@ -18,17 +19,43 @@ func NewUserRepositoryImpl(db *pgxpool.Pool) repositories.UserRepository {
 	}
 }
 
+// selectUsers reads users with the balance and credit limit of their wallet in the default currency $1.
+const selectUsers = `SELECT u.id, COALESCE(w.balance, 0), COALESCE(w.credit_limit, 0), u.account_number, u.created_at
+FROM users u
+LEFT JOIN wallets w ON w.user_id = u.id AND w.currency = $1`
+
 func (r *UserRepositoryImpl) GetByID(ctx context.Context, id string) (*models.User, error) {
 	user := &models.User{}
 	err := r.db.QueryRow(
 		ctx,
-		"SELECT id, balance FROM users WHERE id = $1",
+		selectUsers+" WHERE u.id = $2",
+		models.DefaultCurrency().Code,
 		id,
-	).Scan(&user.ID, &user.Balance)
+	).Scan(&user.ID, &user.Balance, &user.CreditLimit, &user.Account, &user.CreatedAt)
 
 	if err != nil {
 		if err.Error() == "no rows in result set" {
-			return nil, errors.NewBadRequestError("User not found")
+			return nil, errors.NewNotFoundError("User not found")
+		}
+		return nil, err
+	}
+
+	return user, nil
+}
+
+// GetByAccountNumber returns the user owning the account number or nil when there is none.
+func (r *UserRepositoryImpl) GetByAccountNumber(ctx context.Context, accountNumber string) (*models.User, error) {
+	user := &models.User{}
+	err := r.db.QueryRow(
+		ctx,
+		selectUsers+" WHERE u.account_number = $2",
+		models.DefaultCurrency().Code,
+		accountNumber,
+	).Scan(&user.ID, &user.Balance, &user.CreditLimit, &user.Account, &user.CreatedAt)
+
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, nil
 		}
 		return nil, err
 	}
@@ -36,12 +63,113 @@ func (r *UserRepositoryImpl) GetByID(ctx context.Context, id string) (*models.Us
 	return user, nil
 }
 
+// List returns users, newest first, starting after the cursor.
+func (r *UserRepositoryImpl) List(ctx context.Context, after *repositories.Cursor, limit int) ([]models.User, error) {
+	var rows pgx.Rows
+	var err error
+	if after == nil {
+		rows, err = r.db.Query(
+			ctx,
+			selectUsers+`
+			ORDER BY u.created_at DESC, u.id DESC LIMIT $2`,
+			models.DefaultCurrency().Code,
+			limit,
+		)
+	} else {
+		rows, err = r.db.Query(
+			ctx,
+			selectUsers+`
+			WHERE (u.created_at, u.id) < ($3, $4)
+			ORDER BY u.created_at DESC, u.id DESC LIMIT $2`,
+			models.DefaultCurrency().Code,
+			limit,
+			after.CreatedAt,
+			after.ID,
+		)
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	users := make([]models.User, 0, limit)
+	for rows.Next() {
+		var user models.User
+		err = rows.Scan(&user.ID, &user.Balance, &user.CreditLimit, &user.Account, &user.CreatedAt)
+		if err != nil {
+			return nil, err
+		}
+		users = append(users, user)
+	}
+
+	return users, rows.Err()
+}
+
+const createUser = `
+WITH new_user AS (
+  INSERT INTO users DEFAULT VALUES
+  RETURNING id, account_number, created_at
+),
+new_wallet AS (
+  INSERT INTO wallets (user_id, currency)
+  SELECT id, $1 FROM new_user
+  RETURNING balance, credit_limit
+)
+SELECT nu.id, nw.balance, nw.credit_limit, nu.account_number, nu.created_at
+FROM new_user nu
+CROSS JOIN new_wallet nw;`
+
+// Create creates a user with a generated account number and an empty wallet in the default currency.
+func (r *UserRepositoryImpl) Create(ctx context.Context) (*models.User, error) {
+	user := &models.User{}
+	err := r.db.QueryRow(
+		ctx,
+		createUser,
+		models.DefaultCurrency().Code,
+	).Scan(&user.ID, &user.Balance, &user.CreditLimit, &user.Account, &user.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	return user, nil
+}
+
+const updateUserBalance = `
+WITH updated_wallet AS (
+  UPDATE wallets w
+  SET balance = $1::NUMERIC(28,8)
+  FROM (SELECT user_id, currency, balance FROM wallets WHERE user_id = $2 AND currency = $4 FOR UPDATE) old
+  WHERE w.user_id = old.user_id AND w.currency = old.currency
+  RETURNING w.user_id, w.currency, w.balance - old.balance AS change
+),
+adjustment AS MATERIALIZED (
+  SELECT user_id, currency, change, gen_random_uuid() AS posting_id
+  FROM updated_wallet
+  WHERE change <> 0
+)
+INSERT INTO ledger_entries (posting_id, entry_type, account_type, account_id, direction, amount, currency)
+SELECT a.posting_id, 'adjustment', e.account_type, e.account_id, e.direction, ABS(a.change), a.currency
+FROM adjustment a
+CROSS JOIN LATERAL (VALUES
+  ('user', a.user_id, CASE WHEN a.change > 0 THEN 'credit' ELSE 'debit' END),
+  ('equity', $3::UUID, CASE WHEN a.change > 0 THEN 'debit' ELSE 'credit' END)
+) AS e (account_type, account_id, direction);`
+
+// Update sets the balance of the user's wallet in the default currency and books the difference to the ledger
+// as an adjustment.
 func (r *UserRepositoryImpl) Update(ctx context.Context, user *models.User) error {
+	currency := models.DefaultCurrency().Code
+	if err := ensureWallet(ctx, r.db, user.ID, currency); err != nil {
+		return err
+	}
+
 	_, err := r.db.Exec(
 		ctx,
-		"UPDATE users SET balance = $1 WHERE id = $2",
+		updateUserBalance,
 		user.Balance,
 		user.ID,
+		models.EquityAccountID,
+		currency,
 	)
 	return err
 }
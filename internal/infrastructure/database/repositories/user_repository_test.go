@@ -0,0 +1,133 @@
+package repositories
+
+import (
+	"context"
+	"github.com/google/uuid"
+	"github.com/mufasadev/enlabs-test/internal/domain/models"
+	"github.com/mufasadev/enlabs-test/internal/domain/repositories"
+	apperrors "github.com/mufasadev/enlabs-test/internal/errors"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"sync"
+	"testing"
+)
+
+func TestUserRepository(t *testing.T) {
+	setupDB()
+	defer db.Close()
+
+	userRepo := NewUserRepositoryImpl(db)
+
+	first, err := userRepo.Create(context.Background())
+	require.NoError(t, err)
+	defer deleteTestUser(db, first.ID)
+
+	second, err := userRepo.Create(context.Background())
+	require.NoError(t, err)
+	defer deleteTestUser(db, second.ID)
+
+	t.Run("create", func(t *testing.T) {
+		assert.NotEmpty(t, first.ID)
+		assert.True(t, first.Balance.IsZero())
+		assert.NotEqual(t, first.Account, second.Account, "Every user must get its own account number")
+	})
+
+	t.Run("get_by_id", func(t *testing.T) {
+		user, err := userRepo.GetByID(context.Background(), first.ID)
+		require.NoError(t, err)
+		assert.Equal(t, first.Account, user.Account)
+	})
+
+	t.Run("get_by_account_number", func(t *testing.T) {
+		user, err := userRepo.GetByAccountNumber(context.Background(), second.Account)
+		require.NoError(t, err)
+		require.NotNil(t, user)
+		assert.Equal(t, second.ID, user.ID)
+
+		user, err = userRepo.GetByAccountNumber(context.Background(), uuid.New().String())
+		require.NoError(t, err)
+		assert.Nil(t, user)
+	})
+
+	t.Run("list", func(t *testing.T) {
+		users, err := userRepo.List(context.Background(), nil, 1)
+		require.NoError(t, err)
+		require.Len(t, users, 1)
+		assert.Equal(t, second.ID, users[0].ID)
+
+		users, err = userRepo.List(context.Background(), &repositories.Cursor{CreatedAt: users[0].CreatedAt, ID: users[0].ID}, 1)
+		require.NoError(t, err)
+		require.Len(t, users, 1)
+		assert.Equal(t, first.ID, users[0].ID)
+	})
+}
+
+func TestCreditLimit(t *testing.T) {
+	setupDB()
+	defer db.Close()
+
+	userRepo := NewUserRepositoryImpl(db)
+	walletRepo := NewWalletRepositoryImpl(db)
+	transactionRepo := NewTransactionRepositoryImpl(db)
+
+	err := truncateTransactionsTable(db)
+	require.NoError(t, err)
+	err = setInitialUserBalance(db, 0)
+	require.NoError(t, err)
+
+	creditLimit := decimal.NewFromInt(100)
+	err = walletRepo.SetCreditLimit(context.Background(), &models.Wallet{UserID: userId, Currency: models.DefaultCurrency(), CreditLimit: creditLimit})
+	require.NoError(t, err)
+	defer func() {
+		_ = setInitialUserBalance(db, 0)
+		_ = walletRepo.SetCreditLimit(context.Background(), &models.Wallet{UserID: userId, Currency: models.DefaultCurrency()})
+	}()
+
+	t.Run("overdraft_within_limit", func(t *testing.T) {
+		transaction := &models.Transaction{
+			TransactionID: uuid.New().String(),
+			State:         "lost",
+			Amount:        newMoney(decimal.NewFromInt(60)),
+			SourceType:    models.SourceType{ID: sourceTypeId},
+			User:          models.User{ID: userId},
+		}
+		data, err := transactionRepo.InsertTransactionAndUpdateUserBalanceWithCreatingTransaction(context.Background(), transaction)
+		require.NoError(t, err)
+		assert.True(t, decimal.NewFromInt(-60).Equal(data.UserBalance.Decimal))
+	})
+
+	t.Run("lowering_limit_below_overdraft", func(t *testing.T) {
+		err := walletRepo.SetCreditLimit(context.Background(), &models.Wallet{UserID: userId, Currency: models.DefaultCurrency(), CreditLimit: decimal.NewFromInt(50)})
+		assert.True(t, apperrors.As(err, new(*apperrors.ConflictError)))
+	})
+
+	t.Run("concurrent_losses_and_cancellations", func(t *testing.T) {
+		n := 50
+		var wg sync.WaitGroup
+		wg.Add(n)
+		for i := 0; i < n; i++ {
+			go func(i int) {
+				defer wg.Done()
+				state := "lost"
+				if i%5 == 0 {
+					state = "win"
+				}
+				transaction := &models.Transaction{
+					TransactionID: uuid.New().String(),
+					State:         state,
+					Amount:        newMoney(randDecimal(0)),
+					SourceType:    models.SourceType{ID: sourceTypeId},
+					User:          models.User{ID: userId},
+				}
+				_, _ = transactionRepo.InsertTransactionAndUpdateUserBalanceWithCreatingTransaction(context.Background(), transaction)
+				_, _ = transactionRepo.CancelTransactionsAndUpdateBalance(context.Background(), NewPerUserOddStrategy(DefaultCancelBatchSize), "")
+			}(i)
+		}
+		wg.Wait()
+
+		user, err := userRepo.GetByID(context.Background(), userId)
+		require.NoError(t, err)
+		assert.True(t, user.Balance.GreaterThanOrEqual(creditLimit.Neg()), "The balance must never go below the credit limit")
+	})
+}
@@ -0,0 +1,84 @@
+package repositories
+
+import (
+	"context"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/mufasadev/enlabs-test/internal/domain/models"
+	"github.com/mufasadev/enlabs-test/internal/domain/repositories"
+	"github.com/mufasadev/enlabs-test/internal/errors"
+)
+
+type WalletRepositoryImpl struct {
+	db *pgxpool.Pool
+}
+
+func NewWalletRepositoryImpl(db *pgxpool.Pool) repositories.WalletRepository {
+	return &WalletRepositoryImpl{
+		db: db,
+	}
+}
+
+// ensureWallet opens an empty wallet of the user in the currency unless it exists. It runs on its own, before the
+// statement moving funds, so the transaction of that statement sees the wallet.
+func ensureWallet(ctx context.Context, db *pgxpool.Pool, userID string, currency string) error {
+	_, err := db.Exec(
+		ctx,
+		"INSERT INTO wallets (user_id, currency) VALUES ($1, $2) ON CONFLICT (user_id, currency) DO NOTHING",
+		userID,
+		currency,
+	)
+	return err
+}
+
+// ListByUserID returns the wallets of the user ordered by currency.
+func (r *WalletRepositoryImpl) ListByUserID(ctx context.Context, userID string) ([]models.Wallet, error) {
+	rows, err := r.db.Query(
+		ctx,
+		`SELECT w.user_id, c.code, c.scale, w.balance, w.credit_limit, w.created_at, w.updated_at
+		FROM wallets w
+		JOIN currencies c ON c.code = w.currency
+		WHERE w.user_id = $1
+		ORDER BY w.currency`,
+		userID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	wallets := make([]models.Wallet, 0)
+	for rows.Next() {
+		var w models.Wallet
+		err = rows.Scan(&w.UserID, &w.Currency.Code, &w.Currency.Scale, &w.Balance, &w.CreditLimit, &w.CreatedAt, &w.UpdatedAt)
+		if err != nil {
+			return nil, err
+		}
+		wallets = append(wallets, w)
+	}
+
+	return wallets, rows.Err()
+}
+
+// SetCreditLimit stores the credit limit of a wallet, opening the wallet when the user has none in the currency.
+// A limit below the current overdraft is rejected.
+func (r *WalletRepositoryImpl) SetCreditLimit(ctx context.Context, wallet *models.Wallet) error {
+	_, err := r.db.Exec(
+		ctx,
+		`INSERT INTO wallets (user_id, currency, credit_limit) VALUES ($1, $2, $3::NUMERIC(28,8))
+		ON CONFLICT (user_id, currency) DO UPDATE SET credit_limit = EXCLUDED.credit_limit`,
+		wallet.UserID,
+		wallet.Currency.Code,
+		wallet.CreditLimit,
+	)
+
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.SQLState() == repositories.CheckViolationError {
+		return errors.NewConflictError("The balance is below the new credit limit")
+	}
+	if errors.As(err, &pgErr) && pgErr.SQLState() == repositories.ForeignKeyViolationError {
+		return errors.NewNotFoundError("User not found")
+	}
+
+	return err
+}
@@ -0,0 +1,114 @@
+package repositories
+
+import (
+	"context"
+	"github.com/google/uuid"
+	"github.com/mufasadev/enlabs-test/internal/domain/models"
+	apperrors "github.com/mufasadev/enlabs-test/internal/errors"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func TestCurrencyRepository(t *testing.T) {
+	setupDB()
+	defer db.Close()
+
+	currencyRepo := NewCurrencyRepositoryImpl(db)
+
+	currency, err := currencyRepo.GetByCode(context.Background(), "BTC")
+	require.NoError(t, err)
+	require.NotNil(t, currency)
+	assert.Equal(t, int32(8), currency.Scale)
+
+	currency, err = currencyRepo.GetByCode(context.Background(), "XXX")
+	require.NoError(t, err)
+	assert.Nil(t, currency)
+
+	currencies, err := currencyRepo.List(context.Background())
+	require.NoError(t, err)
+	assert.GreaterOrEqual(t, len(currencies), 3)
+}
+
+func TestWallets(t *testing.T) {
+	setupDB()
+	defer db.Close()
+
+	walletRepo := NewWalletRepositoryImpl(db)
+	transactionRepo := NewTransactionRepositoryImpl(db)
+	ledgerRepo := NewLedgerRepositoryImpl(db)
+
+	err := truncateTransactionsTable(db)
+	require.NoError(t, err)
+	err = setInitialUserBalance(db, 100)
+	require.NoError(t, err)
+	defer func() {
+		_, _ = db.Exec(context.Background(), "DELETE FROM wallets WHERE user_id = $1 AND currency <> 'EUR'", userId)
+	}()
+
+	usd := models.Currency{Code: "USD", Scale: 2}
+	btc := models.Currency{Code: "BTC", Scale: 8}
+	apply := func(state string, amount string, currency models.Currency) (decimal.Decimal, error) {
+		transaction := &models.Transaction{
+			TransactionID: uuid.New().String(),
+			State:         state,
+			Amount:        models.NewMoney(decimal.RequireFromString(amount), currency),
+			SourceType:    models.SourceType{ID: sourceTypeId},
+			User:          models.User{ID: userId},
+		}
+		data, err := transactionRepo.InsertTransactionAndUpdateUserBalanceWithCreatingTransaction(context.Background(), transaction)
+		return data.UserBalance.Decimal, err
+	}
+
+	t.Run("win_opens_a_wallet", func(t *testing.T) {
+		balance, err := apply("win", "50", usd)
+		require.NoError(t, err)
+		assert.True(t, decimal.NewFromInt(50).Equal(balance))
+
+		balance, err = apply("win", "0.12345678", btc)
+		require.NoError(t, err)
+		assert.Equal(t, "0.12345678", btc.Format(balance))
+	})
+
+	t.Run("wallets_are_separate", func(t *testing.T) {
+		// the EUR balance would cover the loss, the USD balance does not
+		_, err := apply("lost", "80", usd)
+		assert.True(t, apperrors.As(err, new(*apperrors.InsufficientFundsError)))
+
+		balance, err := transactionRepo.GetUserBalance(context.Background(), userId, "USD")
+		require.NoError(t, err)
+		assert.True(t, decimal.NewFromInt(50).Equal(*balance))
+
+		balance, err = transactionRepo.GetUserBalance(context.Background(), userId, "EUR")
+		require.NoError(t, err)
+		assert.True(t, decimal.NewFromInt(100).Equal(*balance))
+	})
+
+	t.Run("list", func(t *testing.T) {
+		wallets, err := walletRepo.ListByUserID(context.Background(), userId)
+		require.NoError(t, err)
+		require.Len(t, wallets, 3)
+		assert.Equal(t, btc, wallets[0].Currency)
+		assert.Equal(t, "EUR", wallets[1].Currency.Code)
+		assert.Equal(t, usd, wallets[2].Currency)
+	})
+
+	t.Run("ledger_per_currency", func(t *testing.T) {
+		ledgerBalance, err := ledgerRepo.GetAccountBalance(context.Background(), models.AccountTypeUser, userId, "USD")
+		require.NoError(t, err)
+		assert.True(t, decimal.NewFromInt(50).Equal(ledgerBalance))
+	})
+
+	t.Run("cancel_on_the_matching_wallet", func(t *testing.T) {
+		rows, err := transactionRepo.CancelTransactionsAndUpdateBalance(context.Background(), NewPerUserOddStrategy(1), "")
+		require.NoError(t, err)
+		require.Len(t, rows, 1)
+		assert.Equal(t, btc, rows[0].Currency)
+		assert.True(t, rows[0].UserBalance.IsZero())
+
+		balance, err := transactionRepo.GetUserBalance(context.Background(), userId, "USD")
+		require.NoError(t, err)
+		assert.True(t, decimal.NewFromInt(50).Equal(*balance))
+	})
+}
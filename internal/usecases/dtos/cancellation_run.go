@@ -0,0 +1,134 @@
+package dtos
+
+import (
+	"github.com/mufasadev/enlabs-test/internal/domain/models"
+	"github.com/mufasadev/enlabs-test/internal/domain/repositories"
+	"time"
+)
+
+// PageQuery holds the pagination parameters taken from the query string.
+type PageQuery struct {
+	Cursor string
+	Limit  int
+}
+
+type CancellationResponse struct {
+	ID            string    `json:"id"`
+	TransactionID string    `json:"transactionId"`
+	UserID        string    `json:"userId"`
+	Currency      string    `json:"currency"`
+	BalanceBefore string    `json:"balanceBefore"`
+	BalanceAfter  string    `json:"balanceAfter"`
+	CreatedAt     time.Time `json:"createdAt"`
+}
+
+type CancellationRunResponse struct {
+	ID             string                 `json:"id"`
+	Strategy       string                 `json:"strategy"`
+	StartedAt      time.Time              `json:"startedAt"`
+	FinishedAt     *time.Time             `json:"finishedAt,omitempty"`
+	CancelledCount int                    `json:"cancelledCount"`
+	Error          *string                `json:"error,omitempty"`
+	Items          []CancellationResponse `json:"items,omitempty"`
+}
+
+type CancellationRunPageResponse struct {
+	Items      []CancellationRunResponse `json:"items"`
+	NextCursor string                    `json:"nextCursor,omitempty"`
+}
+
+// NewCancellationRunResponse maps a cancellation run to its API representation.
+func NewCancellationRunResponse(r *models.CancellationRun) CancellationRunResponse {
+	return CancellationRunResponse{
+		ID:             r.ID,
+		Strategy:       r.Strategy,
+		StartedAt:      r.StartedAt,
+		FinishedAt:     r.FinishedAt,
+		CancelledCount: r.CancelledCount,
+		Error:          r.Error,
+	}
+}
+
+// NewCancellationResponse maps a cancelled transaction to its API representation.
+func NewCancellationResponse(c *models.Cancellation) CancellationResponse {
+	return CancellationResponse{
+		ID:            c.ID,
+		TransactionID: c.TransactionID,
+		UserID:        c.UserID,
+		Currency:      c.Currency.Code,
+		BalanceBefore: c.Currency.Format(c.BalanceBefore),
+		BalanceAfter:  c.Currency.Format(c.BalanceAfter),
+		CreatedAt:     c.CreatedAt,
+	}
+}
+
+// NewCancellationResponses maps the transactions cancelled by a run to their API representation.
+func NewCancellationResponses(items []models.Cancellation) []CancellationResponse {
+	responses := make([]CancellationResponse, 0, len(items))
+	for idx := range items {
+		responses = append(responses, NewCancellationResponse(&items[idx]))
+	}
+	return responses
+}
+
+type CancellationPreviewItem struct {
+	TransactionID string `json:"transactionId"`
+	UserID        string `json:"userId"`
+	State         string `json:"state"`
+	Amount        string `json:"amount"`
+	Currency      string `json:"currency"`
+	BalanceBefore string `json:"balanceBefore"`
+	BalanceAfter  string `json:"balanceAfter"`
+}
+
+type UserBalanceResponse struct {
+	UserID   string `json:"userId"`
+	Currency string `json:"currency"`
+	Balance  string `json:"balance"`
+}
+
+type CancellationPreviewResponse struct {
+	Strategy  string                    `json:"strategy"`
+	Cancelled []CancellationPreviewItem `json:"cancelled"`
+	Skipped   []CancellationPreviewItem `json:"skipped"`
+	Balances  []UserBalanceResponse     `json:"balances"`
+}
+
+// NewCancellationPreviewResponse splits the preview rows into cancelled and skipped transactions
+// and collects the resulting balance of every wallet.
+func NewCancellationPreviewResponse(strategy string, rows []repositories.CancellationPreviewRow) *CancellationPreviewResponse {
+	response := &CancellationPreviewResponse{
+		Strategy:  strategy,
+		Cancelled: make([]CancellationPreviewItem, 0),
+		Skipped:   make([]CancellationPreviewItem, 0),
+		Balances:  make([]UserBalanceResponse, 0),
+	}
+
+	for _, row := range rows {
+		item := CancellationPreviewItem{
+			TransactionID: row.TransactionId,
+			UserID:        row.UserId,
+			State:         row.State,
+			Amount:        row.Currency.Format(row.Amount),
+			Currency:      row.Currency.Code,
+			BalanceBefore: row.Currency.Format(row.BalanceBefore),
+			BalanceAfter:  row.Currency.Format(row.BalanceAfter),
+		}
+		if row.Cancellable {
+			response.Cancelled = append(response.Cancelled, item)
+		} else {
+			response.Skipped = append(response.Skipped, item)
+		}
+
+		// rows are ordered by user and currency
+		if n := len(response.Balances); n == 0 || response.Balances[n-1].UserID != row.UserId || response.Balances[n-1].Currency != row.Currency.Code {
+			response.Balances = append(response.Balances, UserBalanceResponse{
+				UserID:   row.UserId,
+				Currency: row.Currency.Code,
+				Balance:  row.Currency.Format(row.UserBalance),
+			})
+		}
+	}
+
+	return response
+}
@@ -0,0 +1,57 @@
+package dtos
+
+import (
+	"github.com/mufasadev/enlabs-test/internal/domain/models"
+	"github.com/shopspring/decimal"
+	"time"
+)
+
+type CurrencyResponse struct {
+	Code  string `json:"code"`
+	Scale int32  `json:"scale"`
+}
+
+type WalletResponse struct {
+	Currency    string `json:"currency"`
+	Balance     string `json:"balance"`
+	CreditLimit string `json:"creditLimit,omitempty"`
+}
+
+// BalanceResponse holds every wallet of a user. Balance is the balance in the default currency, kept for clients
+// not aware of wallets. At is set for a balance at a past instant, which has no credit limits.
+type BalanceResponse struct {
+	Balance string           `json:"balance"`
+	Wallets []WalletResponse `json:"wallets"`
+	At      *time.Time       `json:"at,omitempty"`
+}
+
+// NewCurrencyResponses maps the currency registry to its API representation.
+func NewCurrencyResponses(currencies []models.Currency) []CurrencyResponse {
+	responses := make([]CurrencyResponse, 0, len(currencies))
+	for _, c := range currencies {
+		responses = append(responses, CurrencyResponse{Code: c.Code, Scale: c.Scale})
+	}
+	return responses
+}
+
+// NewBalanceResponse maps the wallets of a user to their API representation.
+func NewBalanceResponse(wallets []models.Wallet, at *time.Time) *BalanceResponse {
+	response := &BalanceResponse{
+		Balance: models.DefaultCurrency().Format(decimal.Zero),
+		Wallets: make([]WalletResponse, 0, len(wallets)),
+		At:      at,
+	}
+
+	for _, w := range wallets {
+		wallet := WalletResponse{Currency: w.Currency.Code, Balance: w.Currency.Format(w.Balance)}
+		if at == nil {
+			wallet.CreditLimit = w.Currency.Format(w.CreditLimit)
+		}
+		if w.Currency.Code == models.DefaultCurrency().Code {
+			response.Balance = wallet.Balance
+		}
+		response.Wallets = append(response.Wallets, wallet)
+	}
+
+	return response
+}
@@ -0,0 +1,35 @@
+package dtos
+
+import (
+	"github.com/mufasadev/enlabs-test/internal/domain/models"
+	"time"
+)
+
+// FxRateDTO is the body of the rate create request. A rate without validFrom is valid from now on.
+type FxRateDTO struct {
+	BaseCurrency  string     `json:"baseCurrency"`
+	QuoteCurrency string     `json:"quoteCurrency"`
+	Rate          string     `json:"rate"`
+	ValidFrom     *time.Time `json:"validFrom"`
+}
+
+type FxRateResponse struct {
+	ID            int64     `json:"id"`
+	BaseCurrency  string    `json:"baseCurrency"`
+	QuoteCurrency string    `json:"quoteCurrency"`
+	Rate          string    `json:"rate"`
+	ValidFrom     time.Time `json:"validFrom"`
+	CreatedAt     time.Time `json:"createdAt"`
+}
+
+// NewFxRateResponse maps a rate to its API representation.
+func NewFxRateResponse(r *models.FxRate) FxRateResponse {
+	return FxRateResponse{
+		ID:            r.ID,
+		BaseCurrency:  r.BaseCurrency,
+		QuoteCurrency: r.QuoteCurrency,
+		Rate:          r.Rate.String(),
+		ValidFrom:     r.ValidFrom,
+		CreatedAt:     r.CreatedAt,
+	}
+}
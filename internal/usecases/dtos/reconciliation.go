@@ -0,0 +1,58 @@
+package dtos
+
+import (
+	"github.com/mufasadev/enlabs-test/internal/domain/models"
+	"time"
+)
+
+type BalanceDriftResponse struct {
+	UserID          string    `json:"userId"`
+	Currency        string    `json:"currency"`
+	ExpectedBalance string    `json:"expectedBalance"`
+	ActualBalance   string    `json:"actualBalance"`
+	LedgerBalance   string    `json:"ledgerBalance"`
+	CreatedAt       time.Time `json:"createdAt"`
+}
+
+type ReconciliationRunResponse struct {
+	ID             string                 `json:"id"`
+	StartedAt      time.Time              `json:"startedAt"`
+	FinishedAt     *time.Time             `json:"finishedAt,omitempty"`
+	WalletsChecked int                    `json:"walletsChecked"`
+	DriftedCount   int                    `json:"driftedCount"`
+	Error          *string                `json:"error,omitempty"`
+	Drifts         []BalanceDriftResponse `json:"drifts,omitempty"`
+}
+
+type ReconciliationRunPageResponse struct {
+	Items      []ReconciliationRunResponse `json:"items"`
+	NextCursor string                      `json:"nextCursor,omitempty"`
+}
+
+// NewReconciliationRunResponse maps a reconciliation run to its API representation.
+func NewReconciliationRunResponse(r *models.ReconciliationRun) ReconciliationRunResponse {
+	return ReconciliationRunResponse{
+		ID:             r.ID,
+		StartedAt:      r.StartedAt,
+		FinishedAt:     r.FinishedAt,
+		WalletsChecked: r.WalletsChecked,
+		DriftedCount:   r.DriftedCount,
+		Error:          r.Error,
+	}
+}
+
+// NewBalanceDriftResponses maps the drifts found by a run to their API representation.
+func NewBalanceDriftResponses(drifts []models.BalanceDrift) []BalanceDriftResponse {
+	responses := make([]BalanceDriftResponse, 0, len(drifts))
+	for _, d := range drifts {
+		responses = append(responses, BalanceDriftResponse{
+			UserID:          d.UserID,
+			Currency:        d.Currency.Code,
+			ExpectedBalance: d.Currency.Format(d.ExpectedBalance),
+			ActualBalance:   d.Currency.Format(d.ActualBalance),
+			LedgerBalance:   d.Currency.Format(d.LedgerBalance),
+			CreatedAt:       d.CreatedAt,
+		})
+	}
+	return responses
+}
@@ -0,0 +1,39 @@
+package dtos
+
+import (
+	"github.com/mufasadev/enlabs-test/internal/domain/models"
+	"time"
+)
+
+// RoundResponse is the status of a game round. Balance and RolledBackCount are only set by a rollback: the wallet
+// balance after it and the number of transactions it reversed.
+type RoundResponse struct {
+	RoundID         string     `json:"roundId"`
+	UserID          string     `json:"userId"`
+	Source          string     `json:"source"`
+	Currency        string     `json:"currency"`
+	Status          string     `json:"status"`
+	BetAmount       string     `json:"betAmount"`
+	WinAmount       string     `json:"winAmount"`
+	Balance         *string    `json:"balance,omitempty"`
+	RolledBackCount int        `json:"rolledBackCount,omitempty"`
+	CreatedAt       time.Time  `json:"createdAt"`
+	UpdatedAt       time.Time  `json:"updatedAt"`
+	FinishedAt      *time.Time `json:"finishedAt,omitempty"`
+}
+
+// NewRoundResponse maps a round to its API representation.
+func NewRoundResponse(r *models.Round) *RoundResponse {
+	return &RoundResponse{
+		RoundID:    r.RoundID,
+		UserID:     r.UserID,
+		Source:     r.SourceType.Name,
+		Currency:   r.Currency.Code,
+		Status:     string(r.Status),
+		BetAmount:  r.Currency.Format(r.BetAmount),
+		WinAmount:  r.Currency.Format(r.WinAmount),
+		CreatedAt:  r.CreatedAt,
+		UpdatedAt:  r.UpdatedAt,
+		FinishedAt: r.FinishedAt,
+	}
+}
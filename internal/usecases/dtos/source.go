@@ -0,0 +1,63 @@
+package dtos
+
+import (
+	"github.com/mufasadev/enlabs-test/internal/domain/models"
+	"github.com/shopspring/decimal"
+	"time"
+)
+
+// SourceDTO is the body of the source create and update requests. Omitted fields are left unchanged on update.
+type SourceDTO struct {
+	Name        string                 `json:"name"`
+	DisplayName *string                `json:"displayName"`
+	Enabled     *bool                  `json:"enabled"`
+	Settings    map[string]interface{} `json:"settings"`
+	Limits      *SourceLimitsDTO       `json:"limits"`
+	States      []string               `json:"states"`
+}
+
+// SourceLimitsDTO replaces all limits of a source. A null or omitted limit is unlimited.
+type SourceLimitsDTO struct {
+	MaxAmount    *string `json:"maxAmount"`
+	MaxDailyWin  *string `json:"maxDailyWin"`
+	MaxDailyLoss *string `json:"maxDailyLoss"`
+}
+
+type SourceResponse struct {
+	ID          string                 `json:"id"`
+	Name        string                 `json:"name"`
+	DisplayName string                 `json:"displayName"`
+	Enabled     bool                   `json:"enabled"`
+	Settings    map[string]interface{} `json:"settings"`
+	Limits      SourceLimitsDTO        `json:"limits"`
+	States      []string               `json:"states"`
+	CreatedAt   time.Time              `json:"createdAt"`
+	UpdatedAt   time.Time              `json:"updatedAt"`
+}
+
+// NewSourceResponse maps a source to its API representation.
+func NewSourceResponse(s *models.SourceType) SourceResponse {
+	return SourceResponse{
+		ID:          s.ID,
+		Name:        s.Name,
+		DisplayName: s.DisplayName,
+		Enabled:     s.Enabled,
+		Settings:    s.Settings,
+		Limits: SourceLimitsDTO{
+			MaxAmount:    formatLimit(s.Limits.MaxAmount),
+			MaxDailyWin:  formatLimit(s.Limits.MaxDailyWin),
+			MaxDailyLoss: formatLimit(s.Limits.MaxDailyLoss),
+		},
+		States:    s.States,
+		CreatedAt: s.CreatedAt,
+		UpdatedAt: s.UpdatedAt,
+	}
+}
+
+func formatLimit(limit *decimal.Decimal) *string {
+	if limit == nil {
+		return nil
+	}
+	formatted := models.DefaultCurrency().Format(*limit)
+	return &formatted
+}
@@ -0,0 +1,40 @@
+package dtos
+
+import (
+	"time"
+)
+
+// Statement formats.
+const (
+	StatementFormatJSON = "json"
+	StatementFormatCSV  = "csv"
+)
+
+// StatementQuery holds the statement period, currency and format taken from the query string.
+type StatementQuery struct {
+	From     *time.Time
+	To       *time.Time
+	Currency string
+	Format   string
+}
+
+// StatementSummary describes a statement. The closing balance is only known once every line is written.
+type StatementSummary struct {
+	UserID         string     `json:"userId"`
+	Currency       string     `json:"currency"`
+	From           *time.Time `json:"from,omitempty"`
+	To             time.Time  `json:"to"`
+	OpeningBalance string     `json:"openingBalance"`
+	ClosingBalance string     `json:"closingBalance,omitempty"`
+}
+
+// StatementLine is a balance change with the balance right after it.
+type StatementLine struct {
+	Date          time.Time `json:"date"`
+	Type          string    `json:"type"`
+	TransactionID string    `json:"transactionId,omitempty"`
+	State         string    `json:"state,omitempty"`
+	Source        string    `json:"source,omitempty"`
+	Amount        string    `json:"amount"`
+	Balance       string    `json:"balance"`
+}
@@ -1,10 +1,121 @@
 package dtos
 
-import "encoding/json"
+import (
+	"encoding/json"
+	"github.com/mufasadev/enlabs-test/internal/domain/models"
+	"time"
+)
 
+// TransactionDTO is a transaction request. The amount is in Currency and is converted when WalletCurrency, the
+// currency of the wallet to change, differs from it. Both default to the default currency. Metadata is an optional
+// JSON object stored with the transaction. RoundID links a bet and its wins to a game round.
 type TransactionDTO struct {
-	State         string          `json:"state"`
-	Amount        string          `json:"-"`
-	RawAmount     json.RawMessage `json:"amount"`
-	TransactionID string          `json:"transactionId"`
+	State          string                 `json:"state"`
+	Amount         string                 `json:"-"`
+	RawAmount      json.RawMessage        `json:"amount"`
+	Currency       string                 `json:"currency"`
+	WalletCurrency string                 `json:"walletCurrency"`
+	TransactionID  string                 `json:"transactionId"`
+	Metadata       map[string]interface{} `json:"metadata"`
+	RoundID        string                 `json:"roundId"`
+}
+
+// TransactionListQuery holds the history filters taken from the query string.
+type TransactionListQuery struct {
+	State    string
+	Currency string
+	Source   string
+	Status   string
+	From     *time.Time
+	To       *time.Time
+	Metadata map[string]string
+	RoundID  string
+	Cursor   string
+	Limit    int
+}
+
+type TransactionResponse struct {
+	ID               string                 `json:"id"`
+	TransactionID    string                 `json:"transactionId"`
+	State            string                 `json:"state"`
+	Amount           string                 `json:"amount"`
+	Currency         string                 `json:"currency"`
+	OriginalAmount   *string                `json:"originalAmount,omitempty"`
+	OriginalCurrency string                 `json:"originalCurrency,omitempty"`
+	FxRate           *string                `json:"fxRate,omitempty"`
+	RoundID          string                 `json:"roundId,omitempty"`
+	Source           string                 `json:"source"`
+	Status           string                 `json:"status"`
+	CreatedAt        time.Time              `json:"createdAt"`
+	UpdatedAt        time.Time              `json:"updatedAt"`
+	CancelledAt      *time.Time             `json:"cancelledAt,omitempty"`
+	Metadata         map[string]interface{} `json:"metadata"`
+}
+
+type TransactionPageResponse struct {
+	Items      []TransactionResponse `json:"items"`
+	NextCursor string                `json:"nextCursor,omitempty"`
+}
+
+// NewTransactionResponse maps a stored transaction to its API representation.
+func NewTransactionResponse(t *models.Transaction) TransactionResponse {
+	response := TransactionResponse{
+		ID:            t.ID,
+		TransactionID: t.TransactionID,
+		State:         t.State,
+		Amount:        t.Amount.String(),
+		Currency:      t.Amount.CurrencyOrDefault().Code,
+		Source:        t.SourceType.Name,
+		Status:        string(t.Status),
+		CreatedAt:     t.CreatedAt,
+		UpdatedAt:     t.UpdatedAt,
+		CancelledAt:   t.CancelledAt,
+		Metadata:      t.Metadata,
+		RoundID:       t.RoundID,
+	}
+	if response.Metadata == nil {
+		response.Metadata = map[string]interface{}{}
+	}
+
+	if t.OriginalAmount != nil && t.FxRate != nil {
+		amount := t.OriginalAmount.String()
+		rate := t.FxRate.String()
+		response.OriginalAmount = &amount
+		response.OriginalCurrency = t.OriginalAmount.Currency.Code
+		response.FxRate = &rate
+	}
+
+	return response
+}
+
+// Batch modes. An atomic batch is applied as a whole or not at all, a best effort batch applies every item on its own.
+const (
+	BatchModeAtomic     = "atomic"
+	BatchModeBestEffort = "best_effort"
+)
+
+// Batch item statuses besides the transaction statuses. A failed item was not processed because of its error
+// code, a not applied item was rolled back or skipped because another item of an atomic batch failed.
+const (
+	BatchItemFailed     = "failed"
+	BatchItemNotApplied = "not_applied"
+)
+
+type TransactionBatchDTO struct {
+	Mode         string           `json:"mode"`
+	Transactions []TransactionDTO `json:"transactions"`
+}
+
+type TransactionBatchItemResponse struct {
+	TransactionID string  `json:"transactionId"`
+	Status        string  `json:"status"`
+	Balance       *string `json:"balance,omitempty"`
+	Replayed      bool    `json:"replayed,omitempty"`
+	ErrorCode     string  `json:"errorCode,omitempty"`
+	Message       string  `json:"message,omitempty"`
+}
+
+type TransactionBatchResponse struct {
+	Mode  string                         `json:"mode"`
+	Items []TransactionBatchItemResponse `json:"items"`
 }
@@ -0,0 +1,42 @@
+package dtos
+
+import (
+	"github.com/mufasadev/enlabs-test/internal/domain/models"
+	"time"
+)
+
+type TransferDTO struct {
+	TransferID string `json:"transferId"`
+	ToUserID   string `json:"toUserId"`
+	Amount     string `json:"amount"`
+	Currency   string `json:"currency"`
+}
+
+type TransferResponse struct {
+	ID          string    `json:"id"`
+	TransferID  string    `json:"transferId"`
+	FromUserID  string    `json:"fromUserId"`
+	ToUserID    string    `json:"toUserId"`
+	Amount      string    `json:"amount"`
+	Currency    string    `json:"currency"`
+	Status      string    `json:"status"`
+	FromBalance string    `json:"fromBalance"`
+	ToBalance   string    `json:"toBalance"`
+	CreatedAt   time.Time `json:"createdAt"`
+}
+
+// NewTransferResponse maps a transfer to its API representation.
+func NewTransferResponse(t *models.Transfer) *TransferResponse {
+	return &TransferResponse{
+		ID:          t.ID,
+		TransferID:  t.TransferID,
+		FromUserID:  t.FromUserID,
+		ToUserID:    t.ToUserID,
+		Amount:      t.Amount.String(),
+		Currency:    t.Amount.CurrencyOrDefault().Code,
+		Status:      string(t.Status),
+		FromBalance: t.Amount.CurrencyOrDefault().Format(t.FromBalanceAfter),
+		ToBalance:   t.Amount.CurrencyOrDefault().Format(t.ToBalanceAfter),
+		CreatedAt:   t.CreatedAt,
+	}
+}
@@ -0,0 +1,35 @@
+package dtos
+
+import (
+	"github.com/mufasadev/enlabs-test/internal/domain/models"
+	"time"
+)
+
+type UserResponse struct {
+	ID            string    `json:"id"`
+	AccountNumber string    `json:"accountNumber"`
+	Balance       string    `json:"balance"`
+	CreditLimit   string    `json:"creditLimit"`
+	CreatedAt     time.Time `json:"createdAt"`
+}
+
+type CreditLimitDTO struct {
+	CreditLimit string `json:"creditLimit"`
+	Currency    string `json:"currency"`
+}
+
+type UserPageResponse struct {
+	Items      []UserResponse `json:"items"`
+	NextCursor string         `json:"nextCursor,omitempty"`
+}
+
+// NewUserResponse maps a user to its API representation.
+func NewUserResponse(u *models.User) UserResponse {
+	return UserResponse{
+		ID:            u.ID,
+		AccountNumber: u.Account,
+		Balance:       models.DefaultCurrency().Format(u.Balance),
+		CreditLimit:   models.DefaultCurrency().Format(u.CreditLimit),
+		CreatedAt:     u.CreatedAt,
+	}
+}
@@ -0,0 +1,75 @@
+package interactor
+
+import (
+	"context"
+	"github.com/mufasadev/enlabs-test/internal/domain/repositories"
+	apperrors "github.com/mufasadev/enlabs-test/internal/errors"
+	"github.com/mufasadev/enlabs-test/internal/usecases/dtos"
+	"github.com/mufasadev/enlabs-test/pkg/log"
+	"github.com/rs/zerolog"
+	"time"
+)
+
+// snapshotLag keeps snapshots behind the clock, so transactions still in flight at the snapshot instant
+// are committed before it is taken.
+const snapshotLag = time.Minute
+
+type BalanceSnapshotInteractor struct {
+	balanceSnapshotRepository repositories.BalanceSnapshotRepository
+	walletRepository          repositories.WalletRepository
+	userRepository            repositories.UserRepository
+	logger                    *zerolog.Logger
+}
+
+func NewBalanceSnapshotInteractor(balanceSnapshotRepository repositories.BalanceSnapshotRepository, walletRepository repositories.WalletRepository, userRepository repositories.UserRepository) *BalanceSnapshotInteractor {
+	l := log.GetLogger()
+	return &BalanceSnapshotInteractor{
+		balanceSnapshotRepository: balanceSnapshotRepository,
+		walletRepository:          walletRepository,
+		userRepository:            userRepository,
+		logger:                    &l,
+	}
+}
+
+// Execute takes a balance snapshot of every user whose balance changed since the previous one.
+func (i *BalanceSnapshotInteractor) Execute(ctx context.Context) error {
+	ctx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+
+	count, err := i.balanceSnapshotRepository.TakeSnapshots(ctx, time.Now().Add(-snapshotLag))
+	if err != nil {
+		i.logger.Error().Err(err).Msg(apperrors.ErrFailedTakeBalanceSnapshots)
+		return err
+	}
+
+	i.logger.Info().Msgf("Balance snapshots taken: %d", count)
+	return nil
+}
+
+// GetBalanceAt returns the balance of every wallet of the user at a past instant.
+func (i *BalanceSnapshotInteractor) GetBalanceAt(ctx context.Context, userID string, at time.Time) (*dtos.BalanceResponse, error) {
+	if at.After(time.Now()) {
+		return nil, apperrors.NewBadRequestError("at must not be in the future")
+	}
+
+	if _, err := findUser(ctx, i.userRepository, userID); err != nil {
+		return nil, err
+	}
+
+	wallets, err := i.walletRepository.ListByUserID(ctx, userID)
+	if err != nil {
+		i.logger.Error().Err(err).Msg("Failed to get balance")
+		return nil, err
+	}
+
+	for idx := range wallets {
+		balance, err := i.balanceSnapshotRepository.GetUserBalanceAt(ctx, userID, wallets[idx].Currency.Code, at)
+		if err != nil {
+			i.logger.Error().Err(err).Msg("Failed to get balance")
+			return nil, err
+		}
+		wallets[idx].Balance = balance
+	}
+
+	return dtos.NewBalanceResponse(wallets, &at), nil
+}
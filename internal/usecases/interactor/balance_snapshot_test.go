@@ -0,0 +1,20 @@
+package interactor
+
+import (
+	"context"
+	apperrors "github.com/mufasadev/enlabs-test/internal/errors"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestGetBalanceAtChecksTheUser(t *testing.T) {
+	i := NewBalanceSnapshotInteractor(nil, nil, &fakeUserRepository{})
+	at := time.Now().Add(-time.Hour)
+
+	_, err := i.GetBalanceAt(context.Background(), "not-a-uuid", at)
+	assert.True(t, apperrors.As(err, new(*apperrors.BadRequestError)))
+
+	_, err = i.GetBalanceAt(context.Background(), "8d1b6e0a-6d55-4d0f-a3f4-0c1c7b8e2d11", at)
+	assert.True(t, apperrors.As(err, new(*apperrors.NotFoundError)))
+}
@@ -2,9 +2,9 @@ package interactor
 
 import (
 	"context"
-	"fmt"
 	"github.com/mufasadev/enlabs-test/internal/domain/repositories"
 	"github.com/mufasadev/enlabs-test/internal/errors"
+	"github.com/mufasadev/enlabs-test/internal/usecases/dtos"
 	"github.com/mufasadev/enlabs-test/pkg/log"
 	"github.com/rs/zerolog"
 	"sync"
@@ -12,39 +12,114 @@ import (
 )
 
 type CancelTransactionInteractor struct {
-	transactionRepository repositories.TransactionRepository
-	logger                *zerolog.Logger
+	transactionRepository     repositories.TransactionRepository
+	cancellationRunRepository repositories.CancellationRunRepository
+	strategy                  repositories.CancellationStrategy
+	logger                    *zerolog.Logger
 	sync.Mutex
-	counter int
 }
 
 // NewCancelTransactionInteractor creates a new CancelTransactionInteractor
-func NewCancelTransactionInteractor(transactionRepository repositories.TransactionRepository) *CancelTransactionInteractor {
+func NewCancelTransactionInteractor(transactionRepository repositories.TransactionRepository, cancellationRunRepository repositories.CancellationRunRepository, strategy repositories.CancellationStrategy) *CancelTransactionInteractor {
 	l := log.GetLogger()
 	return &CancelTransactionInteractor{
-		transactionRepository: transactionRepository,
-		logger:                &l,
+		transactionRepository:     transactionRepository,
+		cancellationRunRepository: cancellationRunRepository,
+		strategy:                  strategy,
+		logger:                    &l,
 	}
 }
 
-// Execute will cancel all odd transactions and update the balance
+// Execute will cancel the transactions selected by the strategy and update the balance.
+// Every execution is recorded as a cancellation run.
 func (c *CancelTransactionInteractor) Execute(ctx context.Context) error {
-	c.Lock() // needed to test and print the counter. Should be removed in production
+	c.Lock()
 	defer c.Unlock()
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	ctx, cancel := context.WithTimeout(context.Background(), cancelRunTimeout)
 	defer cancel()
-	ids, err := c.transactionRepository.CancelOddTransactionsAndUpdateBalance(ctx)
+
+	_, err := c.cancelTransactions(ctx)
+	return err
+}
+
+// Run executes the cancel process immediately and returns the run with the transactions it cancelled.
+// It never overlaps a scheduled execution, the run is only timed once a scheduled execution has finished.
+func (c *CancelTransactionInteractor) Run(ctx context.Context) (*dtos.CancellationRunResponse, error) {
+	c.Lock()
+	defer c.Unlock()
+	ctx, cancel := context.WithTimeout(ctx, cancelRunTimeout)
+	defer cancel()
+
+	runID, err := c.cancelTransactions(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	run, err := c.cancellationRunRepository.GetByID(ctx, runID)
+	if err != nil {
+		c.logger.Error().Err(err).Str("run", runID).Msg(errors.ErrFailedGetCancellationRun)
+		return nil, err
+	}
+	if run == nil {
+		return nil, errors.NewNotFoundError("Cancellation run not found")
+	}
+
+	items, err := c.cancellationRunRepository.ListItems(ctx, runID)
 	if err != nil {
-		c.logger.Error().Err(err).Msg(errors.ErrFailedCancelOddTransactions)
-		return err
+		c.logger.Error().Err(err).Str("run", runID).Msg(errors.ErrFailedGetCancellationRun)
+		return nil, err
+	}
+
+	response := dtos.NewCancellationRunResponse(run)
+	response.Items = dtos.NewCancellationResponses(items)
+
+	return &response, nil
+}
+
+const (
+	// cancelRunTimeout bounds a cancellation run from the moment it holds the lock.
+	cancelRunTimeout = 5 * time.Second
+	// finishRunTimeout bounds recording the outcome of a cancellation or reconciliation run.
+	finishRunTimeout = 5 * time.Second
+)
+
+// cancelTransactions records a cancellation run and cancels the transactions selected by the strategy.
+// The caller must hold the lock.
+func (c *CancelTransactionInteractor) cancelTransactions(ctx context.Context) (string, error) {
+	run, err := c.cancellationRunRepository.Create(ctx, c.strategy.Name())
+	if err != nil {
+		c.logger.Error().Err(err).Str("strategy", c.strategy.Name()).Msg(errors.ErrFailedCreateCancellationRun)
+		return "", err
+	}
+
+	ids, err := c.transactionRepository.CancelTransactionsAndUpdateBalance(ctx, c.strategy, run.ID)
+
+	// the run is finished even when ctx has expired, otherwise it would stay running forever
+	finishCtx, cancel := context.WithTimeout(context.Background(), finishRunTimeout)
+	defer cancel()
+	if finishErr := c.cancellationRunRepository.Finish(finishCtx, run.ID, len(ids), err); finishErr != nil {
+		c.logger.Error().Err(finishErr).Str("run", run.ID).Msg(errors.ErrFailedFinishCancellationRun)
+	}
+	if err != nil {
+		c.logger.Error().Err(err).Str("strategy", c.strategy.Name()).Str("run", run.ID).Msg(errors.ErrFailedCancelOddTransactions)
+		return run.ID, err
 	}
 
 	for _, id := range ids {
-		c.logger.Info().Msgf("Transaction canceled: %s", id)
+		c.logger.Info().Str("run", run.ID).Str("user", id.UserId).Str("currency", id.Currency.Code).Msgf("Transaction canceled: %s", id.TransactionId)
 	}
 
-	c.counter++
-	fmt.Println("Transactions canceled: ", len(ids), " | Total iterations: ", c.counter)
+	return run.ID, nil
+}
+
+// Preview returns what the next execution would cancel and skip, and the resulting user balances,
+// without changing any data.
+func (c *CancelTransactionInteractor) Preview(ctx context.Context) (*dtos.CancellationPreviewResponse, error) {
+	rows, err := c.transactionRepository.PreviewCancelTransactions(ctx, c.strategy)
+	if err != nil {
+		c.logger.Error().Err(err).Str("strategy", c.strategy.Name()).Msg(errors.ErrFailedPreviewCancellation)
+		return nil, err
+	}
 
-	return nil
+	return dtos.NewCancellationPreviewResponse(c.strategy.Name(), rows), nil
 }
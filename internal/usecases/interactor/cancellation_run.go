@@ -0,0 +1,84 @@
+package interactor
+
+import (
+	"context"
+	"github.com/google/uuid"
+	"github.com/mufasadev/enlabs-test/internal/domain/repositories"
+	apperrors "github.com/mufasadev/enlabs-test/internal/errors"
+	"github.com/mufasadev/enlabs-test/internal/usecases/dtos"
+	"github.com/mufasadev/enlabs-test/pkg/log"
+	"github.com/rs/zerolog"
+)
+
+type CancellationRunInteractor struct {
+	cancellationRunRepository repositories.CancellationRunRepository
+	logger                    *zerolog.Logger
+}
+
+func NewCancellationRunInteractor(cancellationRunRepository repositories.CancellationRunRepository) *CancellationRunInteractor {
+	l := log.GetLogger()
+	return &CancellationRunInteractor{
+		cancellationRunRepository: cancellationRunRepository,
+		logger:                    &l,
+	}
+}
+
+// ListRuns returns a page of cancellation runs, newest first.
+func (i *CancellationRunInteractor) ListRuns(ctx context.Context, query *dtos.PageQuery) (*dtos.CancellationRunPageResponse, error) {
+	limit := pageLimit(query.Limit)
+
+	var after *repositories.Cursor
+	if query.Cursor != "" {
+		cursor, err := decodeCursor(query.Cursor)
+		if err != nil {
+			return nil, apperrors.NewBadRequestError("Invalid cursor")
+		}
+		after = cursor
+	}
+
+	runs, err := i.cancellationRunRepository.List(ctx, after, limit+1)
+	if err != nil {
+		i.logger.Error().Err(err).Msg("Failed to list cancellation runs")
+		return nil, err
+	}
+
+	page := &dtos.CancellationRunPageResponse{Items: make([]dtos.CancellationRunResponse, 0, limit)}
+	if len(runs) > limit {
+		runs = runs[:limit]
+		last := runs[limit-1]
+		page.NextCursor = encodeCursor(&repositories.Cursor{CreatedAt: last.StartedAt, ID: last.ID})
+	}
+
+	for idx := range runs {
+		page.Items = append(page.Items, dtos.NewCancellationRunResponse(&runs[idx]))
+	}
+
+	return page, nil
+}
+
+// GetRun returns a cancellation run with the transactions it cancelled.
+func (i *CancellationRunInteractor) GetRun(ctx context.Context, runID string) (*dtos.CancellationRunResponse, error) {
+	if _, err := uuid.Parse(runID); err != nil {
+		return nil, apperrors.NewNotFoundError("Cancellation run not found")
+	}
+
+	run, err := i.cancellationRunRepository.GetByID(ctx, runID)
+	if err != nil {
+		i.logger.Error().Err(err).Msg("Failed to get cancellation run")
+		return nil, err
+	}
+	if run == nil {
+		return nil, apperrors.NewNotFoundError("Cancellation run not found")
+	}
+
+	items, err := i.cancellationRunRepository.ListItems(ctx, runID)
+	if err != nil {
+		i.logger.Error().Err(err).Msg("Failed to list cancellations")
+		return nil, err
+	}
+
+	response := dtos.NewCancellationRunResponse(run)
+	response.Items = dtos.NewCancellationResponses(items)
+
+	return &response, nil
+}
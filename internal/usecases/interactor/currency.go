@@ -0,0 +1,54 @@
+package interactor
+
+import (
+	"context"
+	"fmt"
+	"github.com/mufasadev/enlabs-test/internal/domain/models"
+	"github.com/mufasadev/enlabs-test/internal/domain/repositories"
+	apperrors "github.com/mufasadev/enlabs-test/internal/errors"
+	"github.com/mufasadev/enlabs-test/internal/usecases/dtos"
+	"github.com/mufasadev/enlabs-test/pkg/log"
+	"github.com/rs/zerolog"
+	"strings"
+)
+
+type CurrencyInteractor struct {
+	currencyRepository repositories.CurrencyRepository
+	logger             *zerolog.Logger
+}
+
+func NewCurrencyInteractor(currencyRepository repositories.CurrencyRepository) *CurrencyInteractor {
+	l := log.GetLogger()
+	return &CurrencyInteractor{
+		currencyRepository: currencyRepository,
+		logger:             &l,
+	}
+}
+
+// ListCurrencies returns the currencies of the registry.
+func (i *CurrencyInteractor) ListCurrencies(ctx context.Context) ([]dtos.CurrencyResponse, error) {
+	currencies, err := i.currencyRepository.List(ctx)
+	if err != nil {
+		i.logger.Error().Err(err).Msg(apperrors.ErrFailedListCurrencies)
+		return nil, err
+	}
+
+	return dtos.NewCurrencyResponses(currencies), nil
+}
+
+// resolveCurrency looks a currency code up in the registry. An empty code stands for the default currency.
+func resolveCurrency(ctx context.Context, currencyRepository repositories.CurrencyRepository, code string) (models.Currency, error) {
+	if code == "" {
+		return models.DefaultCurrency(), nil
+	}
+
+	currency, err := currencyRepository.GetByCode(ctx, strings.ToUpper(code))
+	if err != nil {
+		return models.Currency{}, err
+	}
+	if currency == nil {
+		return models.Currency{}, apperrors.NewBadRequestError(fmt.Sprintf("Unknown currency %s", code))
+	}
+
+	return *currency, nil
+}
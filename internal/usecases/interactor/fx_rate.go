@@ -0,0 +1,91 @@
+package interactor
+
+import (
+	"context"
+	"fmt"
+	"github.com/mufasadev/enlabs-test/internal/domain/models"
+	"github.com/mufasadev/enlabs-test/internal/domain/repositories"
+	apperrors "github.com/mufasadev/enlabs-test/internal/errors"
+	"github.com/mufasadev/enlabs-test/internal/usecases/dtos"
+	"github.com/mufasadev/enlabs-test/pkg/log"
+	"github.com/rs/zerolog"
+	"github.com/shopspring/decimal"
+	"time"
+)
+
+// maxRate is the exclusive upper bound of a rate, the rate column holds 16 integer digits.
+var maxRate = decimal.New(1, 16)
+
+type FxRateInteractor struct {
+	fxRateRepository   repositories.FxRateRepository
+	currencyRepository repositories.CurrencyRepository
+	logger             *zerolog.Logger
+}
+
+func NewFxRateInteractor(fxRateRepository repositories.FxRateRepository, currencyRepository repositories.CurrencyRepository) *FxRateInteractor {
+	l := log.GetLogger()
+	return &FxRateInteractor{
+		fxRateRepository:   fxRateRepository,
+		currencyRepository: currencyRepository,
+		logger:             &l,
+	}
+}
+
+// ListRates returns the rate of every currency pair valid now.
+func (i *FxRateInteractor) ListRates(ctx context.Context) ([]dtos.FxRateResponse, error) {
+	rates, err := i.fxRateRepository.ListCurrent(ctx)
+	if err != nil {
+		i.logger.Error().Err(err).Msg(apperrors.ErrFailedListFxRates)
+		return nil, err
+	}
+
+	responses := make([]dtos.FxRateResponse, 0, len(rates))
+	for idx := range rates {
+		responses = append(responses, dtos.NewFxRateResponse(&rates[idx]))
+	}
+	return responses, nil
+}
+
+// CreateRate stores a new rate of a currency pair. Earlier rates of the pair are kept for audit.
+func (i *FxRateInteractor) CreateRate(ctx context.Context, dto *dtos.FxRateDTO) (*dtos.FxRateResponse, error) {
+	if dto.BaseCurrency == "" || dto.QuoteCurrency == "" {
+		return nil, apperrors.NewBadRequestError("baseCurrency and quoteCurrency are required")
+	}
+	base, err := resolveCurrency(ctx, i.currencyRepository, dto.BaseCurrency)
+	if err != nil {
+		return nil, err
+	}
+	quote, err := resolveCurrency(ctx, i.currencyRepository, dto.QuoteCurrency)
+	if err != nil {
+		return nil, err
+	}
+	if base.Code == quote.Code {
+		return nil, apperrors.NewBadRequestError("baseCurrency and quoteCurrency must differ")
+	}
+
+	rate, err := decimal.NewFromString(dto.Rate)
+	if err != nil {
+		return nil, apperrors.NewBadRequestError("Invalid rate")
+	}
+	if !rate.IsPositive() || !rate.Equal(rate.Round(models.MaxRateScale)) || rate.GreaterThanOrEqual(maxRate) {
+		return nil, apperrors.NewBadRequestError(fmt.Sprintf("Rate must be positive, below %s and have at most %d decimal places", maxRate, models.MaxRateScale))
+	}
+
+	fxRate := &models.FxRate{
+		BaseCurrency:  base.Code,
+		QuoteCurrency: quote.Code,
+		Rate:          rate,
+		ValidFrom:     time.Now(),
+	}
+	if dto.ValidFrom != nil {
+		fxRate.ValidFrom = *dto.ValidFrom
+	}
+
+	if err = i.fxRateRepository.Create(ctx, fxRate); err != nil {
+		i.logger.Error().Err(err).Msg(apperrors.ErrFailedCreateFxRate)
+		return nil, err
+	}
+
+	response := dtos.NewFxRateResponse(fxRate)
+	return &response, nil
+}
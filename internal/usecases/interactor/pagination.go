@@ -0,0 +1,56 @@
+package interactor
+
+import (
+	"encoding/base64"
+	"fmt"
+	"github.com/google/uuid"
+	"github.com/mufasadev/enlabs-test/internal/domain/repositories"
+	"strings"
+	"time"
+)
+
+const (
+	defaultPageLimit = 50
+	maxPageLimit     = 100
+)
+
+// pageLimit returns the requested page size bounded by the default and maximum page size.
+func pageLimit(limit int) int {
+	if limit <= 0 {
+		return defaultPageLimit
+	}
+	if limit > maxPageLimit {
+		return maxPageLimit
+	}
+	return limit
+}
+
+// encodeCursor encodes the position of the last returned row into an opaque string.
+func encodeCursor(c *repositories.Cursor) string {
+	raw := c.CreatedAt.UTC().Format(time.RFC3339Nano) + "|" + c.ID
+	return base64.RawURLEncoding.EncodeToString([]byte(raw))
+}
+
+// decodeCursor reverses encodeCursor.
+func decodeCursor(s string) (*repositories.Cursor, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, err
+	}
+
+	parts := strings.SplitN(string(raw), "|", 2)
+	if len(parts) != 2 {
+		return nil, fmt.Errorf("malformed cursor")
+	}
+
+	createdAt, err := time.Parse(time.RFC3339Nano, parts[0])
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err = uuid.Parse(parts[1]); err != nil {
+		return nil, err
+	}
+
+	return &repositories.Cursor{CreatedAt: createdAt, ID: parts[1]}, nil
+}
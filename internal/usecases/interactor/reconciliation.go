@@ -0,0 +1,136 @@
+package interactor
+
+import (
+	"context"
+	"github.com/google/uuid"
+	"github.com/mufasadev/enlabs-test/internal/domain/repositories"
+	"github.com/mufasadev/enlabs-test/internal/errors"
+	"github.com/mufasadev/enlabs-test/internal/usecases/dtos"
+	"github.com/mufasadev/enlabs-test/pkg/log"
+	"github.com/rs/zerolog"
+	"sync"
+	"time"
+)
+
+type ReconciliationInteractor struct {
+	reconciliationRepository repositories.ReconciliationRepository
+	logger                   *zerolog.Logger
+	sync.Mutex
+}
+
+func NewReconciliationInteractor(reconciliationRepository repositories.ReconciliationRepository) *ReconciliationInteractor {
+	l := log.GetLogger()
+	return &ReconciliationInteractor{
+		reconciliationRepository: reconciliationRepository,
+		logger:                   &l,
+	}
+}
+
+// Execute compares every wallet balance with the balance expected from the transactions and records the drifts.
+func (i *ReconciliationInteractor) Execute(ctx context.Context) error {
+	ctx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+
+	_, err := i.Run(ctx)
+	return err
+}
+
+// Run reconciles the balances immediately and returns the run with the drifts it found.
+// It never overlaps a scheduled execution.
+func (i *ReconciliationInteractor) Run(ctx context.Context) (*dtos.ReconciliationRunResponse, error) {
+	i.Lock()
+	defer i.Unlock()
+
+	run, err := i.reconciliationRepository.Create(ctx)
+	if err != nil {
+		i.logger.Error().Err(err).Msg(errors.ErrFailedCreateReconciliationRun)
+		return nil, err
+	}
+
+	walletsChecked, drifts, err := i.reconciliationRepository.Reconcile(ctx, run.ID)
+
+	// the run is finished even when ctx has expired, otherwise it would stay running forever
+	finishCtx, cancel := context.WithTimeout(context.Background(), finishRunTimeout)
+	defer cancel()
+	if finishErr := i.reconciliationRepository.Finish(finishCtx, run.ID, walletsChecked, len(drifts), err); finishErr != nil {
+		i.logger.Error().Err(finishErr).Str("run", run.ID).Msg(errors.ErrFailedFinishReconciliationRun)
+	}
+	if err != nil {
+		i.logger.Error().Err(err).Str("run", run.ID).Msg(errors.ErrFailedReconcileBalances)
+		return nil, err
+	}
+
+	for _, d := range drifts {
+		i.logger.Warn().
+			Str("run", run.ID).
+			Str("user", d.UserID).
+			Str("currency", d.Currency.Code).
+			Str("expected", d.Currency.Format(d.ExpectedBalance)).
+			Str("actual", d.Currency.Format(d.ActualBalance)).
+			Str("ledger", d.Currency.Format(d.LedgerBalance)).
+			Msg("Balance drift detected")
+	}
+	i.logger.Info().Str("run", run.ID).Msgf("Balances reconciled: %d wallets, %d drifted", walletsChecked, len(drifts))
+
+	return i.GetRun(ctx, run.ID)
+}
+
+// ListRuns returns a page of reconciliation runs, newest first.
+func (i *ReconciliationInteractor) ListRuns(ctx context.Context, query *dtos.PageQuery) (*dtos.ReconciliationRunPageResponse, error) {
+	limit := pageLimit(query.Limit)
+
+	var after *repositories.Cursor
+	if query.Cursor != "" {
+		cursor, err := decodeCursor(query.Cursor)
+		if err != nil {
+			return nil, errors.NewBadRequestError("Invalid cursor")
+		}
+		after = cursor
+	}
+
+	runs, err := i.reconciliationRepository.List(ctx, after, limit+1)
+	if err != nil {
+		i.logger.Error().Err(err).Msg(errors.ErrFailedListReconciliationRuns)
+		return nil, err
+	}
+
+	page := &dtos.ReconciliationRunPageResponse{Items: make([]dtos.ReconciliationRunResponse, 0, limit)}
+	if len(runs) > limit {
+		runs = runs[:limit]
+		last := runs[limit-1]
+		page.NextCursor = encodeCursor(&repositories.Cursor{CreatedAt: last.StartedAt, ID: last.ID})
+	}
+
+	for idx := range runs {
+		page.Items = append(page.Items, dtos.NewReconciliationRunResponse(&runs[idx]))
+	}
+
+	return page, nil
+}
+
+// GetRun returns a reconciliation run with the drifts it found.
+func (i *ReconciliationInteractor) GetRun(ctx context.Context, runID string) (*dtos.ReconciliationRunResponse, error) {
+	if _, err := uuid.Parse(runID); err != nil {
+		return nil, errors.NewNotFoundError("Reconciliation run not found")
+	}
+
+	run, err := i.reconciliationRepository.GetByID(ctx, runID)
+	if err != nil {
+		i.logger.Error().Err(err).Msg(errors.ErrFailedGetReconciliationRun)
+		return nil, err
+	}
+	if run == nil {
+		return nil, errors.NewNotFoundError("Reconciliation run not found")
+	}
+
+	drifts, err := i.reconciliationRepository.ListDrifts(ctx, runID)
+	if err != nil {
+		i.logger.Error().Err(err).Msg(errors.ErrFailedGetReconciliationRun)
+		return nil, err
+	}
+
+	response := dtos.NewReconciliationRunResponse(run)
+	response.Drifts = dtos.NewBalanceDriftResponses(drifts)
+
+	return &response, nil
+}
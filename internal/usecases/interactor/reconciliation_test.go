@@ -0,0 +1,45 @@
+package interactor
+
+import (
+	"context"
+	"github.com/mufasadev/enlabs-test/internal/domain/models"
+	"github.com/mufasadev/enlabs-test/internal/domain/repositories"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+// fakeReconciliationRepository fails every reconciliation with the error of its context and records the finish.
+type fakeReconciliationRepository struct {
+	repositories.ReconciliationRepository
+	finished  bool
+	finishErr error
+}
+
+func (r *fakeReconciliationRepository) Create(_ context.Context) (*models.ReconciliationRun, error) {
+	return &models.ReconciliationRun{ID: "f0e3ad52-3b3c-4f6f-a1b0-3c7f2f6f0a11"}, nil
+}
+
+func (r *fakeReconciliationRepository) Reconcile(ctx context.Context, _ string) (int, []models.BalanceDrift, error) {
+	<-ctx.Done()
+	return 0, nil, ctx.Err()
+}
+
+func (r *fakeReconciliationRepository) Finish(ctx context.Context, _ string, _ int, _ int, runErr error) error {
+	r.finished = ctx.Err() == nil
+	r.finishErr = runErr
+	return ctx.Err()
+}
+
+func TestReconciliationFinishesTimedOutRuns(t *testing.T) {
+	repository := &fakeReconciliationRepository{}
+	i := NewReconciliationInteractor(repository)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err := i.Run(ctx)
+	require.ErrorIs(t, err, context.Canceled)
+
+	assert.True(t, repository.finished, "A run must be finished with a live context")
+	assert.ErrorIs(t, repository.finishErr, context.Canceled)
+}
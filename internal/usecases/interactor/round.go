@@ -0,0 +1,104 @@
+package interactor
+
+import (
+	"context"
+	"github.com/mufasadev/enlabs-test/internal/domain/repositories"
+	apperrors "github.com/mufasadev/enlabs-test/internal/errors"
+	"github.com/mufasadev/enlabs-test/internal/usecases/dtos"
+	"github.com/mufasadev/enlabs-test/pkg/log"
+	"github.com/rs/zerolog"
+	"strings"
+)
+
+type RoundInteractor struct {
+	roundRepository repositories.RoundRepository
+	logger          *zerolog.Logger
+}
+
+func NewRoundInteractor(roundRepository repositories.RoundRepository) *RoundInteractor {
+	l := log.GetLogger()
+	return &RoundInteractor{
+		roundRepository: roundRepository,
+		logger:          &l,
+	}
+}
+
+// GetRound returns the status of a round of the user.
+func (i *RoundInteractor) GetRound(ctx context.Context, userID string, roundID string) (*dtos.RoundResponse, error) {
+	if err := checkUserID(userID); err != nil {
+		return nil, err
+	}
+
+	round, err := i.roundRepository.GetByRoundID(ctx, userID, roundID)
+	if err != nil {
+		i.logger.Error().Err(err).Msg(apperrors.ErrFailedGetRound)
+		return nil, err
+	}
+	if round == nil {
+		return nil, apperrors.NewNotFoundError("Round not found")
+	}
+
+	return dtos.NewRoundResponse(round), nil
+}
+
+// CloseRound settles a round of the user played with the source.
+func (i *RoundInteractor) CloseRound(ctx context.Context, userID string, sourceType string, roundID string) (*dtos.RoundResponse, error) {
+	if err := i.checkSource(ctx, userID, sourceType, roundID); err != nil {
+		return nil, err
+	}
+
+	round, err := i.roundRepository.Close(ctx, userID, roundID)
+	if err != nil {
+		i.logger.Error().Err(err).Msg(apperrors.ErrFailedCloseRound)
+		return nil, err
+	}
+
+	return dtos.NewRoundResponse(round), nil
+}
+
+// RollbackRound reverses every applied transaction of a round of the user played with the source.
+func (i *RoundInteractor) RollbackRound(ctx context.Context, userID string, sourceType string, roundID string) (*dtos.RoundResponse, error) {
+	if err := i.checkSource(ctx, userID, sourceType, roundID); err != nil {
+		return nil, err
+	}
+
+	round, rows, err := i.roundRepository.Rollback(ctx, userID, roundID)
+	if err != nil {
+		i.logger.Error().Err(err).Msg(apperrors.ErrFailedRollbackRound)
+		return nil, err
+	}
+
+	response := dtos.NewRoundResponse(round)
+	response.RolledBackCount = len(rows)
+	if len(rows) > 0 {
+		balance := round.Currency.Format(rows[0].UserBalance)
+		response.Balance = &balance
+	}
+
+	i.logger.Info().
+		Str("user_id", userID).
+		Str("round_id", roundID).
+		Int("rolled_back", len(rows)).
+		Msg("Round rolled back")
+
+	return response, nil
+}
+
+// checkSource makes sure the round exists and is played with the source. A round of another source is reported
+// as not found.
+func (i *RoundInteractor) checkSource(ctx context.Context, userID string, sourceType string, roundID string) error {
+	if err := checkUserID(userID); err != nil {
+		return err
+	}
+
+	round, err := i.roundRepository.GetByRoundID(ctx, userID, roundID)
+	if err != nil {
+		i.logger.Error().Err(err).Msg(apperrors.ErrFailedGetRound)
+		return err
+	}
+	if round == nil || !strings.EqualFold(round.SourceType.Name, sourceType) {
+		return apperrors.NewNotFoundError("Round not found")
+	}
+
+	return nil
+}
@@ -0,0 +1,32 @@
+package interactor
+
+import (
+	"context"
+	"github.com/mufasadev/enlabs-test/internal/domain/models"
+	"github.com/mufasadev/enlabs-test/internal/domain/repositories"
+	apperrors "github.com/mufasadev/enlabs-test/internal/errors"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+// fakeRoundRepository has no rounds and fails the test when it is queried.
+type fakeRoundRepository struct {
+	repositories.RoundRepository
+	t *testing.T
+}
+
+func (r *fakeRoundRepository) GetByRoundID(_ context.Context, userID string, _ string) (*models.Round, error) {
+	r.t.Errorf("GetByRoundID called with user id %q", userID)
+	return nil, nil
+}
+
+func TestRoundsCheckTheUserID(t *testing.T) {
+	i := NewRoundInteractor(&fakeRoundRepository{t: t})
+
+	_, err := i.GetRound(context.Background(), "not-a-uuid", "r-1")
+	assert.True(t, apperrors.As(err, new(*apperrors.BadRequestError)))
+	_, err = i.CloseRound(context.Background(), "not-a-uuid", testSourceName, "r-1")
+	assert.True(t, apperrors.As(err, new(*apperrors.BadRequestError)))
+	_, err = i.RollbackRound(context.Background(), "not-a-uuid", testSourceName, "r-1")
+	assert.True(t, apperrors.As(err, new(*apperrors.BadRequestError)))
+}
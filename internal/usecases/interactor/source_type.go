@@ -2,21 +2,213 @@ package interactor
 
 import (
 	"context"
+	"fmt"
+	"github.com/google/uuid"
+	"github.com/mufasadev/enlabs-test/internal/domain/models"
 	"github.com/mufasadev/enlabs-test/internal/domain/repositories"
+	apperrors "github.com/mufasadev/enlabs-test/internal/errors"
+	"github.com/mufasadev/enlabs-test/internal/usecases/dtos"
+	"github.com/mufasadev/enlabs-test/pkg/log"
+	"github.com/rs/zerolog"
+	"github.com/shopspring/decimal"
+	"strings"
 )
 
 type SourceTypeInteractor struct {
 	sourceTypeRepository repositories.SourceTypeRepository
+	logger               *zerolog.Logger
 }
 
 func NewSourceTypeInteractor(Repository repositories.SourceTypeRepository) *SourceTypeInteractor {
-	return &SourceTypeInteractor{sourceTypeRepository: Repository}
+	l := log.GetLogger()
+	return &SourceTypeInteractor{sourceTypeRepository: Repository, logger: &l}
 }
 
-func (s *SourceTypeInteractor) ExistsByName(ctx context.Context, name string) (bool, error) {
-	_, err := s.sourceTypeRepository.GetByName(ctx, name)
+// CheckEnabled returns an error unless the source exists and is enabled.
+func (s *SourceTypeInteractor) CheckEnabled(ctx context.Context, name string) error {
+	source, err := s.sourceTypeRepository.GetByName(ctx, name)
 	if err != nil {
-		return false, err
+		return apperrors.NewBadRequestError(apperrors.ErrInvalidSourceType)
 	}
-	return true, nil
+	if !source.Enabled {
+		return apperrors.NewSourceDisabledError(source.Name)
+	}
+	return nil
+}
+
+// ListSources returns all sources.
+func (s *SourceTypeInteractor) ListSources(ctx context.Context) ([]dtos.SourceResponse, error) {
+	sources, err := s.sourceTypeRepository.List(ctx)
+	if err != nil {
+		s.logger.Error().Err(err).Msg("Failed to list sources")
+		return nil, err
+	}
+
+	responses := make([]dtos.SourceResponse, 0, len(sources))
+	for idx := range sources {
+		responses = append(responses, dtos.NewSourceResponse(&sources[idx]))
+	}
+
+	return responses, nil
+}
+
+// GetSource returns a source by id.
+func (s *SourceTypeInteractor) GetSource(ctx context.Context, id string) (*dtos.SourceResponse, error) {
+	source, err := s.getSource(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	response := dtos.NewSourceResponse(source)
+	return &response, nil
+}
+
+// CreateSource creates a source. New sources are enabled unless the request says otherwise.
+func (s *SourceTypeInteractor) CreateSource(ctx context.Context, dto *dtos.SourceDTO) (*dtos.SourceResponse, error) {
+	name := strings.ToLower(dto.Name)
+	if !models.SourceNamePattern.MatchString(name) {
+		return nil, apperrors.NewBadRequestError("Invalid name")
+	}
+
+	source := &models.SourceType{
+		Name:        name,
+		DisplayName: name,
+		Enabled:     true,
+		Settings:    map[string]interface{}{},
+		States:      append([]string(nil), models.DefaultSourceStates...),
+	}
+	if err := applySourceDTO(source, dto); err != nil {
+		return nil, err
+	}
+
+	if err := s.sourceTypeRepository.Create(ctx, source); err != nil {
+		s.logger.Error().Err(err).Msg("Failed to create source")
+		return nil, err
+	}
+
+	response := dtos.NewSourceResponse(source)
+	return &response, nil
+}
+
+// UpdateSource changes the display name, enabled flag, settings, limits or states of a source.
+func (s *SourceTypeInteractor) UpdateSource(ctx context.Context, id string, dto *dtos.SourceDTO) (*dtos.SourceResponse, error) {
+	source, err := s.getSource(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if source.ID == models.TransferSourceID {
+		return nil, apperrors.NewConflictError("The transfer source is reserved")
+	}
+	if dto.Name != "" && strings.ToLower(dto.Name) != source.Name {
+		return nil, apperrors.NewBadRequestError("The name of a source cannot be changed")
+	}
+	if err = applySourceDTO(source, dto); err != nil {
+		return nil, err
+	}
+
+	if err = s.sourceTypeRepository.Update(ctx, source); err != nil {
+		s.logger.Error().Err(err).Msg("Failed to update source")
+		return nil, err
+	}
+
+	response := dtos.NewSourceResponse(source)
+	return &response, nil
+}
+
+// DeleteSource deletes a source without transactions.
+func (s *SourceTypeInteractor) DeleteSource(ctx context.Context, id string) error {
+	if _, err := uuid.Parse(id); err != nil {
+		return apperrors.NewNotFoundError("Source not found")
+	}
+	if id == models.TransferSourceID {
+		return apperrors.NewConflictError("The transfer source is reserved")
+	}
+
+	return s.sourceTypeRepository.Delete(ctx, id)
+}
+
+func (s *SourceTypeInteractor) getSource(ctx context.Context, id string) (*models.SourceType, error) {
+	if _, err := uuid.Parse(id); err != nil {
+		return nil, apperrors.NewNotFoundError("Source not found")
+	}
+
+	source, err := s.sourceTypeRepository.GetByID(ctx, id)
+	if err != nil {
+		s.logger.Error().Err(err).Msg("Failed to get source")
+		return nil, err
+	}
+	if source == nil {
+		return nil, apperrors.NewNotFoundError("Source not found")
+	}
+
+	return source, nil
+}
+
+// applySourceDTO copies the fields set in the request to the source.
+func applySourceDTO(source *models.SourceType, dto *dtos.SourceDTO) error {
+	if dto.DisplayName != nil {
+		source.DisplayName = *dto.DisplayName
+	}
+	if dto.Enabled != nil {
+		source.Enabled = *dto.Enabled
+	}
+	if dto.Settings != nil {
+		source.Settings = dto.Settings
+	}
+	if dto.Limits != nil {
+		limits := models.SourceLimits{}
+		var err error
+		if limits.MaxAmount, err = parseLimit(dto.Limits.MaxAmount); err != nil {
+			return apperrors.NewBadRequestError("Invalid maxAmount")
+		}
+		if limits.MaxDailyWin, err = parseLimit(dto.Limits.MaxDailyWin); err != nil {
+			return apperrors.NewBadRequestError("Invalid maxDailyWin")
+		}
+		if limits.MaxDailyLoss, err = parseLimit(dto.Limits.MaxDailyLoss); err != nil {
+			return apperrors.NewBadRequestError("Invalid maxDailyLoss")
+		}
+		source.Limits = limits
+	}
+	if dto.States != nil {
+		states := make([]string, 0, len(dto.States))
+		for _, state := range dto.States {
+			if _, ok := models.GetTransactionKind(state); !ok {
+				return apperrors.NewBadRequestError(fmt.Sprintf("Invalid state %s", state))
+			}
+			if !containsString(states, state) {
+				states = append(states, state)
+			}
+		}
+		source.States = states
+	}
+	return nil
+}
+
+// containsString reports whether values holds value.
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+// parseLimit parses an optional positive limit.
+func parseLimit(value *string) (*decimal.Decimal, error) {
+	if value == nil {
+		return nil, nil
+	}
+
+	limit, err := decimal.NewFromString(*value)
+	if err != nil {
+		return nil, err
+	}
+	scale := models.DefaultCurrency().Scale
+	if !limit.IsPositive() || !limit.Equal(limit.Round(scale)) {
+		return nil, fmt.Errorf("limit must be positive with at most %d decimals", scale)
+	}
+
+	return &limit, nil
 }
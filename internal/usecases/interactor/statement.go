@@ -0,0 +1,103 @@
+package interactor
+
+import (
+	"context"
+	"github.com/mufasadev/enlabs-test/internal/domain/models"
+	"github.com/mufasadev/enlabs-test/internal/domain/repositories"
+	apperrors "github.com/mufasadev/enlabs-test/internal/errors"
+	"github.com/mufasadev/enlabs-test/internal/usecases/dtos"
+	"github.com/mufasadev/enlabs-test/pkg/log"
+	"github.com/rs/zerolog"
+	"github.com/shopspring/decimal"
+	"time"
+)
+
+// StatementWriter writes an account statement while it is read from the database.
+type StatementWriter interface {
+	Begin(summary *dtos.StatementSummary) error
+	WriteLine(line *dtos.StatementLine) error
+	End(summary *dtos.StatementSummary) error
+}
+
+type StatementInteractor struct {
+	ledgerRepository   repositories.LedgerRepository
+	currencyRepository repositories.CurrencyRepository
+	logger             *zerolog.Logger
+}
+
+func NewStatementInteractor(ledgerRepository repositories.LedgerRepository, currencyRepository repositories.CurrencyRepository) *StatementInteractor {
+	l := log.GetLogger()
+	return &StatementInteractor{
+		ledgerRepository:   ledgerRepository,
+		currencyRepository: currencyRepository,
+		logger:             &l,
+	}
+}
+
+// WriteStatement writes every change of the user's balance in the currency in the period with the running
+// balance. Cancellations are separate lines. Without from the statement starts at the first entry, without to it
+// ends now. Without a currency it covers the default currency.
+func (i *StatementInteractor) WriteStatement(ctx context.Context, userID string, query *dtos.StatementQuery, w StatementWriter) error {
+	currency, err := resolveCurrency(ctx, i.currencyRepository, query.Currency)
+	if err != nil {
+		return err
+	}
+
+	summary := &dtos.StatementSummary{UserID: userID, Currency: currency.Code, From: query.From, To: time.Now().UTC()}
+	if query.To != nil {
+		summary.To = *query.To
+	}
+
+	var from time.Time
+	if query.From != nil {
+		from = *query.From
+	}
+	if from.After(summary.To) {
+		return apperrors.NewBadRequestError("from must not be after to")
+	}
+
+	var balance decimal.Decimal
+	err = i.ledgerRepository.StreamUserStatement(
+		ctx,
+		userID,
+		currency.Code,
+		from,
+		summary.To,
+		func(opening decimal.Decimal) error {
+			balance = opening
+			summary.OpeningBalance = currency.Format(opening)
+			return w.Begin(summary)
+		},
+		func(l *models.StatementLine) error {
+			balance = balance.Add(l.Amount)
+			return w.WriteLine(newStatementLine(l, balance, currency))
+		},
+	)
+	if err != nil {
+		i.logger.Error().Err(err).Str("user", userID).Msg(apperrors.ErrFailedWriteStatement)
+		return err
+	}
+
+	summary.ClosingBalance = currency.Format(balance)
+	return w.End(summary)
+}
+
+// newStatementLine maps a ledger line to a statement line with the balance right after it.
+func newStatementLine(l *models.StatementLine, balance decimal.Decimal, currency models.Currency) *dtos.StatementLine {
+	line := &dtos.StatementLine{
+		Date:    l.CreatedAt,
+		Type:    l.EntryType,
+		Amount:  currency.Format(l.Amount),
+		Balance: currency.Format(balance),
+	}
+	if l.TransactionID != nil {
+		line.TransactionID = *l.TransactionID
+	}
+	if l.State != nil {
+		line.State = *l.State
+	}
+	if l.Source != nil {
+		line.Source = *l.Source
+	}
+	return line
+}
@@ -1,7 +1,9 @@
 package interactor
 
 import (
+	"bytes"
 	"context"
+	"encoding/json"
 	"fmt"
 	"github.com/mufasadev/enlabs-test/internal/domain/models"
 	"github.com/mufasadev/enlabs-test/internal/domain/repositories"
@@ -17,76 +19,519 @@ type TransactionInteractor struct {
 	transactionRepository repositories.TransactionRepository
 	userRepository        repositories.UserRepository
 	sourceType            repositories.SourceTypeRepository
+	currencyRepository    repositories.CurrencyRepository
+	rateProvider          repositories.RateProvider
 	logger                *zerolog.Logger
 }
 
-func NewTransactionInteractor(transactionRepository repositories.TransactionRepository, userRepository repositories.UserRepository, sourceType repositories.SourceTypeRepository) *TransactionInteractor {
+func NewTransactionInteractor(transactionRepository repositories.TransactionRepository, userRepository repositories.UserRepository, sourceType repositories.SourceTypeRepository, currencyRepository repositories.CurrencyRepository, rateProvider repositories.RateProvider) *TransactionInteractor {
 	l := log.GetLogger()
 	return &TransactionInteractor{
 		transactionRepository: transactionRepository,
 		userRepository:        userRepository,
 		sourceType:            sourceType,
+		currencyRepository:    currencyRepository,
+		rateProvider:          rateProvider,
 		logger:                &l,
 	}
 }
 
-func (i *TransactionInteractor) ProcessTransaction(userID string, sourceType string, dto *dtos.TransactionDTO) (*repositories.TransactionRow, error) {
+// ProcessTransaction applies a transaction to the user's wallet in the wallet currency, converting the amount when
+// it is sent in another one. A transaction id that was already processed with the same payload is replayed: the
+// stored result is returned and replayed is true.
+func (i *TransactionInteractor) ProcessTransaction(userID string, sourceType string, dto *dtos.TransactionDTO) (row *repositories.TransactionRow, replayed bool, err error) {
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
 
-	user, err := i.userRepository.GetByID(ctx, userID)
+	user, source, err := i.getUserAndSource(ctx, userID, sourceType)
 	if err != nil {
-		i.logger.Error().Err(err).Msg("Failed to get user")
-		return nil, apperrors.NewBadRequestError("Invalid user ID")
+		return nil, false, err
+	}
+
+	transaction, err := i.newTransaction(ctx, user, source, dto)
+	if err != nil {
+		return nil, false, err
 	}
 
 	// check if transaction exists TODO: add caching
-	tx, err := i.transactionRepository.GetByTransactionID(ctx, dto.TransactionID)
+	stored, err := i.transactionRepository.GetByTransactionID(ctx, dto.TransactionID)
 	if err != nil {
-		return nil, err
+		return nil, false, err
+	}
+
+	if stored != nil {
+		return i.replayTransaction(stored, transaction)
 	}
 
-	if tx != nil {
-		return nil, apperrors.NewTransactionDuplicateError()
+	data, err := i.transactionRepository.InsertTransactionAndUpdateUserBalanceWithCreatingTransaction(ctx, transaction)
+	if data.Status != "" {
+		if transitionErr := transaction.TransitionTo(data.Status); transitionErr != nil {
+			i.logger.Error().Err(transitionErr).Msg("Unexpected transaction status")
+			return nil, false, transitionErr
+		}
+	}
+	if apperrors.As(err, new(*apperrors.TransactionDuplicateError)) {
+		// a concurrent request with the same transaction id won the race
+		stored, err = i.transactionRepository.GetByTransactionID(ctx, dto.TransactionID)
+		if err != nil {
+			return nil, false, err
+		}
+		if stored == nil {
+			return nil, false, apperrors.NewTransactionDuplicateError()
+		}
+		return i.replayTransaction(stored, transaction)
+	}
+	if err != nil {
+		return nil, false, err
+	}
+
+	return &data, false, nil
+}
+
+// getUserAndSource loads the user and the enabled source a transaction is sent for.
+func (i *TransactionInteractor) getUserAndSource(ctx context.Context, userID string, sourceType string) (*models.User, *models.SourceType, error) {
+	user, err := i.userRepository.GetByID(ctx, userID)
+	if err != nil {
+		i.logger.Error().Err(err).Msg("Failed to get user")
+		return nil, nil, apperrors.NewBadRequestError("Invalid user ID")
 	}
 
 	// check if source type exists
 	source, err := i.sourceType.GetByName(ctx, sourceType)
 	if err != nil {
 		i.logger.Error().Err(err).Msg("Failed to get source type")
-		return nil, apperrors.NewBadRequestError("Invalid source type")
+		return nil, nil, apperrors.NewBadRequestError("Invalid source type")
 	}
 
-	if _, ok := models.ValidStates[dto.State]; !ok {
-		i.logger.Error().Err(err).Msg("Invalid state")
+	if source == nil {
+		return nil, nil, apperrors.NewBadRequestError("Invalid source type")
+	}
+
+	if !source.Enabled {
+		return nil, nil, apperrors.NewSourceDisabledError(source.Name)
+	}
+
+	return user, source, nil
+}
+
+// newTransaction validates the state, currencies and amount of a request and builds the pending transaction. An
+// amount sent in another currency than the wallet is converted with the current rate of the pair.
+func (i *TransactionInteractor) newTransaction(ctx context.Context, user *models.User, source *models.SourceType, dto *dtos.TransactionDTO) (*models.Transaction, error) {
+	kind, ok := models.GetTransactionKind(dto.State)
+	if !ok {
 		return nil, apperrors.NewBadRequestError("Invalid state")
 	}
+	if !source.AllowsState(kind.State) {
+		return nil, apperrors.NewBadRequestError(fmt.Sprintf("State %s is not allowed for source %s", kind.State, source.Name))
+	}
 
-	if source == nil {
-		return nil, apperrors.NewBadRequestError("Invalid source type")
+	currency, err := resolveCurrency(ctx, i.currencyRepository, dto.Currency)
+	if err != nil {
+		return nil, err
 	}
 
-	var amount decimal.Decimal
-	fmt.Println(dto.Amount)
-	if amount, err = decimal.NewFromString(dto.Amount); err != nil {
-		i.logger.Error().Err(err).Msg("Failed to parse amount")
-		return nil, apperrors.NewBadRequestError("Invalid amount")
+	amount, err := models.ParseMoney(dto.Amount, currency)
+	if err != nil {
+		return nil, err
+	}
+
+	if err = validateMetadata(dto.Metadata); err != nil {
+		return nil, err
+	}
+	if dto.RoundID != "" && kind.State != models.StateLost && kind.State != models.StateWin {
+		return nil, apperrors.NewBadRequestError("Only lost and win transactions belong to a round")
+	}
+	if len(dto.RoundID) > maxRoundIDLength {
+		return nil, apperrors.NewBadRequestError(fmt.Sprintf("roundId must be at most %d characters", maxRoundIDLength))
 	}
 
 	transaction := &models.Transaction{
 		TransactionID: dto.TransactionID,
 		State:         dto.State,
-		Amount:        amount.Abs().Round(2),
+		Amount:        amount,
+		Metadata:      dto.Metadata,
+		RoundID:       dto.RoundID,
 		SourceType:    *source,
 		User:          *user,
-		Processed:     false,
+		Status:        models.StatusPending,
 	}
 
-	data, err := i.transactionRepository.InsertTransactionAndUpdateUserBalanceWithCreatingTransaction(ctx, transaction)
+	if dto.WalletCurrency != "" {
+		walletCurrency, err := resolveCurrency(ctx, i.currencyRepository, dto.WalletCurrency)
+		if err != nil {
+			return nil, err
+		}
+		if walletCurrency.Code != currency.Code {
+			if err = i.convert(ctx, transaction, walletCurrency); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	if err = i.setLimitAmount(ctx, transaction); err != nil {
+		return nil, err
+	}
+
+	return transaction, nil
+}
+
+// setLimitAmount converts the amount of a transaction in another currency than the default one to the default
+// currency, which the limits and daily volumes of the source are in. A transaction of a limited source is rejected
+// when the amount cannot be converted, one of an unlimited source is applied without counting towards the volume.
+func (i *TransactionInteractor) setLimitAmount(ctx context.Context, transaction *models.Transaction) error {
+	defaultCurrency := models.DefaultCurrency()
+	if transaction.Amount.Currency.Code == defaultCurrency.Code {
+		return nil
+	}
+
+	// an amount sent in the default currency is checked as sent
+	if original := transaction.OriginalAmount; original != nil && original.Currency.Code == defaultCurrency.Code {
+		transaction.LimitAmount = original
+		return nil
+	}
+
+	limited := transaction.SourceType.Limits.IsSet()
+	rate, err := i.rateProvider.GetRate(ctx, transaction.Amount.Currency.Code, defaultCurrency.Code, time.Now())
 	if err != nil {
+		i.logger.Error().Err(err).Msg("Failed to get exchange rate")
+		if limited {
+			return err
+		}
+		return nil
+	}
+	if rate == nil {
+		if limited {
+			return apperrors.NewBadRequestError(fmt.Sprintf("No exchange rate from %s to %s to check the source limits", transaction.Amount.Currency.Code, defaultCurrency.Code))
+		}
+		return nil
+	}
+
+	amount := rate.Convert(transaction.Amount.Decimal, defaultCurrency)
+	transaction.LimitAmount = &amount
+	return nil
+}
+
+// convert converts the amount of the transaction to the wallet currency and keeps the amount as sent and the rate.
+func (i *TransactionInteractor) convert(ctx context.Context, transaction *models.Transaction, walletCurrency models.Currency) error {
+	original := transaction.Amount
+	rate, err := i.rateProvider.GetRate(ctx, original.Currency.Code, walletCurrency.Code, time.Now())
+	if err != nil {
+		i.logger.Error().Err(err).Msg("Failed to get exchange rate")
+		return err
+	}
+	if rate == nil {
+		return apperrors.NewBadRequestError(fmt.Sprintf("No exchange rate from %s to %s", original.Currency.Code, walletCurrency.Code))
+	}
+
+	amount := rate.Convert(original.Decimal, walletCurrency)
+	if !amount.IsPositive() {
+		return apperrors.NewBadRequestError(fmt.Sprintf("Amount is too small to convert to %s", walletCurrency.Code))
+	}
+
+	transaction.Amount = amount
+	transaction.OriginalAmount = &original
+	transaction.FxRate = &rate.Rate
+	return nil
+}
+
+// maxRoundIDLength is the longest round id the rounds table stores.
+const maxRoundIDLength = 200
+
+// maxMetadataSize is the largest metadata accepted, in bytes of its JSON encoding.
+const maxMetadataSize = 4096
+
+// validateMetadata checks that the metadata of a transaction stays small.
+func validateMetadata(metadata map[string]interface{}) error {
+	encoded, err := json.Marshal(metadata)
+	if err != nil {
+		return apperrors.NewBadRequestError("Invalid metadata")
+	}
+	if len(encoded) > maxMetadataSize {
+		return apperrors.NewBadRequestError(fmt.Sprintf("Metadata must be at most %d bytes", maxMetadataSize))
+	}
+	return nil
+}
+
+// sameMetadata reports whether two metadata objects hold the same keys and values. Empty and missing metadata
+// are the same.
+func sameMetadata(a map[string]interface{}, b map[string]interface{}) bool {
+	if len(a) == 0 || len(b) == 0 {
+		return len(a) == len(b)
+	}
+	// encoding sorts the keys, and numbers decode to float64 from both the request and the database
+	encodedA, errA := json.Marshal(a)
+	encodedB, errB := json.Marshal(b)
+	return errA == nil && errB == nil && bytes.Equal(encodedA, encodedB)
+}
+
+// sameRound reports whether a replay is sent with the round id of the stored transaction. A bet rejected for
+// insufficient funds opened no round, so it stores no round id to compare.
+func sameRound(stored *models.Transaction, requested *models.Transaction) bool {
+	if stored.Status == models.StatusRejectedInsufficientFunds && stored.RoundID == "" {
+		return true
+	}
+	return stored.RoundID == requested.RoundID
+}
+
+// sentAmount returns the amount of a transaction as it was sent, before any conversion.
+func sentAmount(transaction *models.Transaction) models.Money {
+	if transaction.OriginalAmount != nil {
+		return *transaction.OriginalAmount
+	}
+	return transaction.Amount
+}
+
+// replayTransaction returns the stored outcome of a transaction when the request repeats its payload. Amounts are
+// compared as sent, so a replay matches even when the rate of a converted transaction has changed since.
+func (i *TransactionInteractor) replayTransaction(stored *models.Transaction, requested *models.Transaction) (*repositories.TransactionRow, bool, error) {
+	storedAmount, requestedAmount := sentAmount(stored), sentAmount(requested)
+	if stored.User.ID != requested.User.ID ||
+		stored.State != requested.State ||
+		stored.Amount.Currency.Code != requested.Amount.Currency.Code ||
+		storedAmount.Currency.Code != requestedAmount.Currency.Code ||
+		!storedAmount.Equal(requestedAmount.Decimal) ||
+		stored.SourceType.ID != requested.SourceType.ID ||
+		!sameRound(stored, requested) ||
+		!sameMetadata(stored.Metadata, requested.Metadata) {
+		return nil, false, apperrors.NewTransactionConflictError()
+	}
+
+	var balance decimal.Decimal
+	if stored.BalanceAfter != nil {
+		balance = *stored.BalanceAfter
+	}
+
+	// a cancelled transaction was applied before the cancel process reverted it
+	status := stored.Status
+	if status == models.StatusCancelled {
+		status = models.StatusApplied
+	}
+	// a rejected transaction is replayed like an applied one, its status tells the client it was rejected
+	return &repositories.TransactionRow{
+		UserId:        stored.User.ID,
+		UserBalance:   models.NewMoney(balance, requested.Amount.Currency),
+		TransactionId: stored.TransactionID,
+		Status:        status,
+	}, true, nil
+}
+
+// GetTransaction returns a user's transaction by its external transaction id.
+func (i *TransactionInteractor) GetTransaction(ctx context.Context, userID string, transactionID string) (*dtos.TransactionResponse, error) {
+	tx, err := i.transactionRepository.GetByTransactionID(ctx, transactionID)
+	if err != nil {
+		i.logger.Error().Err(err).Msg("Failed to get transaction")
 		return nil, err
 	}
 
-	transaction.Processed = true
-	return &data, nil
+	if tx == nil || tx.User.ID != userID {
+		return nil, apperrors.NewNotFoundError("Transaction not found")
+	}
+
+	response := dtos.NewTransactionResponse(tx)
+	return &response, nil
+}
+
+// ListTransactions returns a page of the user's transaction history, newest first.
+func (i *TransactionInteractor) ListTransactions(ctx context.Context, userID string, query *dtos.TransactionListQuery) (*dtos.TransactionPageResponse, error) {
+	if query.State != "" {
+		if _, ok := models.GetTransactionKind(query.State); !ok {
+			return nil, apperrors.NewBadRequestError("Invalid state")
+		}
+	}
+
+	if query.Status != "" && !models.TransactionStatus(query.Status).IsValid() {
+		return nil, apperrors.NewBadRequestError("Invalid status")
+	}
+
+	var currency string
+	if query.Currency != "" {
+		c, err := resolveCurrency(ctx, i.currencyRepository, query.Currency)
+		if err != nil {
+			return nil, err
+		}
+		currency = c.Code
+	}
+
+	limit := pageLimit(query.Limit)
+
+	filter := repositories.TransactionFilter{
+		UserID:   userID,
+		State:    query.State,
+		Currency: currency,
+		Source:   query.Source,
+		Status:   query.Status,
+		From:     query.From,
+		To:       query.To,
+		Metadata: query.Metadata,
+		RoundID:  query.RoundID,
+		Limit:    limit + 1, // fetch one more row to know whether there is a next page
+	}
+
+	if query.Cursor != "" {
+		cursor, err := decodeCursor(query.Cursor)
+		if err != nil {
+			return nil, apperrors.NewBadRequestError("Invalid cursor")
+		}
+		filter.After = cursor
+	}
+
+	transactions, err := i.transactionRepository.List(ctx, filter)
+	if err != nil {
+		i.logger.Error().Err(err).Msg("Failed to list transactions")
+		return nil, err
+	}
+
+	page := &dtos.TransactionPageResponse{Items: make([]dtos.TransactionResponse, 0, limit)}
+	if len(transactions) > limit {
+		transactions = transactions[:limit]
+		last := transactions[limit-1]
+		page.NextCursor = encodeCursor(&repositories.Cursor{CreatedAt: last.CreatedAt, ID: last.ID})
+	}
+
+	for idx := range transactions {
+		page.Items = append(page.Items, dtos.NewTransactionResponse(&transactions[idx]))
+	}
+
+	return page, nil
+}
+
+// maxBatchSize is the largest number of transactions accepted in one batch.
+const maxBatchSize = 100
+
+// ProcessBatch applies the transactions of a batch in order. An atomic batch is applied in a single database
+// transaction and fails as a whole, a best effort batch processes every item like a single transaction.
+// Item failures are reported per item, the error is only set when the batch itself is invalid.
+func (i *TransactionInteractor) ProcessBatch(userID string, sourceType string, dto *dtos.TransactionBatchDTO) (*dtos.TransactionBatchResponse, error) {
+	if dto.Mode == "" {
+		dto.Mode = dtos.BatchModeAtomic
+	}
+	if dto.Mode != dtos.BatchModeAtomic && dto.Mode != dtos.BatchModeBestEffort {
+		return nil, apperrors.NewBadRequestError("Invalid mode")
+	}
+	if len(dto.Transactions) == 0 || len(dto.Transactions) > maxBatchSize {
+		return nil, apperrors.NewBadRequestError(fmt.Sprintf("A batch must hold between 1 and %d transactions", maxBatchSize))
+	}
+
+	seen := make(map[string]struct{}, len(dto.Transactions))
+	for _, item := range dto.Transactions {
+		if _, ok := seen[item.TransactionID]; ok {
+			return nil, apperrors.NewBadRequestError(fmt.Sprintf("Duplicate transactionId %s in the batch", item.TransactionID))
+		}
+		seen[item.TransactionID] = struct{}{}
+	}
+
+	response := &dtos.TransactionBatchResponse{
+		Mode:  dto.Mode,
+		Items: make([]dtos.TransactionBatchItemResponse, len(dto.Transactions)),
+	}
+
+	if dto.Mode == dtos.BatchModeBestEffort {
+		for idx := range dto.Transactions {
+			row, replayed, err := i.ProcessTransaction(userID, sourceType, &dto.Transactions[idx])
+			item := newBatchItemResponse(dto.Transactions[idx].TransactionID, row, replayed, err)
+			if row == nil && apperrors.As(err, new(*apperrors.InsufficientFundsError)) {
+				// the transaction is stored as rejected, like a single one
+				item.Status = string(models.StatusRejectedInsufficientFunds)
+			}
+			response.Items[idx] = item
+		}
+		return response, nil
+	}
+
+	return response, i.processAtomicBatch(userID, sourceType, dto.Transactions, response.Items)
+}
+
+// processAtomicBatch applies the transactions in a single database transaction and fills in the item results.
+// Items already processed with the same payload are replayed instead of applied again.
+func (i *TransactionInteractor) processAtomicBatch(userID string, sourceType string, items []dtos.TransactionDTO, results []dtos.TransactionBatchItemResponse) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	user, source, err := i.getUserAndSource(ctx, userID, sourceType)
+	if err != nil {
+		return err
+	}
+
+	// fail marks the item at failed with the error and every other item not replayed as not applied
+	fail := func(failed int, err error) {
+		for idx := range results {
+			if results[idx].Replayed {
+				continue
+			}
+			if idx == failed {
+				results[idx] = newBatchItemResponse(items[idx].TransactionID, nil, false, err)
+				continue
+			}
+			results[idx] = dtos.TransactionBatchItemResponse{TransactionID: items[idx].TransactionID, Status: dtos.BatchItemNotApplied}
+		}
+	}
+
+	transactions := make([]*models.Transaction, 0, len(items))
+	positions := make([]int, 0, len(items))
+	for idx := range items {
+		transaction, err := i.newTransaction(ctx, user, source, &items[idx])
+		if err != nil {
+			fail(idx, err)
+			return nil
+		}
+
+		stored, err := i.transactionRepository.GetByTransactionID(ctx, transaction.TransactionID)
+		if err != nil {
+			return err
+		}
+		if stored != nil {
+			row, replayed, err := i.replayTransaction(stored, transaction)
+			if !replayed {
+				fail(idx, err)
+				return nil
+			}
+			results[idx] = newBatchItemResponse(transaction.TransactionID, row, replayed, err)
+			continue
+		}
+
+		transactions = append(transactions, transaction)
+		positions = append(positions, idx)
+	}
+
+	if len(transactions) == 0 {
+		return nil
+	}
+
+	rows, err := i.transactionRepository.InsertTransactionsAndUpdateUserBalance(ctx, transactions)
+	if err != nil {
+		if len(rows) >= len(transactions) {
+			// the commit failed, no item is to blame
+			i.logger.Error().Err(err).Msg("Failed to apply batch")
+			return err
+		}
+		fail(positions[len(rows)], err)
+		return nil
+	}
+
+	for n, idx := range positions {
+		results[idx] = newBatchItemResponse(items[idx].TransactionID, &rows[n], false, nil)
+	}
+
+	return nil
+}
+
+// newBatchItemResponse maps the outcome of a batch item to its API representation.
+func newBatchItemResponse(transactionID string, row *repositories.TransactionRow, replayed bool, err error) dtos.TransactionBatchItemResponse {
+	item := dtos.TransactionBatchItemResponse{TransactionID: transactionID, Replayed: replayed}
+	if row != nil {
+		balance := row.UserBalance.String()
+		item.Balance = &balance
+		item.Status = string(row.Status)
+	}
+
+	if err != nil {
+		httpErr := apperrors.NewHTTPError(err)
+		item.ErrorCode = httpErr.ErrorCode
+		item.Message = httpErr.Message
+		if row == nil {
+			item.Status = dtos.BatchItemFailed
+		}
+	}
+
+	return item
 }
@@ -0,0 +1,266 @@
+package interactor
+
+import (
+	"context"
+	"github.com/mufasadev/enlabs-test/internal/domain/models"
+	"github.com/mufasadev/enlabs-test/internal/domain/repositories"
+	apperrors "github.com/mufasadev/enlabs-test/internal/errors"
+	"github.com/mufasadev/enlabs-test/internal/usecases/dtos"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"testing"
+	"time"
+)
+
+const (
+	testUserID      = "f60ae2e1-ee72-4a6a-bef2-7cde5c83782f"
+	testRecipientID = "3c0f1b6d-2e4a-4b7f-9c1d-5a8e7f6b2d90"
+	testSourceID    = "5ae5e2a4-9a57-4dd2-8a8c-9b8b1e0f6e10"
+	testSourceName  = "game"
+)
+
+// fakeTransactionRepository holds stored transactions by their transaction id and records the inserted ones.
+type fakeTransactionRepository struct {
+	repositories.TransactionRepository
+	stored   map[string]*models.Transaction
+	inserted []*models.Transaction
+}
+
+func (r *fakeTransactionRepository) GetByTransactionID(_ context.Context, transactionID string) (*models.Transaction, error) {
+	return r.stored[transactionID], nil
+}
+
+func (r *fakeTransactionRepository) InsertTransactionAndUpdateUserBalanceWithCreatingTransaction(_ context.Context, transaction *models.Transaction) (repositories.TransactionRow, error) {
+	r.inserted = append(r.inserted, transaction)
+	return repositories.TransactionRow{
+		UserId:        transaction.User.ID,
+		UserBalance:   transaction.Amount,
+		TransactionId: transaction.TransactionID,
+		Status:        models.StatusApplied,
+	}, nil
+}
+
+// fakeUserRepository only holds the test user and the recipient of its transfers.
+type fakeUserRepository struct {
+	repositories.UserRepository
+}
+
+func (r *fakeUserRepository) GetByID(_ context.Context, id string) (*models.User, error) {
+	if id != testUserID && id != testRecipientID {
+		return nil, apperrors.NewNotFoundError("User not found")
+	}
+	return &models.User{ID: id}, nil
+}
+
+// fakeSourceTypeRepository holds an enabled source of any name, which sends the default states unless states are set.
+type fakeSourceTypeRepository struct {
+	repositories.SourceTypeRepository
+	limits models.SourceLimits
+	states []string
+}
+
+func (r *fakeSourceTypeRepository) GetByName(_ context.Context, name string) (*models.SourceType, error) {
+	states := r.states
+	if states == nil {
+		states = models.DefaultSourceStates
+	}
+	return &models.SourceType{ID: testSourceID, Name: name, Enabled: true, Limits: r.limits, States: states}, nil
+}
+
+type fakeCurrencyRepository struct {
+	repositories.CurrencyRepository
+}
+
+func (r *fakeCurrencyRepository) GetByCode(_ context.Context, code string) (*models.Currency, error) {
+	scales := map[string]int32{"EUR": 2, "USD": 2, "BTC": 8}
+	scale, ok := scales[code]
+	if !ok {
+		return nil, nil
+	}
+	return &models.Currency{Code: code, Scale: scale}, nil
+}
+
+// fakeRateProvider holds the rates by base and quote currency.
+type fakeRateProvider map[[2]string]string
+
+func (p fakeRateProvider) GetRate(_ context.Context, base string, quote string, _ time.Time) (*models.FxRate, error) {
+	rate, ok := p[[2]string{base, quote}]
+	if !ok {
+		return nil, nil
+	}
+	return &models.FxRate{BaseCurrency: base, QuoteCurrency: quote, Rate: decimal.RequireFromString(rate)}, nil
+}
+
+func newReplayInteractor(t *testing.T, stored ...*models.Transaction) *TransactionInteractor {
+	transactionRepository := &fakeTransactionRepository{stored: map[string]*models.Transaction{}}
+	for _, transaction := range stored {
+		transactionRepository.stored[transaction.TransactionID] = transaction
+	}
+	t.Cleanup(func() {
+		assert.Empty(t, transactionRepository.inserted, "A replay must never apply a transaction again")
+	})
+	return NewTransactionInteractor(transactionRepository, &fakeUserRepository{}, &fakeSourceTypeRepository{}, nil, nil)
+}
+
+func storedTransaction(transactionID string, status models.TransactionStatus, balanceAfter string) *models.Transaction {
+	balance := decimal.RequireFromString(balanceAfter)
+	return &models.Transaction{
+		TransactionID: transactionID,
+		State:         models.StateLost,
+		Amount:        models.NewMoney(decimal.RequireFromString("10.15"), models.DefaultCurrency()),
+		SourceType:    models.SourceType{ID: testSourceID, Name: testSourceName},
+		User:          models.User{ID: testUserID},
+		Status:        status,
+		BalanceAfter:  &balance,
+	}
+}
+
+func TestReplayTransaction(t *testing.T) {
+	t.Run("match", func(t *testing.T) {
+		i := newReplayInteractor(t, storedTransaction("tx-1", models.StatusApplied, "89.85"))
+
+		row, replayed, err := i.ProcessTransaction(testUserID, testSourceName, &dtos.TransactionDTO{
+			TransactionID: "tx-1",
+			State:         models.StateLost,
+			Amount:        "10.15",
+		})
+		require.NoError(t, err)
+		assert.True(t, replayed)
+		assert.Equal(t, models.StatusApplied, row.Status)
+		assert.Equal(t, "89.85", row.UserBalance.String())
+		assert.Equal(t, testUserID, row.UserId)
+	})
+
+	t.Run("conflict", func(t *testing.T) {
+		i := newReplayInteractor(t, storedTransaction("tx-2", models.StatusApplied, "89.85"))
+
+		for name, dto := range map[string]dtos.TransactionDTO{
+			"amount": {TransactionID: "tx-2", State: models.StateLost, Amount: "10.16"},
+			"state":  {TransactionID: "tx-2", State: models.StateWin, Amount: "10.15"},
+		} {
+			row, replayed, err := i.ProcessTransaction(testUserID, testSourceName, &dto)
+			assert.Nil(t, row, name)
+			assert.False(t, replayed, name)
+			assert.True(t, apperrors.As(err, new(*apperrors.TransactionConflictError)), name)
+		}
+	})
+
+	t.Run("rejected_original", func(t *testing.T) {
+		i := newReplayInteractor(t, storedTransaction("tx-3", models.StatusRejectedInsufficientFunds, "5"))
+
+		row, replayed, err := i.ProcessTransaction(testUserID, testSourceName, &dtos.TransactionDTO{
+			TransactionID: "tx-3",
+			State:         models.StateLost,
+			Amount:        "10.15",
+		})
+		require.NoError(t, err)
+		assert.True(t, replayed)
+		assert.Equal(t, models.StatusRejectedInsufficientFunds, row.Status)
+		assert.Equal(t, "5.00", row.UserBalance.String())
+	})
+
+	t.Run("rejected_bet_with_a_round", func(t *testing.T) {
+		// a rejected bet opened no round, so it is stored without its round id
+		i := newReplayInteractor(t, storedTransaction("tx-4", models.StatusRejectedInsufficientFunds, "5"))
+
+		row, replayed, err := i.ProcessTransaction(testUserID, testSourceName, &dtos.TransactionDTO{
+			TransactionID: "tx-4",
+			State:         models.StateLost,
+			Amount:        "10.15",
+			RoundID:       "r-1",
+		})
+		require.NoError(t, err)
+		assert.True(t, replayed)
+		assert.Equal(t, models.StatusRejectedInsufficientFunds, row.Status)
+	})
+}
+
+func TestSourceLimitsInOtherCurrencies(t *testing.T) {
+	maxAmount := decimal.NewFromInt(50)
+	transactionRepository := &fakeTransactionRepository{stored: map[string]*models.Transaction{}}
+	i := NewTransactionInteractor(
+		transactionRepository,
+		&fakeUserRepository{},
+		&fakeSourceTypeRepository{limits: models.SourceLimits{MaxAmount: &maxAmount}},
+		&fakeCurrencyRepository{},
+		fakeRateProvider{{"USD", "EUR"}: "0.9", {"EUR", "USD"}: "1.1"},
+	)
+
+	t.Run("converted_to_the_default_currency", func(t *testing.T) {
+		_, _, err := i.ProcessTransaction(testUserID, testSourceName, &dtos.TransactionDTO{
+			TransactionID: "tx-usd", State: models.StateWin, Amount: "40", Currency: "USD",
+		})
+		require.NoError(t, err)
+		inserted := transactionRepository.inserted[len(transactionRepository.inserted)-1]
+		require.NotNil(t, inserted.LimitAmount)
+		assert.Equal(t, "36.00", inserted.LimitAmount.String())
+		assert.Equal(t, "EUR", inserted.LimitAmount.Currency.Code)
+	})
+
+	t.Run("sent_in_the_default_currency", func(t *testing.T) {
+		_, _, err := i.ProcessTransaction(testUserID, testSourceName, &dtos.TransactionDTO{
+			TransactionID: "tx-eur-usd", State: models.StateWin, Amount: "40", Currency: "EUR", WalletCurrency: "USD",
+		})
+		require.NoError(t, err)
+		inserted := transactionRepository.inserted[len(transactionRepository.inserted)-1]
+		assert.Equal(t, "44.00", inserted.Amount.String())
+		require.NotNil(t, inserted.LimitAmount)
+		assert.Equal(t, "40.00", inserted.LimitAmount.String())
+	})
+
+	t.Run("no_rate", func(t *testing.T) {
+		_, _, err := i.ProcessTransaction(testUserID, testSourceName, &dtos.TransactionDTO{
+			TransactionID: "tx-btc", State: models.StateWin, Amount: "0.001", Currency: "BTC",
+		})
+		assert.True(t, apperrors.As(err, new(*apperrors.BadRequestError)))
+	})
+
+	t.Run("unlimited_source", func(t *testing.T) {
+		// the volume of an unlimited source is counted too, so a limit set later includes it
+		transactionRepository := &fakeTransactionRepository{stored: map[string]*models.Transaction{}}
+		i := NewTransactionInteractor(
+			transactionRepository,
+			&fakeUserRepository{},
+			&fakeSourceTypeRepository{},
+			&fakeCurrencyRepository{},
+			fakeRateProvider{{"USD", "EUR"}: "0.9"},
+		)
+
+		_, _, err := i.ProcessTransaction(testUserID, testSourceName, &dtos.TransactionDTO{
+			TransactionID: "tx-usd-unlimited", State: models.StateWin, Amount: "40", Currency: "USD",
+		})
+		require.NoError(t, err)
+		require.NotNil(t, transactionRepository.inserted[0].LimitAmount)
+		assert.Equal(t, "36.00", transactionRepository.inserted[0].LimitAmount.String())
+
+		_, _, err = i.ProcessTransaction(testUserID, testSourceName, &dtos.TransactionDTO{
+			TransactionID: "tx-btc-unlimited", State: models.StateWin, Amount: "0.001", Currency: "BTC",
+		})
+		require.NoError(t, err, "A transaction of an unlimited source needs no rate")
+		assert.Nil(t, transactionRepository.inserted[1].LimitAmount)
+	})
+}
+
+func TestSourceStates(t *testing.T) {
+	transactionRepository := &fakeTransactionRepository{stored: map[string]*models.Transaction{}}
+	i := NewTransactionInteractor(
+		transactionRepository,
+		&fakeUserRepository{},
+		&fakeSourceTypeRepository{states: []string{models.StateAdjustment, models.StateAdjustmentDebit}},
+		nil,
+		nil,
+	)
+
+	_, _, err := i.ProcessTransaction(testUserID, "backoffice", &dtos.TransactionDTO{
+		TransactionID: "tx-adjustment-debit", State: models.StateAdjustmentDebit, Amount: "15",
+	})
+	require.NoError(t, err)
+	require.Len(t, transactionRepository.inserted, 1)
+	assert.Equal(t, -1, transactionRepository.inserted[0].Sign(), "An adjustment debit must debit the user")
+
+	_, _, err = i.ProcessTransaction(testUserID, "backoffice", &dtos.TransactionDTO{
+		TransactionID: "tx-win", State: models.StateWin, Amount: "15",
+	})
+	assert.True(t, apperrors.As(err, new(*apperrors.BadRequestError)), "A state the source does not send must be rejected")
+}
@@ -0,0 +1,123 @@
+package interactor
+
+import (
+	"context"
+	"github.com/google/uuid"
+	"github.com/mufasadev/enlabs-test/internal/domain/models"
+	"github.com/mufasadev/enlabs-test/internal/domain/repositories"
+	apperrors "github.com/mufasadev/enlabs-test/internal/errors"
+	"github.com/mufasadev/enlabs-test/internal/usecases/dtos"
+	"github.com/mufasadev/enlabs-test/pkg/log"
+	"github.com/rs/zerolog"
+)
+
+// maxTransferIDLength leaves room for the prefix and suffix of the transaction ids of a transfer.
+const maxTransferIDLength = 200
+
+type TransferInteractor struct {
+	transferRepository repositories.TransferRepository
+	userRepository     repositories.UserRepository
+	currencyRepository repositories.CurrencyRepository
+	logger             *zerolog.Logger
+}
+
+func NewTransferInteractor(transferRepository repositories.TransferRepository, userRepository repositories.UserRepository, currencyRepository repositories.CurrencyRepository) *TransferInteractor {
+	l := log.GetLogger()
+	return &TransferInteractor{
+		transferRepository: transferRepository,
+		userRepository:     userRepository,
+		currencyRepository: currencyRepository,
+		logger:             &l,
+	}
+}
+
+// CreateTransfer moves funds from userID, the user the transfer is sent for, to another user atomically. A transfer
+// id that was already processed with the same payload is replayed: the stored result is returned and replayed is
+// true.
+func (i *TransferInteractor) CreateTransfer(ctx context.Context, userID string, dto *dtos.TransferDTO) (*dtos.TransferResponse, bool, error) {
+	transfer, err := i.newTransfer(ctx, userID, dto)
+	if err != nil {
+		return nil, false, err
+	}
+
+	stored, err := i.transferRepository.GetByTransferID(ctx, transfer.TransferID)
+	if err != nil {
+		i.logger.Error().Err(err).Msg("Failed to get transfer")
+		return nil, false, err
+	}
+	if stored != nil {
+		return replayTransfer(stored, transfer)
+	}
+
+	err = i.transferRepository.Create(ctx, transfer)
+	if apperrors.As(err, new(*apperrors.TransactionDuplicateError)) {
+		// a concurrent request with the same transfer id won the race
+		stored, err = i.transferRepository.GetByTransferID(ctx, transfer.TransferID)
+		if err != nil {
+			return nil, false, err
+		}
+		if stored == nil {
+			return nil, false, apperrors.NewTransactionDuplicateError()
+		}
+		return replayTransfer(stored, transfer)
+	}
+	if err != nil {
+		return nil, false, err
+	}
+
+	return dtos.NewTransferResponse(transfer), false, nil
+}
+
+// newTransfer validates a transfer request of the sender.
+func (i *TransferInteractor) newTransfer(ctx context.Context, fromUserID string, dto *dtos.TransferDTO) (*models.Transfer, error) {
+	if dto.TransferID == "" || len(dto.TransferID) > maxTransferIDLength {
+		return nil, apperrors.NewBadRequestError("Invalid transferId")
+	}
+
+	currency, err := resolveCurrency(ctx, i.currencyRepository, dto.Currency)
+	if err != nil {
+		return nil, err
+	}
+
+	amount, err := models.ParseMoney(dto.Amount, currency)
+	if err != nil {
+		return nil, err
+	}
+
+	if fromUserID == dto.ToUserID {
+		return nil, apperrors.NewBadRequestError("A transfer needs two different users")
+	}
+	for _, userID := range []string{fromUserID, dto.ToUserID} {
+		if _, err = uuid.Parse(userID); err != nil {
+			return nil, apperrors.NewBadRequestError("Invalid user ID")
+		}
+		if _, err = i.userRepository.GetByID(ctx, userID); err != nil {
+			i.logger.Error().Err(err).Msg("Failed to get user")
+			return nil, apperrors.NewBadRequestError("Invalid user ID")
+		}
+	}
+
+	return &models.Transfer{
+		TransferID: dto.TransferID,
+		FromUserID: fromUserID,
+		ToUserID:   dto.ToUserID,
+		Amount:     amount,
+		Status:     models.StatusPending,
+	}, nil
+}
+
+// replayTransfer returns the stored outcome of a transfer when the request repeats its payload.
+func replayTransfer(stored *models.Transfer, requested *models.Transfer) (*dtos.TransferResponse, bool, error) {
+	if stored.FromUserID != requested.FromUserID ||
+		stored.ToUserID != requested.ToUserID ||
+		stored.Amount.Currency.Code != requested.Amount.Currency.Code ||
+		!stored.Amount.Equal(requested.Amount.Decimal) {
+		return nil, false, apperrors.NewTransactionConflictError()
+	}
+
+	if stored.Status == models.StatusRejectedInsufficientFunds {
+		return nil, true, apperrors.NewInsufficientFundsError()
+	}
+
+	return dtos.NewTransferResponse(stored), true, nil
+}
@@ -0,0 +1,44 @@
+package interactor
+
+import (
+	"context"
+	"github.com/mufasadev/enlabs-test/internal/domain/models"
+	"github.com/mufasadev/enlabs-test/internal/domain/repositories"
+	"github.com/mufasadev/enlabs-test/internal/usecases/dtos"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+// fakeTransferRepository has no stored transfers and records the created ones as applied.
+type fakeTransferRepository struct {
+	repositories.TransferRepository
+	created []*models.Transfer
+}
+
+func (r *fakeTransferRepository) GetByTransferID(_ context.Context, _ string) (*models.Transfer, error) {
+	return nil, nil
+}
+
+func (r *fakeTransferRepository) Create(_ context.Context, transfer *models.Transfer) error {
+	transfer.Status = models.StatusApplied
+	r.created = append(r.created, transfer)
+	return nil
+}
+
+func TestTransferDebitsTheUserItIsSentFor(t *testing.T) {
+	repository := &fakeTransferRepository{}
+	i := NewTransferInteractor(repository, &fakeUserRepository{}, &fakeCurrencyRepository{})
+
+	transfer, replayed, err := i.CreateTransfer(context.Background(), testUserID, &dtos.TransferDTO{
+		TransferID: "tr-1",
+		ToUserID:   testRecipientID,
+		Amount:     "25.00",
+	})
+	require.NoError(t, err)
+	assert.False(t, replayed)
+	assert.Equal(t, testUserID, transfer.FromUserID)
+	assert.Equal(t, testRecipientID, transfer.ToUserID)
+	require.Len(t, repository.created, 1)
+	assert.Equal(t, testUserID, repository.created[0].FromUserID)
+}
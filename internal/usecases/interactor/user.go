@@ -2,30 +2,160 @@ package interactor
 
 import (
 	"context"
+	"github.com/google/uuid"
+	"github.com/mufasadev/enlabs-test/internal/domain/models"
 	"github.com/mufasadev/enlabs-test/internal/domain/repositories"
+	apperrors "github.com/mufasadev/enlabs-test/internal/errors"
+	"github.com/mufasadev/enlabs-test/internal/usecases/dtos"
+	"github.com/mufasadev/enlabs-test/pkg/log"
+	"github.com/rs/zerolog"
+	"github.com/shopspring/decimal"
 )
 
 type UserInteractor struct {
-	userRepository repositories.UserRepository
+	userRepository     repositories.UserRepository
+	walletRepository   repositories.WalletRepository
+	currencyRepository repositories.CurrencyRepository
+	logger             *zerolog.Logger
 }
 
-func NewUserInteractor(Repository repositories.UserRepository) *UserInteractor {
-	return &UserInteractor{userRepository: Repository}
+func NewUserInteractor(Repository repositories.UserRepository, walletRepository repositories.WalletRepository, currencyRepository repositories.CurrencyRepository) *UserInteractor {
+	l := log.GetLogger()
+	return &UserInteractor{userRepository: Repository, walletRepository: walletRepository, currencyRepository: currencyRepository, logger: &l}
+}
+
+// checkUserID rejects a malformed user id as a bad request before it reaches a uuid column.
+func checkUserID(id string) error {
+	if _, err := uuid.Parse(id); err != nil {
+		return apperrors.NewBadRequestError(apperrors.ErrInvalidUserID)
+	}
+	return nil
+}
+
+// findUser returns the user with the id. A malformed id is a bad request and an unknown user is not found.
+func findUser(ctx context.Context, userRepository repositories.UserRepository, id string) (*models.User, error) {
+	if err := checkUserID(id); err != nil {
+		return nil, err
+	}
+	return userRepository.GetByID(ctx, id)
 }
 
 func (u *UserInteractor) ExistsByID(ctx context.Context, id string) (bool, error) {
-	_, err := u.userRepository.GetByID(ctx, id)
+	_, err := findUser(ctx, u.userRepository, id)
 	if err != nil {
 		return false, err
 	}
 	return true, nil
 }
 
-func (u *UserInteractor) GetBalance(ctx context.Context, id string) (float64, error) {
-	user, err := u.userRepository.GetByID(ctx, id)
+// GetBalance returns every wallet of the user.
+func (u *UserInteractor) GetBalance(ctx context.Context, id string) (*dtos.BalanceResponse, error) {
+	if _, err := findUser(ctx, u.userRepository, id); err != nil {
+		return nil, err
+	}
+
+	wallets, err := u.walletRepository.ListByUserID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	return dtos.NewBalanceResponse(wallets, nil), nil
+}
+
+// CreateUser creates a user with a zero balance and a generated account number.
+func (u *UserInteractor) CreateUser(ctx context.Context) (*dtos.UserResponse, error) {
+	user, err := u.userRepository.Create(ctx)
 	if err != nil {
-		return 0.0, err
+		u.logger.Error().Err(err).Msg("Failed to create user")
+		return nil, err
+	}
+
+	response := dtos.NewUserResponse(user)
+	return &response, nil
+}
+
+// GetUser returns a user by id.
+func (u *UserInteractor) GetUser(ctx context.Context, id string) (*dtos.UserResponse, error) {
+	user, err := findUser(ctx, u.userRepository, id)
+	if err != nil {
+		return nil, err
+	}
+
+	response := dtos.NewUserResponse(user)
+	return &response, nil
+}
+
+// GetUserByAccountNumber resolves a user by account number.
+func (u *UserInteractor) GetUserByAccountNumber(ctx context.Context, accountNumber string) (*dtos.UserResponse, error) {
+	if _, err := uuid.Parse(accountNumber); err != nil {
+		return nil, apperrors.NewNotFoundError("User not found")
+	}
+
+	user, err := u.userRepository.GetByAccountNumber(ctx, accountNumber)
+	if err != nil {
+		u.logger.Error().Err(err).Msg("Failed to get user by account number")
+		return nil, err
+	}
+	if user == nil {
+		return nil, apperrors.NewNotFoundError("User not found")
 	}
-	b, _ := user.Balance.Float64()
-	return b, nil
+
+	response := dtos.NewUserResponse(user)
+	return &response, nil
+}
+
+// ListUsers returns a page of users, newest first.
+func (u *UserInteractor) ListUsers(ctx context.Context, query *dtos.PageQuery) (*dtos.UserPageResponse, error) {
+	limit := pageLimit(query.Limit)
+
+	var after *repositories.Cursor
+	if query.Cursor != "" {
+		cursor, err := decodeCursor(query.Cursor)
+		if err != nil {
+			return nil, apperrors.NewBadRequestError("Invalid cursor")
+		}
+		after = cursor
+	}
+
+	users, err := u.userRepository.List(ctx, after, limit+1)
+	if err != nil {
+		u.logger.Error().Err(err).Msg("Failed to list users")
+		return nil, err
+	}
+
+	page := &dtos.UserPageResponse{Items: make([]dtos.UserResponse, 0, limit)}
+	if len(users) > limit {
+		users = users[:limit]
+		last := users[limit-1]
+		page.NextCursor = encodeCursor(&repositories.Cursor{CreatedAt: last.CreatedAt, ID: last.ID})
+	}
+
+	for idx := range users {
+		page.Items = append(page.Items, dtos.NewUserResponse(&users[idx]))
+	}
+
+	return page, nil
+}
+
+// SetCreditLimit sets how far below zero the balance of the user's wallet in the currency may go.
+func (u *UserInteractor) SetCreditLimit(ctx context.Context, id string, dto *dtos.CreditLimitDTO) (*dtos.UserResponse, error) {
+	if _, err := uuid.Parse(id); err != nil {
+		return nil, apperrors.NewNotFoundError("User not found")
+	}
+
+	currency, err := resolveCurrency(ctx, u.currencyRepository, dto.Currency)
+	if err != nil {
+		return nil, err
+	}
+
+	limit, err := decimal.NewFromString(dto.CreditLimit)
+	if err != nil || limit.IsNegative() || !limit.Equal(limit.Round(currency.Scale)) {
+		return nil, apperrors.NewBadRequestError("Invalid credit limit")
+	}
+
+	if err = u.walletRepository.SetCreditLimit(ctx, &models.Wallet{UserID: id, Currency: currency, CreditLimit: limit}); err != nil {
+		u.logger.Error().Err(err).Msg("Failed to set credit limit")
+		return nil, err
+	}
+
+	return u.GetUser(ctx, id)
 }
@@ -0,0 +1,4 @@
+BEGIN;
+    DROP VIEW IF EXISTS public.ledger_account_balances;
+    DROP TABLE IF EXISTS public.ledger_entries CASCADE;
+COMMIT;
@@ -0,0 +1,46 @@
+BEGIN;
+
+-- TABLES --
+CREATE TABLE IF NOT EXISTS ledger_entries
+(
+    id             UUID PRIMARY KEY        DEFAULT gen_random_uuid(),
+    posting_id     UUID           NOT NULL,
+    transaction_id UUID REFERENCES transactions (id),
+    entry_type     VARCHAR(16)    NOT NULL CHECK (entry_type IN ('opening', 'transaction', 'cancellation', 'adjustment')),
+    account_type   VARCHAR(16)    NOT NULL CHECK (account_type IN ('user', 'source', 'equity')),
+    account_id     UUID           NOT NULL,
+    direction      VARCHAR(6)     NOT NULL CHECK (direction IN ('debit', 'credit')),
+    amount         NUMERIC(10, 2) NOT NULL CHECK (amount > 0),
+    created_at     TIMESTAMPTZ    NOT NULL DEFAULT NOW()
+);
+
+-- INDEXES --
+CREATE INDEX IF NOT EXISTS ledger_entries_account_idx ON ledger_entries (account_type, account_id, created_at);
+CREATE INDEX IF NOT EXISTS ledger_entries_transaction_id_idx ON ledger_entries (transaction_id);
+CREATE INDEX IF NOT EXISTS ledger_entries_posting_id_idx ON ledger_entries (posting_id);
+
+-- VIEWS --
+-- Balance of every ledger account: credits increase it, debits decrease it.
+CREATE OR REPLACE VIEW ledger_account_balances AS
+SELECT account_type,
+       account_id,
+       SUM(CASE WHEN direction = 'credit' THEN amount ELSE -amount END) AS balance
+FROM ledger_entries
+GROUP BY account_type, account_id;
+
+-- DATA --
+-- Open the ledger with the balances users already hold, booked against the equity account.
+WITH openings AS MATERIALIZED (
+  SELECT id AS user_id, balance, gen_random_uuid() AS posting_id
+  FROM users
+  WHERE balance <> 0
+)
+INSERT INTO ledger_entries (posting_id, entry_type, account_type, account_id, direction, amount)
+SELECT o.posting_id, 'opening', e.account_type, e.account_id, e.direction, ABS(o.balance)
+FROM openings o
+CROSS JOIN LATERAL (VALUES
+  ('user', o.user_id, CASE WHEN o.balance > 0 THEN 'credit' ELSE 'debit' END),
+  ('equity', '00000000-0000-0000-0000-000000000000'::UUID, CASE WHEN o.balance > 0 THEN 'debit' ELSE 'credit' END)
+) AS e (account_type, account_id, direction);
+
+COMMIT;
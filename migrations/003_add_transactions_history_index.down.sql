@@ -0,0 +1,3 @@
+BEGIN;
+    DROP INDEX IF EXISTS public.transactions_user_id_created_at_idx;
+COMMIT;
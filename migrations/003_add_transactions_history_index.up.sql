@@ -0,0 +1,6 @@
+BEGIN;
+
+-- INDEXES --
+CREATE INDEX IF NOT EXISTS transactions_user_id_created_at_idx ON transactions (user_id, created_at DESC, id DESC);
+
+COMMIT;
@@ -0,0 +1,6 @@
+BEGIN;
+
+-- Balance of the user right after the transaction was processed, returned on idempotent replays.
+ALTER TABLE transactions ADD COLUMN IF NOT EXISTS balance_after NUMERIC(10, 2);
+
+COMMIT;
@@ -0,0 +1,8 @@
+BEGIN;
+    ALTER TABLE public.transactions DISABLE TRIGGER update_timestamp;
+    ALTER TABLE public.transactions ADD COLUMN IF NOT EXISTS processed BOOLEAN NOT NULL DEFAULT FALSE;
+    UPDATE public.transactions SET processed = (status = 'applied');
+    DROP INDEX IF EXISTS public.transactions_status_created_at_idx;
+    ALTER TABLE public.transactions DROP COLUMN IF EXISTS status;
+    ALTER TABLE public.transactions ENABLE TRIGGER update_timestamp;
+COMMIT;
@@ -0,0 +1,28 @@
+BEGIN;
+
+-- keep updated_at of existing rows untouched while backfilling the status
+ALTER TABLE transactions DISABLE TRIGGER update_timestamp;
+
+ALTER TABLE transactions ADD COLUMN IF NOT EXISTS status VARCHAR(32) NOT NULL DEFAULT 'pending'
+    CHECK (status IN ('pending', 'applied', 'rejected_insufficient_funds', 'cancelled'));
+
+-- Cancelled transactions are the ones with a cancellation posting in the ledger. Transactions cancelled
+-- before the ledger was introduced cannot be told apart and are kept as rejected.
+UPDATE transactions t
+SET status = CASE
+                 WHEN t.processed THEN 'applied'
+                 WHEN EXISTS (SELECT 1
+                              FROM ledger_entries le
+                              WHERE le.transaction_id = t.id
+                                AND le.entry_type = 'cancellation') THEN 'cancelled'
+                 ELSE 'rejected_insufficient_funds'
+    END;
+
+ALTER TABLE transactions DROP COLUMN IF EXISTS processed;
+
+ALTER TABLE transactions ENABLE TRIGGER update_timestamp;
+
+-- INDEXES --
+CREATE INDEX IF NOT EXISTS transactions_status_created_at_idx ON transactions (status, created_at DESC);
+
+COMMIT;
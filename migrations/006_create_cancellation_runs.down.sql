@@ -0,0 +1,4 @@
+BEGIN;
+    DROP TABLE IF EXISTS public.cancellations CASCADE;
+    DROP TABLE IF EXISTS public.cancellation_runs CASCADE;
+COMMIT;
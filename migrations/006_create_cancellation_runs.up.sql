@@ -0,0 +1,29 @@
+BEGIN;
+
+-- TABLES --
+CREATE TABLE IF NOT EXISTS cancellation_runs
+(
+    id              UUID PRIMARY KEY     DEFAULT gen_random_uuid(),
+    strategy        VARCHAR(32) NOT NULL,
+    started_at      TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    finished_at     TIMESTAMPTZ,
+    cancelled_count INTEGER     NOT NULL DEFAULT 0,
+    error           TEXT
+);
+
+CREATE TABLE IF NOT EXISTS cancellations
+(
+    id             UUID PRIMARY KEY        DEFAULT gen_random_uuid(),
+    run_id         UUID           NOT NULL REFERENCES cancellation_runs (id),
+    transaction_id UUID           NOT NULL UNIQUE REFERENCES transactions (id),
+    user_id        UUID           NOT NULL REFERENCES users (id),
+    balance_before NUMERIC(10, 2) NOT NULL,
+    balance_after  NUMERIC(10, 2) NOT NULL,
+    created_at     TIMESTAMPTZ    NOT NULL DEFAULT NOW()
+);
+
+-- INDEXES --
+CREATE INDEX IF NOT EXISTS cancellation_runs_started_at_idx ON cancellation_runs (started_at DESC, id DESC);
+CREATE INDEX IF NOT EXISTS cancellations_run_id_idx ON cancellations (run_id);
+
+COMMIT;
@@ -0,0 +1,5 @@
+BEGIN;
+    DROP INDEX IF EXISTS public.users_created_at_idx;
+    ALTER TABLE public.users ALTER COLUMN account_number DROP DEFAULT;
+    ALTER TABLE public.users DROP COLUMN IF EXISTS created_at;
+COMMIT;
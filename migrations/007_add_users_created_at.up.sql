@@ -0,0 +1,10 @@
+BEGIN;
+
+-- Users are created through the API, the account number is generated by the database.
+ALTER TABLE users ADD COLUMN IF NOT EXISTS created_at TIMESTAMPTZ NOT NULL DEFAULT NOW();
+ALTER TABLE users ALTER COLUMN account_number SET DEFAULT gen_random_uuid();
+
+-- INDEXES --
+CREATE INDEX IF NOT EXISTS users_created_at_idx ON users (created_at DESC, id DESC);
+
+COMMIT;
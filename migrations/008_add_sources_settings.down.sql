@@ -0,0 +1,8 @@
+BEGIN;
+    DROP TRIGGER IF EXISTS update_timestamp ON public.sources;
+    ALTER TABLE public.sources DROP COLUMN IF EXISTS updated_at;
+    ALTER TABLE public.sources DROP COLUMN IF EXISTS created_at;
+    ALTER TABLE public.sources DROP COLUMN IF EXISTS settings;
+    ALTER TABLE public.sources DROP COLUMN IF EXISTS enabled;
+    ALTER TABLE public.sources DROP COLUMN IF EXISTS display_name;
+COMMIT;
@@ -0,0 +1,18 @@
+BEGIN;
+
+ALTER TABLE sources ADD COLUMN IF NOT EXISTS display_name VARCHAR(100) NOT NULL DEFAULT '';
+ALTER TABLE sources ADD COLUMN IF NOT EXISTS enabled BOOLEAN NOT NULL DEFAULT TRUE;
+ALTER TABLE sources ADD COLUMN IF NOT EXISTS settings JSONB NOT NULL DEFAULT '{}'::JSONB;
+ALTER TABLE sources ADD COLUMN IF NOT EXISTS created_at TIMESTAMPTZ NOT NULL DEFAULT NOW();
+ALTER TABLE sources ADD COLUMN IF NOT EXISTS updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW();
+
+UPDATE sources SET display_name = INITCAP(name) WHERE display_name = '';
+
+-- TRIGGERS --
+CREATE TRIGGER update_timestamp
+    BEFORE UPDATE
+    ON sources
+    FOR EACH ROW
+EXECUTE PROCEDURE update_timestamp();
+
+COMMIT;
@@ -0,0 +1,6 @@
+BEGIN;
+    DROP TABLE IF EXISTS public.source_daily_volumes CASCADE;
+    ALTER TABLE public.sources DROP COLUMN IF EXISTS max_daily_loss;
+    ALTER TABLE public.sources DROP COLUMN IF EXISTS max_daily_win;
+    ALTER TABLE public.sources DROP COLUMN IF EXISTS max_amount;
+COMMIT;
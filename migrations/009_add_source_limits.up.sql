@@ -0,0 +1,19 @@
+BEGIN;
+
+-- Limits of a source, NULL means unlimited.
+ALTER TABLE sources ADD COLUMN IF NOT EXISTS max_amount NUMERIC(10, 2) CHECK (max_amount > 0);
+ALTER TABLE sources ADD COLUMN IF NOT EXISTS max_daily_win NUMERIC(10, 2) CHECK (max_daily_win > 0);
+ALTER TABLE sources ADD COLUMN IF NOT EXISTS max_daily_loss NUMERIC(10, 2) CHECK (max_daily_loss > 0);
+
+-- TABLES --
+-- Applied volume per source, UTC day and state. Only tracked for sources with a daily limit.
+CREATE TABLE IF NOT EXISTS source_daily_volumes
+(
+    source_id UUID           NOT NULL REFERENCES sources (id) ON DELETE CASCADE,
+    day       DATE           NOT NULL,
+    state     VARCHAR(4)     NOT NULL,
+    volume    NUMERIC(12, 2) NOT NULL DEFAULT 0,
+    PRIMARY KEY (source_id, day, state)
+);
+
+COMMIT;
@@ -0,0 +1,4 @@
+BEGIN;
+    ALTER TABLE public.users DROP CONSTRAINT IF EXISTS users_balance_within_credit_limit;
+    ALTER TABLE public.users DROP COLUMN IF EXISTS credit_limit;
+COMMIT;
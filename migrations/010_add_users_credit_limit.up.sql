@@ -0,0 +1,7 @@
+BEGIN;
+
+-- Overdraft allowed to the user, the balance never goes below -credit_limit.
+ALTER TABLE users ADD COLUMN IF NOT EXISTS credit_limit NUMERIC(10, 2) NOT NULL DEFAULT 0 CHECK (credit_limit >= 0);
+ALTER TABLE users ADD CONSTRAINT users_balance_within_credit_limit CHECK (balance + credit_limit >= 0);
+
+COMMIT;
@@ -0,0 +1,13 @@
+BEGIN;
+
+-- TABLES --
+-- Balance of a user account as the sum of its ledger entries created up to taken_at.
+CREATE TABLE IF NOT EXISTS balance_snapshots
+(
+    user_id  UUID           NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    taken_at TIMESTAMPTZ    NOT NULL,
+    balance  NUMERIC(10, 2) NOT NULL,
+    PRIMARY KEY (user_id, taken_at)
+);
+
+COMMIT;
@@ -0,0 +1,4 @@
+BEGIN;
+    DROP TABLE IF EXISTS public.reconciliation_drifts CASCADE;
+    DROP TABLE IF EXISTS public.reconciliation_runs CASCADE;
+COMMIT;
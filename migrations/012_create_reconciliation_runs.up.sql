@@ -0,0 +1,29 @@
+BEGIN;
+
+-- TABLES --
+CREATE TABLE IF NOT EXISTS reconciliation_runs
+(
+    id            UUID PRIMARY KEY     DEFAULT gen_random_uuid(),
+    started_at    TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    finished_at   TIMESTAMPTZ,
+    users_checked INTEGER     NOT NULL DEFAULT 0,
+    drifted_count INTEGER     NOT NULL DEFAULT 0,
+    error         TEXT
+);
+
+CREATE TABLE IF NOT EXISTS reconciliation_drifts
+(
+    id               UUID PRIMARY KEY        DEFAULT gen_random_uuid(),
+    run_id           UUID           NOT NULL REFERENCES reconciliation_runs (id),
+    user_id          UUID           NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    expected_balance NUMERIC(12, 2) NOT NULL,
+    actual_balance   NUMERIC(10, 2) NOT NULL,
+    ledger_balance   NUMERIC(12, 2) NOT NULL,
+    created_at       TIMESTAMPTZ    NOT NULL DEFAULT NOW()
+);
+
+-- INDEXES --
+CREATE INDEX IF NOT EXISTS reconciliation_runs_started_at_idx ON reconciliation_runs (started_at DESC, id DESC);
+CREATE INDEX IF NOT EXISTS reconciliation_drifts_run_id_idx ON reconciliation_drifts (run_id);
+
+COMMIT;
@@ -0,0 +1,4 @@
+BEGIN;
+    ALTER TABLE public.transactions DROP COLUMN IF EXISTS transfer_id;
+    DROP TABLE IF EXISTS public.transfers CASCADE;
+COMMIT;
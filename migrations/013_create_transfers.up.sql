@@ -0,0 +1,28 @@
+BEGIN;
+
+-- TABLES --
+CREATE TABLE IF NOT EXISTS transfers
+(
+    id           UUID PRIMARY KEY        DEFAULT gen_random_uuid(),
+    transfer_id  VARCHAR(200)   NOT NULL UNIQUE,
+    from_user_id UUID           NOT NULL REFERENCES users (id),
+    to_user_id   UUID           NOT NULL REFERENCES users (id),
+    amount       NUMERIC(10, 2) NOT NULL CHECK (amount > 0),
+    status       VARCHAR(32)    NOT NULL CHECK (status IN ('applied', 'rejected_insufficient_funds')),
+    created_at   TIMESTAMPTZ    NOT NULL DEFAULT NOW(),
+    CHECK (from_user_id <> to_user_id)
+);
+
+-- Both sides of a transfer are stored as transactions linked to it.
+ALTER TABLE transactions ADD COLUMN IF NOT EXISTS transfer_id UUID REFERENCES transfers (id);
+
+-- INDEXES --
+CREATE INDEX IF NOT EXISTS transactions_transfer_id_idx ON transactions (transfer_id);
+
+-- DATA --
+-- Transfer transactions are booked to a reserved source. It is disabled, so it cannot be sent in the Source-Type header.
+INSERT INTO sources (id, name, display_name, enabled)
+VALUES ('5f0c2d4e-7a1b-4c3d-9e8f-0a1b2c3d4e5f', 'transfer', 'Transfer', FALSE)
+ON CONFLICT (name) DO NOTHING;
+
+COMMIT;
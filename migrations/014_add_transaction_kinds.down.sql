@@ -0,0 +1,8 @@
+BEGIN;
+    -- Only win and lost fit the old column, every other kind becomes the one with the same sign.
+    ALTER TABLE public.transactions DISABLE TRIGGER update_timestamp;
+    UPDATE public.transactions SET state = CASE WHEN sign > 0 THEN 'win' ELSE 'lost' END WHERE state NOT IN ('win', 'lost');
+    ALTER TABLE public.transactions ENABLE TRIGGER update_timestamp;
+    ALTER TABLE public.transactions DROP COLUMN IF EXISTS sign;
+    ALTER TABLE public.transactions ALTER COLUMN state TYPE VARCHAR(4);
+COMMIT;
@@ -0,0 +1,17 @@
+BEGIN;
+
+-- keep updated_at of existing rows untouched while backfilling the sign
+ALTER TABLE transactions DISABLE TRIGGER update_timestamp;
+
+-- The state holds the transaction kind, kinds are longer than win and lost.
+ALTER TABLE transactions ALTER COLUMN state TYPE VARCHAR(32);
+
+-- The sign of the balance change is stored with every transaction: 1 credits the user, -1 debits the user.
+ALTER TABLE transactions ADD COLUMN IF NOT EXISTS sign SMALLINT;
+UPDATE transactions SET sign = CASE WHEN state = 'win' THEN 1 ELSE -1 END;
+ALTER TABLE transactions ALTER COLUMN sign SET NOT NULL;
+ALTER TABLE transactions ADD CONSTRAINT transactions_sign_check CHECK (sign IN (-1, 1));
+
+ALTER TABLE transactions ENABLE TRIGGER update_timestamp;
+
+COMMIT;
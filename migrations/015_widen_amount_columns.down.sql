@@ -0,0 +1,25 @@
+BEGIN;
+    DROP VIEW IF EXISTS public.ledger_account_balances;
+    ALTER TABLE public.users ALTER COLUMN balance TYPE NUMERIC(10, 2);
+    ALTER TABLE public.users ALTER COLUMN credit_limit TYPE NUMERIC(10, 2);
+    ALTER TABLE public.transactions ALTER COLUMN amount TYPE NUMERIC(10, 2);
+    ALTER TABLE public.transactions ALTER COLUMN balance_after TYPE NUMERIC(10, 2);
+    ALTER TABLE public.ledger_entries ALTER COLUMN amount TYPE NUMERIC(10, 2);
+    ALTER TABLE public.cancellations ALTER COLUMN balance_before TYPE NUMERIC(10, 2);
+    ALTER TABLE public.cancellations ALTER COLUMN balance_after TYPE NUMERIC(10, 2);
+    ALTER TABLE public.sources ALTER COLUMN max_amount TYPE NUMERIC(10, 2);
+    ALTER TABLE public.sources ALTER COLUMN max_daily_win TYPE NUMERIC(10, 2);
+    ALTER TABLE public.sources ALTER COLUMN max_daily_loss TYPE NUMERIC(10, 2);
+    ALTER TABLE public.source_daily_volumes ALTER COLUMN volume TYPE NUMERIC(12, 2);
+    ALTER TABLE public.balance_snapshots ALTER COLUMN balance TYPE NUMERIC(10, 2);
+    ALTER TABLE public.reconciliation_drifts ALTER COLUMN expected_balance TYPE NUMERIC(12, 2);
+    ALTER TABLE public.reconciliation_drifts ALTER COLUMN actual_balance TYPE NUMERIC(10, 2);
+    ALTER TABLE public.reconciliation_drifts ALTER COLUMN ledger_balance TYPE NUMERIC(12, 2);
+    ALTER TABLE public.transfers ALTER COLUMN amount TYPE NUMERIC(10, 2);
+    CREATE OR REPLACE VIEW public.ledger_account_balances AS
+    SELECT account_type,
+           account_id,
+           SUM(CASE WHEN direction = 'credit' THEN amount ELSE -amount END) AS balance
+    FROM public.ledger_entries
+    GROUP BY account_type, account_id;
+COMMIT;
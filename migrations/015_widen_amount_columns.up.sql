@@ -0,0 +1,33 @@
+BEGIN;
+
+-- Amounts hold 20 integer digits and up to 8 decimal places, the scale of the currency is enforced by the service.
+-- The view depends on the ledger amount and is recreated with it.
+DROP VIEW IF EXISTS ledger_account_balances;
+
+ALTER TABLE users ALTER COLUMN balance TYPE NUMERIC(28, 8);
+ALTER TABLE users ALTER COLUMN credit_limit TYPE NUMERIC(28, 8);
+ALTER TABLE transactions ALTER COLUMN amount TYPE NUMERIC(28, 8);
+ALTER TABLE transactions ALTER COLUMN balance_after TYPE NUMERIC(28, 8);
+ALTER TABLE ledger_entries ALTER COLUMN amount TYPE NUMERIC(28, 8);
+ALTER TABLE cancellations ALTER COLUMN balance_before TYPE NUMERIC(28, 8);
+ALTER TABLE cancellations ALTER COLUMN balance_after TYPE NUMERIC(28, 8);
+ALTER TABLE sources ALTER COLUMN max_amount TYPE NUMERIC(28, 8);
+ALTER TABLE sources ALTER COLUMN max_daily_win TYPE NUMERIC(28, 8);
+ALTER TABLE sources ALTER COLUMN max_daily_loss TYPE NUMERIC(28, 8);
+ALTER TABLE source_daily_volumes ALTER COLUMN volume TYPE NUMERIC(30, 8);
+ALTER TABLE balance_snapshots ALTER COLUMN balance TYPE NUMERIC(28, 8);
+ALTER TABLE reconciliation_drifts ALTER COLUMN expected_balance TYPE NUMERIC(30, 8);
+ALTER TABLE reconciliation_drifts ALTER COLUMN actual_balance TYPE NUMERIC(28, 8);
+ALTER TABLE reconciliation_drifts ALTER COLUMN ledger_balance TYPE NUMERIC(30, 8);
+ALTER TABLE transfers ALTER COLUMN amount TYPE NUMERIC(28, 8);
+
+-- VIEWS --
+-- Balance of every ledger account: credits increase it, debits decrease it.
+CREATE OR REPLACE VIEW ledger_account_balances AS
+SELECT account_type,
+       account_id,
+       SUM(CASE WHEN direction = 'credit' THEN amount ELSE -amount END) AS balance
+FROM ledger_entries
+GROUP BY account_type, account_id;
+
+COMMIT;
@@ -0,0 +1,26 @@
+BEGIN;
+    DROP VIEW IF EXISTS public.ledger_account_balances;
+    DROP INDEX IF EXISTS public.ledger_entries_account_currency_idx;
+    ALTER TABLE public.users ADD COLUMN IF NOT EXISTS balance NUMERIC(28, 8) NOT NULL DEFAULT 0;
+    ALTER TABLE public.users ADD COLUMN IF NOT EXISTS credit_limit NUMERIC(28, 8) NOT NULL DEFAULT 0 CHECK (credit_limit >= 0);
+    UPDATE public.users u
+    SET balance = w.balance, credit_limit = w.credit_limit
+    FROM public.wallets w
+    WHERE w.user_id = u.id AND w.currency = 'EUR';
+    ALTER TABLE public.users ADD CONSTRAINT users_balance_within_credit_limit CHECK (balance + credit_limit >= 0);
+    ALTER TABLE public.reconciliation_drifts DROP COLUMN IF EXISTS currency;
+    ALTER TABLE public.balance_snapshots DROP CONSTRAINT IF EXISTS balance_snapshots_pkey;
+    ALTER TABLE public.balance_snapshots DROP COLUMN IF EXISTS currency;
+    ALTER TABLE public.balance_snapshots ADD PRIMARY KEY (user_id, taken_at);
+    ALTER TABLE public.ledger_entries DROP COLUMN IF EXISTS currency;
+    ALTER TABLE public.transfers DROP COLUMN IF EXISTS currency;
+    ALTER TABLE public.transactions DROP COLUMN IF EXISTS currency;
+    DROP TABLE IF EXISTS public.wallets CASCADE;
+    DROP TABLE IF EXISTS public.currencies CASCADE;
+    CREATE OR REPLACE VIEW public.ledger_account_balances AS
+    SELECT account_type,
+           account_id,
+           SUM(CASE WHEN direction = 'credit' THEN amount ELSE -amount END) AS balance
+    FROM public.ledger_entries
+    GROUP BY account_type, account_id;
+COMMIT;
@@ -0,0 +1,88 @@
+BEGIN;
+
+-- TABLES --
+-- Registry of the currencies balances are held in, with the number of decimal places of their minor unit.
+CREATE TABLE IF NOT EXISTS currencies
+(
+    code       VARCHAR(10) PRIMARY KEY,
+    scale      SMALLINT    NOT NULL CHECK (scale BETWEEN 0 AND 8),
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+-- Balance of a user in a currency. The balance never goes below -credit_limit.
+CREATE TABLE IF NOT EXISTS wallets
+(
+    user_id      UUID           NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    currency     VARCHAR(10)    NOT NULL REFERENCES currencies (code),
+    balance      NUMERIC(28, 8) NOT NULL DEFAULT 0,
+    credit_limit NUMERIC(28, 8) NOT NULL DEFAULT 0 CHECK (credit_limit >= 0),
+    created_at   TIMESTAMPTZ    NOT NULL DEFAULT NOW(),
+    updated_at   TIMESTAMPTZ    NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (user_id, currency),
+    CONSTRAINT wallets_balance_within_credit_limit CHECK (balance + credit_limit >= 0)
+);
+
+-- TRIGGERS --
+CREATE TRIGGER update_timestamp
+    BEFORE UPDATE
+    ON wallets
+    FOR EACH ROW
+EXECUTE PROCEDURE update_timestamp();
+
+-- DATA --
+INSERT INTO currencies (code, scale)
+VALUES ('EUR', 2),
+       ('USD', 2),
+       ('BTC', 8)
+ON CONFLICT (code) DO NOTHING;
+
+-- Balances held so far are EUR balances and move to the EUR wallet of every user.
+INSERT INTO wallets (user_id, currency, balance, credit_limit)
+SELECT id, 'EUR', balance, credit_limit
+FROM users;
+
+-- Every transaction, transfer, ledger entry, snapshot and drift belongs to one currency.
+ALTER TABLE transactions DISABLE TRIGGER update_timestamp;
+ALTER TABLE transactions ADD COLUMN IF NOT EXISTS currency VARCHAR(10) REFERENCES currencies (code);
+UPDATE transactions SET currency = 'EUR';
+ALTER TABLE transactions ALTER COLUMN currency SET NOT NULL;
+ALTER TABLE transactions ENABLE TRIGGER update_timestamp;
+
+ALTER TABLE transfers ADD COLUMN IF NOT EXISTS currency VARCHAR(10) REFERENCES currencies (code);
+UPDATE transfers SET currency = 'EUR';
+ALTER TABLE transfers ALTER COLUMN currency SET NOT NULL;
+
+ALTER TABLE ledger_entries ADD COLUMN IF NOT EXISTS currency VARCHAR(10) REFERENCES currencies (code);
+UPDATE ledger_entries SET currency = 'EUR';
+ALTER TABLE ledger_entries ALTER COLUMN currency SET NOT NULL;
+
+ALTER TABLE balance_snapshots ADD COLUMN IF NOT EXISTS currency VARCHAR(10) REFERENCES currencies (code);
+UPDATE balance_snapshots SET currency = 'EUR';
+ALTER TABLE balance_snapshots ALTER COLUMN currency SET NOT NULL;
+ALTER TABLE balance_snapshots DROP CONSTRAINT IF EXISTS balance_snapshots_pkey;
+ALTER TABLE balance_snapshots ADD PRIMARY KEY (user_id, currency, taken_at);
+
+ALTER TABLE reconciliation_drifts ADD COLUMN IF NOT EXISTS currency VARCHAR(10) REFERENCES currencies (code);
+UPDATE reconciliation_drifts SET currency = 'EUR';
+ALTER TABLE reconciliation_drifts ALTER COLUMN currency SET NOT NULL;
+
+-- The wallets hold the balances now.
+ALTER TABLE users DROP CONSTRAINT IF EXISTS users_balance_within_credit_limit;
+ALTER TABLE users DROP COLUMN IF EXISTS balance;
+ALTER TABLE users DROP COLUMN IF EXISTS credit_limit;
+
+-- VIEWS --
+-- Balance of every ledger account in every currency: credits increase it, debits decrease it.
+DROP VIEW IF EXISTS ledger_account_balances;
+CREATE VIEW ledger_account_balances AS
+SELECT account_type,
+       account_id,
+       currency,
+       SUM(CASE WHEN direction = 'credit' THEN amount ELSE -amount END) AS balance
+FROM ledger_entries
+GROUP BY account_type, account_id, currency;
+
+-- INDEXES --
+CREATE INDEX IF NOT EXISTS ledger_entries_account_currency_idx ON ledger_entries (account_type, account_id, currency, created_at);
+
+COMMIT;
@@ -0,0 +1,7 @@
+BEGIN;
+    ALTER TABLE public.transactions DROP CONSTRAINT IF EXISTS transactions_conversion_complete;
+    ALTER TABLE public.transactions DROP COLUMN IF EXISTS fx_rate;
+    ALTER TABLE public.transactions DROP COLUMN IF EXISTS original_currency;
+    ALTER TABLE public.transactions DROP COLUMN IF EXISTS original_amount;
+    DROP TABLE IF EXISTS public.fx_rates CASCADE;
+COMMIT;
@@ -0,0 +1,26 @@
+BEGIN;
+
+-- TABLES --
+-- Price of one unit of the base currency in the quote currency. A rate is valid from valid_from until the next
+-- rate of the pair, so past rates are kept for audit.
+CREATE TABLE IF NOT EXISTS fx_rates
+(
+    id             BIGSERIAL PRIMARY KEY,
+    base_currency  VARCHAR(10)     NOT NULL REFERENCES currencies (code),
+    quote_currency VARCHAR(10)     NOT NULL REFERENCES currencies (code),
+    rate           NUMERIC(28, 12) NOT NULL CHECK (rate > 0),
+    valid_from     TIMESTAMPTZ     NOT NULL DEFAULT NOW(),
+    created_at     TIMESTAMPTZ     NOT NULL DEFAULT NOW(),
+    CONSTRAINT fx_rates_distinct_currencies CHECK (base_currency <> quote_currency),
+    CONSTRAINT fx_rates_pair_valid_from_unique UNIQUE (base_currency, quote_currency, valid_from)
+);
+
+-- A transaction sent in another currency than its wallet keeps the amount as sent and the applied rate.
+ALTER TABLE transactions ADD COLUMN IF NOT EXISTS original_amount NUMERIC(28, 8);
+ALTER TABLE transactions ADD COLUMN IF NOT EXISTS original_currency VARCHAR(10) REFERENCES currencies (code);
+ALTER TABLE transactions ADD COLUMN IF NOT EXISTS fx_rate NUMERIC(28, 12);
+ALTER TABLE transactions ADD CONSTRAINT transactions_conversion_complete CHECK (
+    (original_amount IS NULL) = (original_currency IS NULL) AND (original_amount IS NULL) = (fx_rate IS NULL)
+);
+
+COMMIT;
@@ -0,0 +1,4 @@
+BEGIN;
+    ALTER TABLE public.transactions DROP CONSTRAINT IF EXISTS transactions_metadata_object;
+    ALTER TABLE public.transactions DROP COLUMN IF EXISTS metadata;
+COMMIT;
@@ -0,0 +1,7 @@
+BEGIN;
+
+-- Correlation data of the sender, such as a game round id or a provider reference. Always a JSON object.
+ALTER TABLE transactions ADD COLUMN IF NOT EXISTS metadata JSONB NOT NULL DEFAULT '{}'::JSONB;
+ALTER TABLE transactions ADD CONSTRAINT transactions_metadata_object CHECK (jsonb_typeof(metadata) = 'object');
+
+COMMIT;
@@ -0,0 +1,5 @@
+BEGIN;
+    DROP INDEX IF EXISTS public.transactions_round_id_idx;
+    ALTER TABLE public.transactions DROP COLUMN IF EXISTS round_id;
+    DROP TABLE IF EXISTS public.rounds CASCADE;
+COMMIT;
@@ -0,0 +1,32 @@
+BEGIN;
+
+-- TABLES --
+-- Game round of a user: a bet and its matching wins sent with the same round id. A round is opened by its
+-- first bet and is closed or rolled back as a unit.
+CREATE TABLE IF NOT EXISTS rounds
+(
+    id          UUID PRIMARY KEY      DEFAULT gen_random_uuid(),
+    round_id    VARCHAR(200) NOT NULL,
+    user_id     UUID         NOT NULL REFERENCES users (id),
+    source_id   UUID         NOT NULL REFERENCES sources (id),
+    currency    VARCHAR(10)  NOT NULL REFERENCES currencies (code),
+    status      VARCHAR(20)  NOT NULL DEFAULT 'open' CHECK (status IN ('open', 'closed', 'rolled_back')),
+    created_at  TIMESTAMPTZ  NOT NULL DEFAULT NOW(),
+    updated_at  TIMESTAMPTZ  NOT NULL DEFAULT NOW(),
+    finished_at TIMESTAMPTZ,
+    CONSTRAINT rounds_user_round_unique UNIQUE (user_id, round_id)
+);
+
+ALTER TABLE transactions ADD COLUMN IF NOT EXISTS round_id UUID REFERENCES rounds (id);
+
+-- INDEXES --
+CREATE INDEX IF NOT EXISTS transactions_round_id_idx ON transactions (round_id);
+
+-- TRIGGERS --
+CREATE TRIGGER update_timestamp
+    BEFORE UPDATE
+    ON rounds
+    FOR EACH ROW
+EXECUTE PROCEDURE update_timestamp();
+
+COMMIT;
@@ -0,0 +1,3 @@
+BEGIN;
+    ALTER TABLE public.transactions DROP COLUMN IF EXISTS source_volume;
+COMMIT;
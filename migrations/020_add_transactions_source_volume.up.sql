@@ -0,0 +1,7 @@
+BEGIN;
+
+-- Amount an applied transaction added to the daily volume of its source, NULL when it was not counted.
+-- Cancelling the transaction takes it off the volume again.
+ALTER TABLE transactions ADD COLUMN IF NOT EXISTS source_volume NUMERIC(28, 8);
+
+COMMIT;
@@ -0,0 +1,3 @@
+BEGIN;
+    -- The backfilled entries keep every balance of the ledger, they are not reverted.
+COMMIT;
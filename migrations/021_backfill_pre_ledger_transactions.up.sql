@@ -0,0 +1,78 @@
+BEGIN;
+
+-- Transactions applied before 002 opened the ledger are only part of the opening balances, so the balance at an
+-- instant before the opening could not be told. Book them at the time they were applied and open the ledger of
+-- their users at the first of them with the balance held before it. Every current balance stays the same.
+-- A transaction cancelled before the ledger opened is also reversed at the time it was last updated, one cancelled
+-- later already has its cancellation entry and was part of the opening balance until then.
+CREATE TEMPORARY TABLE pre_ledger_transactions ON COMMIT DROP AS
+SELECT t.id, t.user_id, t.source_id, t.sign, t.amount, t.currency, t.created_at, t.updated_at,
+       t.status = 'cancelled' AND NOT EXISTS (SELECT 1
+                                              FROM ledger_entries le
+                                              WHERE le.transaction_id = t.id
+                                                AND le.entry_type = 'cancellation') AS cancelled_before_ledger
+FROM transactions t
+WHERE t.status IN ('applied', 'cancelled')
+  AND t.transfer_id IS NULL
+  AND t.created_at < (SELECT MIN(created_at) FROM ledger_entries WHERE entry_type = 'opening')
+  AND NOT EXISTS (SELECT 1
+                  FROM ledger_entries le
+                  WHERE le.transaction_id = t.id
+                    AND le.entry_type = 'transaction');
+
+CREATE TEMPORARY TABLE pre_ledger_openings ON COMMIT DROP AS
+SELECT p.user_id,
+       p.currency,
+       MIN(p.created_at) AS opened_at,
+       COALESCE((SELECT SUM(CASE WHEN le.direction = 'credit' THEN le.amount ELSE -le.amount END)
+                 FROM ledger_entries le
+                 WHERE le.entry_type = 'opening'
+                   AND le.account_type = 'user'
+                   AND le.account_id = p.user_id
+                   AND le.currency = p.currency), 0)
+         - SUM(CASE WHEN p.cancelled_before_ledger THEN 0 ELSE p.amount * p.sign END) AS balance
+FROM pre_ledger_transactions p
+GROUP BY p.user_id, p.currency;
+
+DELETE FROM ledger_entries le
+USING ledger_entries o
+JOIN pre_ledger_openings op ON op.user_id = o.account_id AND op.currency = o.currency
+WHERE o.entry_type = 'opening'
+  AND o.account_type = 'user'
+  AND le.posting_id = o.posting_id;
+
+WITH openings AS MATERIALIZED (
+  SELECT user_id, currency, opened_at, balance, gen_random_uuid() AS posting_id
+  FROM pre_ledger_openings
+  WHERE balance <> 0
+)
+INSERT INTO ledger_entries (posting_id, entry_type, account_type, account_id, direction, amount, currency, created_at)
+SELECT o.posting_id, 'opening', e.account_type, e.account_id, e.direction, ABS(o.balance), o.currency, o.opened_at
+FROM openings o
+CROSS JOIN LATERAL (VALUES
+  ('user', o.user_id, CASE WHEN o.balance > 0 THEN 'credit' ELSE 'debit' END),
+  ('equity', '00000000-0000-0000-0000-000000000000'::UUID, CASE WHEN o.balance > 0 THEN 'debit' ELSE 'credit' END)
+) AS e (account_type, account_id, direction);
+
+INSERT INTO ledger_entries (posting_id, transaction_id, entry_type, account_type, account_id, direction, amount, currency, created_at)
+SELECT p.id, p.id, 'transaction', e.account_type, e.account_id, e.direction, p.amount, p.currency, p.created_at
+FROM pre_ledger_transactions p
+CROSS JOIN LATERAL (VALUES
+  ('user', p.user_id, CASE WHEN p.sign > 0 THEN 'credit' ELSE 'debit' END),
+  ('source', p.source_id, CASE WHEN p.sign > 0 THEN 'debit' ELSE 'credit' END)
+) AS e (account_type, account_id, direction);
+
+WITH cancellation_postings AS MATERIALIZED (
+  SELECT p.id, p.user_id, p.source_id, p.sign, p.amount, p.currency, p.updated_at, gen_random_uuid() AS posting_id
+  FROM pre_ledger_transactions p
+  WHERE p.cancelled_before_ledger
+)
+INSERT INTO ledger_entries (posting_id, transaction_id, entry_type, account_type, account_id, direction, amount, currency, created_at)
+SELECT cp.posting_id, cp.id, 'cancellation', e.account_type, e.account_id, e.direction, cp.amount, cp.currency, cp.updated_at
+FROM cancellation_postings cp
+CROSS JOIN LATERAL (VALUES
+  ('user', cp.user_id, CASE WHEN cp.sign > 0 THEN 'debit' ELSE 'credit' END),
+  ('source', cp.source_id, CASE WHEN cp.sign > 0 THEN 'credit' ELSE 'debit' END)
+) AS e (account_type, account_id, direction);
+
+COMMIT;
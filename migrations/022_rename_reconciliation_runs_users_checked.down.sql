@@ -0,0 +1,3 @@
+BEGIN;
+    ALTER TABLE public.reconciliation_runs RENAME COLUMN wallets_checked TO users_checked;
+COMMIT;
@@ -0,0 +1,6 @@
+BEGIN;
+
+-- Reconciliation checks every wallet since 016, a user with several currencies is checked once per wallet.
+ALTER TABLE reconciliation_runs RENAME COLUMN users_checked TO wallets_checked;
+
+COMMIT;
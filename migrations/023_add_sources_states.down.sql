@@ -0,0 +1,3 @@
+BEGIN;
+    ALTER TABLE public.sources DROP COLUMN IF EXISTS states;
+COMMIT;
@@ -0,0 +1,10 @@
+BEGIN;
+
+-- Transaction states a source may send. Every source sends wins and losses, the other kinds are granted per source.
+ALTER TABLE sources ADD COLUMN IF NOT EXISTS states VARCHAR(32)[] NOT NULL DEFAULT '{win,lost}';
+
+UPDATE sources SET states = '{win,lost,bonus,refund}' WHERE name = 'game';
+UPDATE sources SET states = '{win,lost,deposit,withdrawal,refund,fee}' WHERE name = 'payment';
+UPDATE sources SET states = '{win,lost,bonus,fee,adjustment,adjustment_debit}' WHERE name = 'server';
+
+COMMIT;